@@ -18,11 +18,14 @@ func main() {
 		stdout      = flag.Bool("stdout", false, "Write to stdout instead of file")
 		constName   = flag.String("const", "", "Name of the generated constant")
 		apiName     = flag.String("api-name", "", "Name for the generated API")
-		methodList  = flag.String("methods", "", "Comma-separated list of method names to include")
+		methodList  = flag.String("methods", "", "Comma-separated list of method names (Method or Service.Method) to include")
 		prefix      = flag.String("prefix", "", "Include methods starting with this prefix")
 		suffix      = flag.String("suffix", "", "Include methods ending with this suffix")
 		contains    = flag.String("contains", "", "Include methods containing this string")
 		mapOutput   = flag.Bool("map", false, "Generate map[string]string instead of single JSON string")
+		clientMode  = flag.Bool("client", false, "Generate a typed Go client instead of API descriptions")
+		serviceName = flag.String("service", "", "Service name for -client, if not the receiver type name")
+		rpcOnly     = flag.Bool("rpc", false, "Include only methods with the signature of a service method")
 		help        = flag.Bool("help", false, "Show help")
 	)
 
@@ -40,9 +43,16 @@ func main() {
 	if *stdout && *outputFile != "" {
 		log.Fatal("cannot specify both -out and -stdout, choose one")
 	}
-	if *constName == "" {
+	if *clientMode {
+		if *mapOutput {
+			log.Fatal("cannot specify both -client and -map, choose one")
+		}
+	} else if *constName == "" {
 		log.Fatal("constant name (-const) is required")
 	}
+	if *serviceName != "" && !*clientMode {
+		log.Fatal("-service can only be used with -client")
+	}
 	if *packagePath == "" && *filePath == "" {
 		log.Fatal("either package path (-package) or file path (-file) is required")
 	}
@@ -95,7 +105,9 @@ func main() {
 	}
 
 	// Set generator type
-	if *mapOutput {
+	if *clientMode {
+		config = config.WithGenerator(apigen.NewGoClientGenerator(packageName, *serviceName))
+	} else if *mapOutput {
 		config = config.WithGenerator(apigen.NewGoMapGenerator(packageName, *constName))
 	} else {
 		config = config.WithGenerator(apigen.NewGoConstGenerator(packageName, *constName))
@@ -115,6 +127,9 @@ func main() {
 	if *contains != "" {
 		config = config.WithMethodFilter(apigen.FilterByContainsFunc(*contains))
 	}
+	if *rpcOnly {
+		config = config.WithMethodFilter(apigen.FilterByServiceSignatureFunc())
+	}
 
 	// Generate
 	err := apigen.Generate(config)
@@ -123,7 +138,11 @@ func main() {
 	}
 
 	if !*stdout {
-		fmt.Printf("Generated %s with constant %s\n", *outputFile, *constName)
+		if *clientMode {
+			fmt.Printf("Generated %s with typed clients\n", *outputFile)
+		} else {
+			fmt.Printf("Generated %s with constant %s\n", *outputFile, *constName)
+		}
 	}
 }
 
@@ -222,13 +241,16 @@ func showHelp() {
 	fmt.Println("  -file string       Go file to analyze (cannot use with -package)")
 	fmt.Println("  -out string        Output Go file path (cannot use with -stdout)")
 	fmt.Println("  -stdout            Write to stdout instead of file")
-	fmt.Println("  -const string      Name of the generated constant (required)")
+	fmt.Println("  -const string      Name of the generated constant (required unless -client)")
 	fmt.Println("  -api-name string   Name for the generated API")
-	fmt.Println("  -methods string    Comma-separated list of method names to include")
+	fmt.Println("  -methods string    Comma-separated list of method names (Method or Service.Method) to include")
 	fmt.Println("  -prefix string     Include methods starting with this prefix")
 	fmt.Println("  -suffix string     Include methods ending with this suffix")
 	fmt.Println("  -contains string   Include methods containing this string")
 	fmt.Println("  -map               Generate map[string]string instead of single JSON string")
+	fmt.Println("  -client            Generate a typed Go client instead of API descriptions")
+	fmt.Println("  -service string    Service name for -client, if not the receiver type name")
+	fmt.Println("  -rpc               Include only methods with the signature of a service method")
 	fmt.Println("  -help              Show this help")
 	fmt.Println()
 	fmt.Println("EXAMPLES:")
@@ -241,6 +263,12 @@ func showHelp() {
 	fmt.Println("  # Generate from file with method list")
 	fmt.Println("  go run github.com/pangobit/agent-sdk/cmd/apigen -file=handlers.go -methods=Method1,Method2 -out=api_gen.go -const=APIJSON")
 	fmt.Println()
+	fmt.Println("  # Generate descriptions of the service methods in a package, keyed by Service.Method")
+	fmt.Println("  go run github.com/pangobit/agent-sdk/cmd/apigen -package=./pkg/notes -rpc -map -out=api_gen.go -const=APIDefinitions")
+	fmt.Println()
+	fmt.Println("  # Generate typed clients for the services in a file")
+	fmt.Println("  go run github.com/pangobit/agent-sdk/cmd/apigen -file=notes.go -client -out=notes_client_gen.go")
+	fmt.Println()
 	fmt.Println("  # Use with go:generate")
 	fmt.Println("  //go:generate go run github.com/pangobit/agent-sdk/cmd/apigen -file=main.go -prefix=Handle -out=api_gen.go -const=APIJSON")
 }
@@ -2,7 +2,8 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 
 	agentsdk "github.com/pangobit/agent-sdk/pkg"
 )
@@ -52,8 +53,10 @@ func (u *UserService) CreateUser(req CreateUserRequest, reply *User) error {
 }
 
 func main() {
-	// Create default server
+	// Create default server, logging registrations, requests and calls as JSON
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
 	server := agentsdk.NewDefaultServer()
+	server.SetLogger(logger)
 
 	// Register services - these are the actual Go types with methods
 	// that can be called via JSON-RPC at the /execute endpoint
@@ -111,5 +114,8 @@ func main() {
 		}'`)
 
 	// Start the server
-	log.Fatal(server.ListenAndServe(":8080"))
+	if err := server.ListenAndServe(":8080"); err != nil {
+		logger.Error("server stopped", slog.Any("error", err))
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	agentsdk "github.com/pangobit/agent-sdk/pkg"
+	"github.com/pangobit/agent-sdk/pkg/server/mcp"
+	"github.com/pangobit/agent-sdk/pkg/server/tools"
+)
+
+// HelloService is a regular agent-sdk service, also served over MCP
+type HelloService struct{}
+
+type HelloRequest struct {
+	Name string `json:"name" desc:"The name to greet"`
+}
+
+type HelloResponse struct {
+	Message string `json:"message"`
+}
+
+func (h *HelloService) Hello(req HelloRequest, reply *HelloResponse) error {
+	reply.Message = "Hello, " + req.Name
+	return nil
+}
+
+func main() {
+	stdio := flag.Bool("stdio", false, "serve MCP over stdin/stdout instead of HTTP")
+	flag.Parse()
+
+	server := agentsdk.NewDefaultServer()
+
+	// Register once; the service is available at /execute and as an MCP tool
+	err := agentsdk.RegisterAndDescribeService(server, &HelloService{},
+		tools.WithMethodDescription("Hello", "Sends a greeting message to the specified name"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mcpTransport, err := agentsdk.NewMCPTransport(server, mcp.WithServerInfo("hello-agent", "1.0.0"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *stdio {
+		// stdout carries the protocol, so diagnostics go to stderr
+		fmt.Fprintln(os.Stderr, "serving MCP on stdio")
+		if err := mcpTransport.ServeStdio(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Println("serving MCP on http://localhost:8080/mcp")
+	fmt.Println(`curl -X POST http://localhost:8080/mcp \
+		-H "Content-Type: application/json" \
+		-d '{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "HelloService.Hello", "arguments": {"name": "World"}}}'`)
+	log.Fatal(mcpTransport.ListenAndServe(":8080"))
+}
@@ -0,0 +1,49 @@
+package sqlitedb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name          string
+		schema        string
+		expectedError bool
+	}{
+		{
+			name:   "creates_tables",
+			schema: "CREATE TABLE IF NOT EXISTS notes (id INTEGER PRIMARY KEY, text TEXT)",
+		},
+		{
+			name:          "invalid_schema",
+			schema:        "CREATE TABLE",
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			db, err := Open(path, tt.schema)
+			if tt.expectedError {
+				if err == nil {
+					db.Close()
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer db.Close()
+
+			if _, err := db.Exec("INSERT INTO notes (text) VALUES ('hi')"); err != nil {
+				t.Errorf("failed to write to created table: %v", err)
+			}
+			if open := db.Stats().MaxOpenConnections; open != 1 {
+				t.Errorf("expected a single connection, got %d", open)
+			}
+		})
+	}
+}
@@ -6,17 +6,25 @@
 package agentsdk
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
 	"github.com/pangobit/agent-sdk/pkg/server"
 	"github.com/pangobit/agent-sdk/pkg/server/http"
+	"github.com/pangobit/agent-sdk/pkg/server/jobs"
+	"github.com/pangobit/agent-sdk/pkg/server/mcp"
+	"github.com/pangobit/agent-sdk/pkg/server/metrics"
 	"github.com/pangobit/agent-sdk/pkg/server/tools"
 )
 
-// NewDefaultServer creates a new server with the default HTTP transport and tool functionality
-func NewDefaultServer() *server.Server {
+// NewDefaultServer creates a new server with the default HTTP transport and tool functionality.
+// opts are applied to the HTTP transport after the defaults, e.g. to require authentication:
+//
+//	agentsdk.NewDefaultServer(http.WithAuthentication(auth.NewBearerAuthenticator(tokens)))
+func NewDefaultServer(opts ...http.HTTPTransportOpts) *server.Server {
 	// Create tool service for method registration
 	toolService := tools.NewToolService()
 
@@ -26,8 +34,23 @@ func NewDefaultServer() *server.Server {
 	// Create method executor for method execution
 	methodExecutor := tools.NewJSONRPCMethodExecutor(jsonrpcServer)
 
-	// Create method execution handler
-	methodHandler := tools.NewMethodExecutionHandler(methodExecutor)
+	// Create the interceptor chain shared by the server and its handlers;
+	// metrics are recorded first, so that they count calls rejected by tool policies
+	toolMetrics := metrics.NewMetrics()
+	interceptors := server.NewInterceptors()
+	interceptors.Use(toolMetrics.Interceptor(), toolService.AuthorizationInterceptor())
+
+	// Create job manager for asynchronous calls; jobs are kept in memory
+	jobManager := jobs.NewManager(jobs.NewMemoryStore(), jobs.WithRetention(24*time.Hour))
+
+	// Create method execution handler; params are validated against the described tools
+	// and keyed requests are replayed for a day
+	methodHandler := tools.NewMethodExecutionHandler(methodExecutor,
+		tools.WithSchemaValidation(toolService),
+		tools.WithInterceptors(interceptors),
+		tools.WithJobs(jobManager),
+		tools.WithIdempotency(24*time.Hour),
+	)
 
 	// Create HTTP transport with tool handler and method handler
 	httpOpts := []http.HTTPTransportOpts{
@@ -36,16 +59,21 @@ func NewDefaultServer() *server.Server {
 		http.WithWriteDeadline(10 * time.Second),
 		http.WithToolHandler(toolService.ToolDiscoveryHandler()),
 		http.WithMethodHandler(methodHandler),
+		http.WithJobHandler(jobManager.Handler()),
+		http.WithMetricsHandler(toolMetrics.Handler()),
 	}
-	httpTransport := http.NewHTTPTransport(httpOpts...)
+	httpTransport := http.NewHTTPTransport(append(httpOpts, opts...)...)
 
 	// Create server with HTTP transport, tool registry, and method executor
 	serverOpts := []server.ServerOpts{
 		server.WithTransport(httpTransport),
 		server.WithToolRegistry(toolService),
 		server.WithMethodExecutor(methodExecutor),
+		server.WithInterceptors(interceptors),
 	}
-	return server.NewServer(serverOpts...)
+	srv := server.NewServer(serverOpts...)
+	srv.OnStop(jobManager.Shutdown)
+	return srv
 }
 
 // NewServer creates a new server with HTTP transport
@@ -56,22 +84,141 @@ func NewServer(opts ...server.ServerOpts) *server.Server {
 // RegisterService registers a service with the server's method executor.
 // A service is a Go struct with methods that can be called via JSON-RPC.
 // The service will be available for method execution at the /execute endpoint.
+// Methods may take a context.Context as their first argument to observe cancellation,
+// and opts such as tools.WithMethodTimeout bound how long they may run.
 //
 // Example:
 //
 //	type HelloService struct{}
 //	func (h *HelloService) Hello(req HelloRequest, reply *HelloResponse) error { ... }
-//	agentsdk.RegisterService(server, &HelloService{})
-func RegisterService(server *server.Server, service any) error {
+//	agentsdk.RegisterService(server, &HelloService{}, tools.WithMethodTimeout("Hello", 5*time.Second))
+func RegisterService(server *server.Server, service any, opts ...tools.ServiceOpts) error {
 	methodExecutor := server.GetMethodExecutor()
 	if methodExecutor != nil {
-		if registry, ok := methodExecutor.(interface{ RegisterService(any) error }); ok {
-			return registry.RegisterService(service)
+		if registry, ok := methodExecutor.(interface {
+			RegisterService(any, ...tools.ServiceOpts) error
+		}); ok {
+			return registry.RegisterService(service, opts...)
 		}
 	}
 	return fmt.Errorf("no method executor configured")
 }
 
+// RegisterAndDescribeService registers a service like RegisterService and publishes a tool
+// description for each of its methods at the /tools endpoint in one step.
+// Parameters and return values are described with JSON Schemas generated from the
+// request and response types, so they cannot drift from the code. Struct tags refine
+// the schema: `desc:"..."` adds a description, `enum:"a,b"` restricts values and fields
+// without omitempty are required. Method descriptions come from tools.WithMethodDescription.
+//
+// Example:
+//
+//	type HelloRequest struct {
+//	    Name string `json:"name" desc:"The name to greet"`
+//	}
+//	agentsdk.RegisterAndDescribeService(server, &HelloService{},
+//	    tools.WithMethodDescription("Hello", "Sends a greeting message to the specified name"))
+func RegisterAndDescribeService(server *server.Server, service any, opts ...tools.ServiceOpts) error {
+	if err := RegisterService(server, service, opts...); err != nil {
+		return err
+	}
+
+	if describer, ok := server.GetToolRegistry().(interface {
+		DescribeService(any, ...tools.ServiceOpts) error
+	}); ok {
+		return describer.DescribeService(service, opts...)
+	}
+	return fmt.Errorf("tool registry does not support describing services")
+}
+
+// NewMCPTransport exposes the services and tool descriptions of server over the
+// Model Context Protocol, so MCP-only clients can use them without registering anything twice.
+// The returned transport serves stdio with ServeStdio, or streamable HTTP with
+// ListenAndServe or HTTPHandler.
+//
+// Example:
+//
+//	mcpTransport, err := agentsdk.NewMCPTransport(server, mcp.WithServerInfo("my-agent", "1.0.0"))
+//	if err != nil { ... }
+//	log.Fatal(mcpTransport.ServeStdio(context.Background()))
+func NewMCPTransport(server *server.Server, opts ...mcp.MCPTransportOpts) (*mcp.MCPTransport, error) {
+	toolLister, ok := server.GetToolRegistry().(mcp.ToolLister)
+	if !ok {
+		return nil, fmt.Errorf("tool registry does not support listing tools")
+	}
+	methodExecutor := server.GetMethodExecutor()
+	if methodExecutor == nil {
+		return nil, fmt.Errorf("no method executor configured")
+	}
+	opts = append([]mcp.MCPTransportOpts{mcp.WithInterceptors(server.GetInterceptors())}, opts...)
+	return mcp.NewMCPTransport(toolLister, methodExecutor, opts...), nil
+}
+
+// ServeJSONRPC serves the services of server as JSON-RPC 2.0 over TCP on listener.
+// Calls run through the same interceptors and parameter validation as /execute.
+//
+// Example:
+//
+//	listener, err := net.Listen("tcp", ":9090")
+//	if err != nil { ... }
+//	go agentsdk.ServeJSONRPC(server, listener)
+func ServeJSONRPC(server *server.Server, listener net.Listener) error {
+	methodExecutor := server.GetMethodExecutor()
+	if methodExecutor == nil {
+		return fmt.Errorf("no method executor configured")
+	}
+
+	handlerOpts := []tools.MethodExecutionHandlerOpts{tools.WithInterceptors(server.GetInterceptors())}
+	if schemas, ok := server.GetToolRegistry().(tools.SchemaProvider); ok {
+		handlerOpts = append(handlerOpts, tools.WithSchemaValidation(schemas))
+	}
+	methodHandler := tools.NewMethodExecutionHandler(methodExecutor, handlerOpts...)
+
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.SetDispatcher(methodHandler.Dispatcher())
+	return rpcServer.Serve(listener)
+}
+
+// ProxyMCPServer re-exports the tools of a remote MCP server through server.
+// Each remote tool is described at /tools as "namespace.tool_name" (dots in tool names
+// become underscores) and calls to it at /execute are forwarded over client.
+//
+// Example:
+//
+//	client, err := mcp.NewCommandClient(exec.Command("legacy-mcp-server"))
+//	if err != nil { ... }
+//	defer client.Close()
+//	err = agentsdk.ProxyMCPServer(ctx, server, client, "Legacy", tools.WithTimeout(30*time.Second))
+func ProxyMCPServer(ctx context.Context, server *server.Server, client *mcp.Client, namespace string, opts ...tools.ServiceOpts) error {
+	registry, ok := server.GetMethodExecutor().(mcp.HandlerRegistry)
+	if !ok {
+		return fmt.Errorf("method executor does not support service handlers")
+	}
+	if server.GetToolRegistry() == nil {
+		return fmt.Errorf("tool registry not configured")
+	}
+	return mcp.NewProxy(client, namespace).Register(ctx, server.GetToolRegistry(), registry, opts...)
+}
+
+// SetToolPolicy restricts who may call a method ("ServiceName.MethodName") or every method
+// of a service ("ServiceName"). Calls from principals that do not satisfy the policy are
+// rejected with a Forbidden error, or Unauthorized if the caller is not authenticated, and
+// the tools are hidden from them at /tools and in MCP tools/list.
+//
+// Example:
+//
+//	agentsdk.SetToolPolicy(server, "NoteService", tools.Policy{Scopes: []string{"notes:read"}})
+//	agentsdk.SetToolPolicy(server, "NoteService.Delete", tools.Policy{Roles: []string{"admin"}})
+func SetToolPolicy(server *server.Server, name string, policy tools.Policy) error {
+	registry, ok := server.GetToolRegistry().(interface {
+		SetPolicy(string, tools.Policy) error
+	})
+	if !ok {
+		return fmt.Errorf("tool registry does not support policies")
+	}
+	return registry.SetPolicy(name, policy)
+}
+
 // DescribeServiceMethod creates a tool description for a service method.
 // This allows clients to discover what methods are available and what parameters they require.
 // The description will be available at the /tools endpoint for tool discovery.
@@ -147,6 +147,12 @@ func FilterByListFunc(names []string) MethodFilter {
 	return &listFilter{names: names}
 }
 
+// FilterByServiceSignatureFunc creates a filter that selects methods with the signature of a
+// service method
+func FilterByServiceSignatureFunc() MethodFilter {
+	return &serviceSignatureFilter{}
+}
+
 type prefixFilter struct {
 	prefix string
 }
@@ -191,6 +197,15 @@ func (f *listFilter) Filter(methods []RawMethod) []RawMethod {
 	return FilterByList(methods, f.names)
 }
 
+type serviceSignatureFilter struct{}
+
+func (f *serviceSignatureFilter) Filter(methods []RawMethod) []RawMethod {
+	if f == nil {
+		return methods
+	}
+	return FilterByServiceSignature(methods)
+}
+
 // Config holds the configuration for API generation
 type Config struct {
 	Input       InputSource    // Where to read source code from
@@ -159,6 +159,7 @@ func (p *DefaultParser) parseTypeDeclarations(genDecl *ast.GenDecl) error {
 func (p *DefaultParser) parseMethod(funcDecl *ast.FuncDecl) (RawMethod, error) {
 	method := RawMethod{
 		Name:     funcDecl.Name.Name,
+		Receiver: receiverTypeName(funcDecl.Recv),
 		Position: p.fset.Position(funcDecl.Pos()),
 		Doc:      p.extractDocComments(funcDecl),
 	}
@@ -174,9 +175,47 @@ func (p *DefaultParser) parseMethod(funcDecl *ast.FuncDecl) (RawMethod, error) {
 		}
 	}
 
+	// Parse results, keeping unnamed ones
+	if funcDecl.Type.Results != nil {
+		for _, field := range funcDecl.Type.Results.List {
+			if len(field.Names) == 0 {
+				method.Results = append(method.Results, RawParam{Type: field.Type})
+				continue
+			}
+			results, err := p.parseFieldList(field)
+			if err != nil {
+				return method, fmt.Errorf("failed to parse results: %w", err)
+			}
+			method.Results = append(method.Results, results...)
+		}
+	}
+
 	return method, nil
 }
 
+// receiverTypeName returns the type name of a method receiver, without "*" or type
+// parameters, or "" for a function
+func receiverTypeName(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+
+	expr := recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = e.X
+	case *ast.IndexListExpr:
+		expr = e.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
 // parseFieldList converts AST field list to RawParams
 func (p *DefaultParser) parseFieldList(field *ast.Field) ([]RawParam, error) {
 	var params []RawParam
@@ -252,10 +291,12 @@ func (t *DefaultTransformer) Transform(methods []RawMethod) ([]EnrichedMethod, e
 func (t *DefaultTransformer) transformMethod(method RawMethod) (EnrichedMethod, error) {
 	enriched := EnrichedMethod{
 		Name:        method.Name,
+		Receiver:    method.Receiver,
 		Description: strings.Join(method.Doc, " "),
 	}
 
-	for _, param := range method.Params {
+	params, returns := splitReturns(method)
+	for _, param := range params {
 		enrichedParam, err := t.transformParam(param)
 		if err != nil {
 			return enriched, fmt.Errorf("failed to transform parameter %s: %w", param.Name, err)
@@ -263,9 +304,42 @@ func (t *DefaultTransformer) transformMethod(method RawMethod) (EnrichedMethod,
 		enriched.Parameters = append(enriched.Parameters, enrichedParam)
 	}
 
+	if returns != nil {
+		enrichedReturns, err := t.transformParam(*returns)
+		if err != nil {
+			return enriched, fmt.Errorf("failed to transform return type: %w", err)
+		}
+		enriched.Returns = &enrichedReturns
+	}
+
 	return enriched, nil
 }
 
+// splitReturns separates the request parameters of a method from what it returns. For a
+// service method the only parameter is its request, and it returns the type its reply
+// argument points to; for a function, its only result other than error. Other methods
+// return nil.
+func splitReturns(method RawMethod) ([]RawParam, *RawParam) {
+	if request, reply, ok := serviceArgs(method); ok {
+		reply.Type = reply.Type.(*ast.StarExpr).X
+		return []RawParam{request}, &reply
+	}
+	if method.Receiver != "" {
+		return method.Params, nil
+	}
+
+	var results []RawParam
+	for _, result := range method.Results {
+		if exprString(result.Type) != "error" {
+			results = append(results, result)
+		}
+	}
+	if len(results) != 1 {
+		return method.Params, nil
+	}
+	return method.Params, &results[0]
+}
+
 // transformParam converts a RawParam to an EnrichedParam
 func (t *DefaultTransformer) transformParam(param RawParam) (EnrichedParam, error) {
 	parsedType, err := t.parseType(param.Type)
@@ -734,7 +808,8 @@ func (g *GoMapGenerator) Generate(desc APIDescription) (GeneratedContent, error)
 	}, nil
 }
 
-// NewDescription creates an API description from enriched methods
+// NewDescription creates an API description from enriched methods. Methods are keyed by the
+// name they are called by, Service.Method, and functions by their plain name.
 func NewDescription(apiName string, methods []EnrichedMethod) (APIDescription, error) {
 	desc := APIDescription{
 		APIName: apiName,
@@ -748,87 +823,104 @@ func NewDescription(apiName string, methods []EnrichedMethod) (APIDescription, e
 		}
 
 		for _, param := range method.Parameters {
-			typeStr, err := typeToString(param.Type)
+			paramInfo, err := newParameterInfo(param)
+			if err != nil {
+				return desc, err
+			}
+			methodDesc.Parameters[param.Name] = paramInfo
+		}
+
+		if method.Returns != nil {
+			returns, err := newParameterInfo(*method.Returns)
 			if err != nil {
-				return desc, fmt.Errorf("failed to stringify parameter %s type: %w", param.Name, err)
+				return desc, fmt.Errorf("failed to describe return type: %w", err)
 			}
+			methodDesc.Returns = &returns
+		}
+
+		desc.Methods[method.QualifiedName()] = methodDesc
+	}
+
+	return desc, nil
+}
+
+// newParameterInfo describes an enriched parameter
+func newParameterInfo(param EnrichedParam) (ParameterInfo, error) {
+	typeStr, err := typeToString(param.Type)
+	if err != nil {
+		return ParameterInfo{}, fmt.Errorf("failed to stringify parameter %s type: %w", param.Name, err)
+	}
+
+	paramInfo := ParameterInfo{
+		Type: typeStr,
+	}
 
-			paramInfo := ParameterInfo{
-				Type: typeStr,
+	// Use resolved type information if available
+	if param.ResolvedType != nil {
+		fields, err := buildFieldInfoFromResolved(param.ResolvedType)
+		if err != nil {
+			return paramInfo, fmt.Errorf("failed to build resolved field info for parameter %s: %w", param.Name, err)
+		}
+		paramInfo.Fields = fields
+
+		// Handle the parameter type itself being a slice or map
+		if param.ResolvedType.IsSlice && param.ResolvedType.KeyType != nil {
+			elementTypeStr, err := resolvedTypeToString(param.ResolvedType.KeyType)
+			if err != nil {
+				return paramInfo, fmt.Errorf("failed to stringify element type for parameter %s: %w", param.Name, err)
 			}
 
-			// Use resolved type information if available
-			if param.ResolvedType != nil {
-				fields, err := buildFieldInfoFromResolved(param.ResolvedType)
+			elementTypeInfo := FieldInfo{
+				Type: elementTypeStr,
+			}
+			elementTypeInfo.Fields, err = buildFieldInfoFromResolved(param.ResolvedType.KeyType)
+			if err != nil {
+				return paramInfo, fmt.Errorf("failed to build element field info for parameter %s: %w", param.Name, err)
+			}
+			// For parameter-level slices/maps, we need to return this as the main info
+			paramInfo.ElementType = &elementTypeInfo
+		} else if param.ResolvedType.IsMap {
+			if param.ResolvedType.KeyType != nil {
+				keyTypeStr, err := resolvedTypeToString(param.ResolvedType.KeyType)
 				if err != nil {
-					return desc, fmt.Errorf("failed to build resolved field info for parameter %s: %w", param.Name, err)
+					return paramInfo, fmt.Errorf("failed to stringify key type for parameter %s: %w", param.Name, err)
 				}
-				paramInfo.Fields = fields
-
-				// Handle the parameter type itself being a slice or map
-				if param.ResolvedType.IsSlice && param.ResolvedType.KeyType != nil {
-					elementTypeStr, err := resolvedTypeToString(param.ResolvedType.KeyType)
-					if err != nil {
-						return desc, fmt.Errorf("failed to stringify element type for parameter %s: %w", param.Name, err)
-					}
 
-					elementTypeInfo := FieldInfo{
-						Type: elementTypeStr,
-					}
-					elementTypeInfo.Fields, err = buildFieldInfoFromResolved(param.ResolvedType.KeyType)
-					if err != nil {
-						return desc, fmt.Errorf("failed to build element field info for parameter %s: %w", param.Name, err)
-					}
-					// For parameter-level slices/maps, we need to return this as the main info
-					paramInfo.ElementType = &elementTypeInfo
-				} else if param.ResolvedType.IsMap {
-					if param.ResolvedType.KeyType != nil {
-						keyTypeStr, err := resolvedTypeToString(param.ResolvedType.KeyType)
-						if err != nil {
-							return desc, fmt.Errorf("failed to stringify key type for parameter %s: %w", param.Name, err)
-						}
-
-						keyTypeInfo := FieldInfo{
-							Type: keyTypeStr,
-						}
-						keyTypeInfo.Fields, err = buildFieldInfoFromResolved(param.ResolvedType.KeyType)
-						if err != nil {
-							return desc, fmt.Errorf("failed to build key field info for parameter %s: %w", param.Name, err)
-						}
-						paramInfo.KeyType = &keyTypeInfo
-					}
-					if param.ResolvedType.ValueType != nil {
-						valueTypeStr, err := resolvedTypeToString(param.ResolvedType.ValueType)
-						if err != nil {
-							return desc, fmt.Errorf("failed to stringify value type for parameter %s: %w", param.Name, err)
-						}
-
-						valueTypeInfo := FieldInfo{
-							Type: valueTypeStr,
-						}
-						valueTypeInfo.Fields, err = buildFieldInfoFromResolved(param.ResolvedType.ValueType)
-						if err != nil {
-							return desc, fmt.Errorf("failed to build value field info for parameter %s: %w", param.Name, err)
-						}
-						paramInfo.ValueType = &valueTypeInfo
-					}
+				keyTypeInfo := FieldInfo{
+					Type: keyTypeStr,
 				}
-			} else {
-				// Fallback to old logic
-				fields, err := buildFieldInfo(param.Type)
+				keyTypeInfo.Fields, err = buildFieldInfoFromResolved(param.ResolvedType.KeyType)
 				if err != nil {
-					return desc, fmt.Errorf("failed to build field info for parameter %s: %w", param.Name, err)
+					return paramInfo, fmt.Errorf("failed to build key field info for parameter %s: %w", param.Name, err)
 				}
-				paramInfo.Fields = fields
+				paramInfo.KeyType = &keyTypeInfo
 			}
+			if param.ResolvedType.ValueType != nil {
+				valueTypeStr, err := resolvedTypeToString(param.ResolvedType.ValueType)
+				if err != nil {
+					return paramInfo, fmt.Errorf("failed to stringify value type for parameter %s: %w", param.Name, err)
+				}
 
-			methodDesc.Parameters[param.Name] = paramInfo
+				valueTypeInfo := FieldInfo{
+					Type: valueTypeStr,
+				}
+				valueTypeInfo.Fields, err = buildFieldInfoFromResolved(param.ResolvedType.ValueType)
+				if err != nil {
+					return paramInfo, fmt.Errorf("failed to build value field info for parameter %s: %w", param.Name, err)
+				}
+				paramInfo.ValueType = &valueTypeInfo
+			}
 		}
-
-		desc.Methods[method.Name] = methodDesc
+	} else {
+		// Fallback to old logic
+		fields, err := buildFieldInfo(param.Type)
+		if err != nil {
+			return paramInfo, fmt.Errorf("failed to build field info for parameter %s: %w", param.Name, err)
+		}
+		paramInfo.Fields = fields
 	}
 
-	return desc, nil
+	return paramInfo, nil
 }
 
 // buildFieldInfo recursively builds field information for a parsed type
@@ -1103,7 +1195,8 @@ func FilterByContains(methods []RawMethod, substr string) []RawMethod {
 	return filtered
 }
 
-// FilterByList filters methods by explicit list
+// FilterByList filters methods by explicit list. Names may be plain (Method) or
+// qualified by their service (Service.Method).
 func FilterByList(methods []RawMethod, names []string) []RawMethod {
 	nameSet := make(map[string]bool)
 	for _, name := range names {
@@ -1112,13 +1205,73 @@ func FilterByList(methods []RawMethod, names []string) []RawMethod {
 
 	var filtered []RawMethod
 	for _, method := range methods {
-		if nameSet[method.Name] {
+		if nameSet[method.Name] || nameSet[method.QualifiedName()] {
 			filtered = append(filtered, method)
 		}
 	}
 	return filtered
 }
 
+// FilterByServiceSignature filters methods that can be called as Service.Method: exported
+// methods with the net/rpc signature func(Request, *Reply) error, optionally taking a
+// context.Context first and a server.Stream before the reply
+func FilterByServiceSignature(methods []RawMethod) []RawMethod {
+	var filtered []RawMethod
+	for _, method := range methods {
+		if isServiceMethod(method) {
+			filtered = append(filtered, method)
+		}
+	}
+	return filtered
+}
+
+// isServiceMethod reports whether a method has the signature of a service method
+func isServiceMethod(method RawMethod) bool {
+	_, _, ok := serviceArgs(method)
+	return ok
+}
+
+// serviceArgs returns the request and reply parameters of a service method, leaving out
+// the context.Context and server.Stream the server passes itself. ok is false for methods
+// without the signature of a service method.
+func serviceArgs(method RawMethod) (request, reply RawParam, ok bool) {
+	if method.Receiver == "" || !token.IsExported(method.Name) {
+		return RawParam{}, RawParam{}, false
+	}
+	if len(method.Results) != 1 || exprString(method.Results[0].Type) != "error" {
+		return RawParam{}, RawParam{}, false
+	}
+
+	params := method.Params
+	if len(params) > 0 && exprString(params[0].Type) == "context.Context" {
+		params = params[1:]
+	}
+	if len(params) == 3 && exprString(params[1].Type) == "server.Stream" {
+		params = []RawParam{params[0], params[2]}
+	}
+	if len(params) != 2 {
+		return RawParam{}, RawParam{}, false
+	}
+	if _, isPointer := params[1].Type.(*ast.StarExpr); !isPointer {
+		return RawParam{}, RawParam{}, false
+	}
+	return params[0], params[1], true
+}
+
+// exprString returns the source form of a simple type expression such as "error" or
+// "context.Context", or "" for other expressions
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := e.X.(*ast.Ident); ok {
+			return pkg.Name + "." + e.Sel.Name
+		}
+	}
+	return ""
+}
+
 const goConstTemplate = `// Code generated by apigen; DO NOT EDIT.
 // This file contains the API description for {{.PackageName}}
 
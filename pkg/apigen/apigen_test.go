@@ -257,6 +257,247 @@ func TestFilterFunctions(t *testing.T) {
 	}
 }
 
+// TestParser_Receivers tests that methods carry their receiver type and results
+func TestParser_Receivers(t *testing.T) {
+	parser := apigen.NewParser()
+	methods, err := parser.ParseSingleFile(filepath.Join("testdata", "service_methods.go"))
+	if err != nil {
+		t.Fatalf("failed to parse file: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		wantReceiver  string
+		wantQualified string
+		wantResults   int
+	}{
+		{
+			name:          "pointer_receiver",
+			method:        "Add",
+			wantReceiver:  "NoteService",
+			wantQualified: "NoteService.Add",
+			wantResults:   1,
+		},
+		{
+			name:          "value_receiver",
+			method:        "Reset",
+			wantReceiver:  "NoteService",
+			wantQualified: "NoteService.Reset",
+			wantResults:   1,
+		},
+		{
+			name:          "function",
+			method:        "HandlePing",
+			wantReceiver:  "",
+			wantQualified: "HandlePing",
+			wantResults:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found *apigen.RawMethod
+			for i := range methods {
+				if methods[i].Name == tt.method {
+					found = &methods[i]
+				}
+			}
+			if found == nil {
+				t.Fatalf("method %s not found", tt.method)
+			}
+
+			if found.Receiver != tt.wantReceiver {
+				t.Errorf("expected receiver %q, got %q", tt.wantReceiver, found.Receiver)
+			}
+			if found.QualifiedName() != tt.wantQualified {
+				t.Errorf("expected qualified name %q, got %q", tt.wantQualified, found.QualifiedName())
+			}
+			if len(found.Results) != tt.wantResults {
+				t.Errorf("expected %d results, got %d", tt.wantResults, len(found.Results))
+			}
+		})
+	}
+}
+
+// TestServiceMethodFilters tests filtering of service methods
+func TestServiceMethodFilters(t *testing.T) {
+	parser := apigen.NewParser()
+	methods, err := parser.ParseSingleFile(filepath.Join("testdata", "service_methods.go"))
+	if err != nil {
+		t.Fatalf("failed to parse file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filter   func([]apigen.RawMethod) []apigen.RawMethod
+		expected []string
+	}{
+		{
+			name:     "FilterByServiceSignature",
+			filter:   apigen.FilterByServiceSignature,
+			expected: []string{"NoteService.Add", "NoteService.Search", "NoteService.Import"},
+		},
+		{
+			name: "FilterByList_qualified",
+			filter: func(m []apigen.RawMethod) []apigen.RawMethod {
+				return apigen.FilterByList(m, []string{"NoteService.Search", "HandlePing"})
+			},
+			expected: []string{"NoteService.Search", "HandlePing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := tt.filter(methods)
+			if len(filtered) != len(tt.expected) {
+				t.Fatalf("expected %d methods, got %d", len(tt.expected), len(filtered))
+			}
+
+			for i, method := range filtered {
+				if method.QualifiedName() != tt.expected[i] {
+					t.Errorf("expected method %s, got %s", tt.expected[i], method.QualifiedName())
+				}
+			}
+		})
+	}
+}
+
+// TestNewDescription_ServiceKeys tests that methods are keyed by Service.Method
+func TestNewDescription_ServiceKeys(t *testing.T) {
+	parser := apigen.NewParser()
+	transformer := apigen.NewTransformer(parser.GetRegistry())
+
+	methods, err := parser.ParseSingleFile(filepath.Join("testdata", "service_methods.go"))
+	if err != nil {
+		t.Fatalf("failed to parse file: %v", err)
+	}
+	enriched, err := transformer.Transform(methods)
+	if err != nil {
+		t.Fatalf("failed to transform methods: %v", err)
+	}
+	desc, err := apigen.NewDescription("NotesAPI", enriched)
+	if err != nil {
+		t.Fatalf("failed to create description: %v", err)
+	}
+
+	for _, key := range []string{"NoteService.Add", "NoteService.Search", "NoteService.Reset", "HandlePing"} {
+		if _, ok := desc.Methods[key]; !ok {
+			t.Errorf("expected method key %s", key)
+		}
+	}
+	if _, ok := desc.Methods["Add"]; ok {
+		t.Errorf("expected no unqualified key for method Add")
+	}
+}
+
+// TestNewDescription_Returns tests that replies and results are described as returns
+func TestNewDescription_Returns(t *testing.T) {
+	parser := apigen.NewParser()
+	transformer := apigen.NewTransformer(parser.GetRegistry())
+
+	methods, err := parser.ParseSingleFile(filepath.Join("testdata", "service_methods.go"))
+	if err != nil {
+		t.Fatalf("failed to parse file: %v", err)
+	}
+	enriched, err := transformer.Transform(methods)
+	if err != nil {
+		t.Fatalf("failed to transform methods: %v", err)
+	}
+	desc, err := apigen.NewDescription("NotesAPI", enriched)
+	if err != nil {
+		t.Fatalf("failed to create description: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		wantParams   []string
+		wantReturns  string
+		wantField    string
+		wantNoReturn bool
+	}{
+		{
+			name:        "service_method",
+			method:      "NoteService.Add",
+			wantParams:  []string{"req"},
+			wantReturns: "AddResponse",
+			wantField:   "ID",
+		},
+		{
+			name:        "service_method_with_context_and_stream",
+			method:      "NoteService.Import",
+			wantParams:  []string{"req"},
+			wantReturns: "AddResponse",
+			wantField:   "ID",
+		},
+		{
+			name:        "function_result",
+			method:      "LookupNote",
+			wantParams:  []string{"id"},
+			wantReturns: "AddRequest",
+			wantField:   "Title",
+		},
+		{
+			name:         "method_without_reply",
+			method:       "NoteService.Reset",
+			wantParams:   []string{"req"},
+			wantNoReturn: true,
+		},
+		{
+			name:         "function_without_result",
+			method:       "HandlePing",
+			wantNoReturn: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, ok := desc.Methods[tt.method]
+			if !ok {
+				t.Fatalf("method %s not found", tt.method)
+			}
+
+			if len(method.Parameters) != len(tt.wantParams) {
+				t.Errorf("expected %d parameters, got %d", len(tt.wantParams), len(method.Parameters))
+			}
+			for _, name := range tt.wantParams {
+				if _, ok := method.Parameters[name]; !ok {
+					t.Errorf("expected parameter %s", name)
+				}
+			}
+
+			if tt.wantNoReturn {
+				if method.Returns != nil {
+					t.Errorf("expected no returns, got %s", method.Returns.Type)
+				}
+				return
+			}
+			if method.Returns == nil {
+				t.Fatal("expected returns")
+			}
+			if method.Returns.Type != tt.wantReturns {
+				t.Errorf("expected returns type %s, got %s", tt.wantReturns, method.Returns.Type)
+			}
+			if _, ok := method.Returns.Fields[tt.wantField]; !ok {
+				t.Errorf("expected returns field %s", tt.wantField)
+			}
+		})
+	}
+
+	data, err := json.Marshal(desc.Methods["NoteService.Add"])
+	if err != nil {
+		t.Fatalf("failed to marshal method: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal method: %v", err)
+	}
+	if _, ok := decoded["returns"]; !ok {
+		t.Errorf("expected a returns member in %s", data)
+	}
+}
+
 // TestJSONGenerator_Generate tests JSON generation
 func TestJSONGenerator_Generate(t *testing.T) {
 	parser := apigen.NewParser()
@@ -0,0 +1,240 @@
+package apigen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// GoClientGenerator generates a Go file with a typed client for each service, calling its
+// methods on a remote agent server through the client package. Only methods with the
+// signature of a service method, func([context.Context,] Request, [server.Stream,] *Reply)
+// error, get a client method; other functions are skipped. Methods are grouped by the service
+// in their Service.Method key. The file refers to request and reply types by their names in
+// the analyzed source, so it belongs in the same package. Methods whose request or reply
+// type comes from another package, such as models.Request, are rejected with an error.
+type GoClientGenerator struct {
+	packageName string
+	serviceName string
+}
+
+// NewGoClientGenerator creates a new Go client generator. serviceName, if not empty,
+// overrides the service the methods are called on, for services registered under another
+// name than their type.
+func NewGoClientGenerator(packageName, serviceName string) Generator {
+	return &GoClientGenerator{
+		packageName: packageName,
+		serviceName: serviceName,
+	}
+}
+
+// clientService is a service as rendered by goClientTemplate
+type clientService struct {
+	Name    string
+	Methods []clientMethod
+}
+
+// clientMethod is a service method as rendered by goClientTemplate
+type clientMethod struct {
+	Name        string
+	Doc         []string
+	RequestType string
+	ReplyType   string
+}
+
+// Generate generates Go code with a client type per service and a client method per method
+func (g *GoClientGenerator) Generate(desc APIDescription) (GeneratedContent, error) {
+	if g.serviceName != "" && (!token.IsIdentifier(g.serviceName) || !token.IsExported(g.serviceName)) {
+		return GeneratedContent{}, fmt.Errorf("invalid service name %q", g.serviceName)
+	}
+
+	names := make([]string, 0, len(desc.Methods))
+	for name := range desc.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := make(map[string]*clientService)
+	var serviceNames []string
+	for _, name := range names {
+		method := desc.Methods[name]
+		requestType, replyType, ok := serviceSignature(method)
+		if !ok {
+			continue
+		}
+		for _, typ := range []string{requestType, replyType} {
+			if pkg := typePackage(typ); pkg != "" {
+				return GeneratedContent{}, fmt.Errorf("%s uses %s of package %s; the generated client can only refer to types of the analyzed package", name, typ, pkg)
+			}
+		}
+
+		// Functions are keyed by their plain name and cannot be called as Service.Method
+		serviceName, methodName, found := strings.Cut(name, ".")
+		if !found || !token.IsExported(methodName) {
+			continue
+		}
+		if g.serviceName != "" {
+			serviceName = g.serviceName
+		}
+
+		service, exists := services[serviceName]
+		if !exists {
+			service = &clientService{Name: serviceName}
+			services[serviceName] = service
+			serviceNames = append(serviceNames, serviceName)
+		}
+		for _, existing := range service.Methods {
+			if existing.Name == methodName {
+				return GeneratedContent{}, fmt.Errorf("more than one method %s for service %s", methodName, serviceName)
+			}
+		}
+		service.Methods = append(service.Methods, clientMethod{
+			Name:        methodName,
+			Doc:         methodDoc(methodName, serviceName, method.Description),
+			RequestType: requestType,
+			ReplyType:   replyType,
+		})
+	}
+	if len(serviceNames) == 0 {
+		return GeneratedContent{}, fmt.Errorf("no methods with the signature of a service method")
+	}
+
+	sort.Strings(serviceNames)
+	var ordered []clientService
+	for _, name := range serviceNames {
+		ordered = append(ordered, *services[name])
+	}
+
+	tmpl, err := template.New("goClient").Parse(goClientTemplate)
+	if err != nil {
+		return GeneratedContent{}, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		PackageName string
+		Services    []clientService
+	}{
+		PackageName: g.packageName,
+		Services:    ordered,
+	})
+	if err != nil {
+		return GeneratedContent{}, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return GeneratedContent{}, fmt.Errorf("failed to format generated client: %w", err)
+	}
+
+	return GeneratedContent{
+		Content:     string(source),
+		PackageName: g.packageName,
+	}, nil
+}
+
+// serviceSignature returns the request and reply types of a method with the signature of a
+// service method: a single request parameter besides the context and stream, and a reply
+func serviceSignature(method MethodDescription) (requestType, replyType string, ok bool) {
+	if method.Returns == nil {
+		return "", "", false
+	}
+
+	var requests []string
+	for _, param := range method.Parameters {
+		if param.Type != "context.Context" && param.Type != "server.Stream" {
+			requests = append(requests, param.Type)
+		}
+	}
+	if len(requests) != 1 {
+		return "", "", false
+	}
+	return requests[0], method.Returns.Type, true
+}
+
+// typePackage returns the name of the first package a type expression refers to, e.g.
+// "models" for []models.Note, or "" if it only uses types of the analyzed package
+func typePackage(typ string) string {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return ""
+	}
+	pkg := ""
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if selector, ok := n.(*ast.SelectorExpr); ok && pkg == "" {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				pkg = ident.Name
+			}
+		}
+		return pkg == ""
+	})
+	return pkg
+}
+
+// methodDoc returns the doc comment lines of a client method
+func methodDoc(methodName, serviceName, description string) []string {
+	call := fmt.Sprintf("%s calls %s.%s on the server.", methodName, serviceName, methodName)
+	if description == "" {
+		return []string{call}
+	}
+	return append(wrapText(description, 90), "", call)
+}
+
+// wrapText splits text into lines of at most width characters, breaking at spaces
+func wrapText(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+const goClientTemplate = `// Code generated by apigen; DO NOT EDIT.
+// This file contains typed clients for the services of {{.PackageName}}
+
+package {{.PackageName}}
+
+import (
+	"context"
+
+	agentclient "github.com/pangobit/agent-sdk/pkg/client"
+)
+{{range $service := .Services}}
+// {{$service.Name}}Client calls the methods of {{$service.Name}} on a remote agent server
+type {{$service.Name}}Client struct {
+	client *agentclient.Client
+}
+
+// New{{$service.Name}}Client creates a {{$service.Name}}Client sending its calls through c
+func New{{$service.Name}}Client(c *agentclient.Client) *{{$service.Name}}Client {
+	return &{{$service.Name}}Client{client: c}
+}
+{{range $method := $service.Methods}}
+{{- range $method.Doc}}
+//{{if .}} {{.}}{{end}}
+{{- end}}
+func (c *{{$service.Name}}Client) {{$method.Name}}(ctx context.Context, req {{$method.RequestType}}) ({{$method.ReplyType}}, error) {
+	var reply {{$method.ReplyType}}
+	err := c.client.Call(ctx, "{{$service.Name}}.{{$method.Name}}", req, &reply)
+	return reply, err
+}
+{{end}}
+{{- end}}
+`
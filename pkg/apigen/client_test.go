@@ -0,0 +1,140 @@
+package apigen
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoClientGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name        string
+		filePath    string
+		serviceName string
+		contains    []string
+		notContains []string
+		expectError bool
+	}{
+		{
+			name:     "service_methods",
+			filePath: filepath.Join("testdata", "service_methods.go"),
+			contains: []string{
+				"// Code generated by apigen; DO NOT EDIT.",
+				"package test",
+				"func NewNoteServiceClient(c *agentclient.Client) *NoteServiceClient",
+				"// Add stores a note and returns its ID",
+				"func (c *NoteServiceClient) Add(ctx context.Context, req AddRequest) (AddResponse, error)",
+				`c.client.Call(ctx, "NoteService.Add", req, &reply)`,
+				"func (c *NoteServiceClient) Search(ctx context.Context, req SearchRequest) (SearchResponse, error)",
+				"func (c *NoteServiceClient) Import(ctx context.Context, req SearchRequest) (AddResponse, error)",
+			},
+			notContains: []string{"HandlePing", "LookupNote", "Reset", "count"},
+		},
+		{
+			name:        "service_name_override",
+			filePath:    filepath.Join("testdata", "service_methods.go"),
+			serviceName: "Notes",
+			contains: []string{
+				"func NewNotesClient(c *agentclient.Client) *NotesClient",
+				`c.client.Call(ctx, "Notes.Add", req, &reply)`,
+			},
+			notContains: []string{"NoteService.Add"},
+		},
+		{
+			name:        "no_service_methods",
+			filePath:    filepath.Join("testdata", "basic_methods.go"),
+			expectError: true,
+		},
+		{
+			name:        "package_qualified_types",
+			filePath:    filepath.Join("testdata", "qualified_types.go"),
+			expectError: true,
+		},
+		{
+			name:        "invalid_service_name",
+			filePath:    filepath.Join("testdata", "service_methods.go"),
+			serviceName: "note-service",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			config := NewConfig().
+				WithFile(tt.filePath).
+				WithOutput(Writer(&buf)).
+				WithGenerator(NewGoClientGenerator("test", tt.serviceName))
+
+			err := Generate(config)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to generate client: %v", err)
+			}
+
+			output := buf.String()
+			if _, err := parser.ParseFile(token.NewFileSet(), "client_gen.go", output, 0); err != nil {
+				t.Fatalf("generated client does not parse: %v\n%s", err, output)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(output, s) {
+					t.Errorf("expected output to contain %q\n%s", s, output)
+				}
+			}
+			for _, s := range tt.notContains {
+				if strings.Contains(output, s) {
+					t.Errorf("expected output not to contain %q", s)
+				}
+			}
+		})
+	}
+}
+
+func TestGoClientGenerator_GeneratedClientCompiles(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	var buf bytes.Buffer
+	source := filepath.Join("testdata", "service_methods.go")
+	config := NewConfig().
+		WithFile(source).
+		WithOutput(Writer(&buf)).
+		WithGenerator(NewGoClientGenerator("test", ""))
+	if err := Generate(config); err != nil {
+		t.Fatalf("Failed to generate client: %v", err)
+	}
+
+	// The package must be inside the module to resolve its imports; testdata is not part of ./...
+	dir, err := os.MkdirTemp("testdata", "client-")
+	if err != nil {
+		t.Fatalf("failed to create package directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	fixture, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "service_methods.go"), fixture, 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "client_gen.go"), buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write generated client: %v", err)
+	}
+
+	cmd := exec.Command(goTool, "vet", "./"+filepath.ToSlash(dir))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated client does not compile: %v\n%s\n%s", err, output, buf.String())
+	}
+}
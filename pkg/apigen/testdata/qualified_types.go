@@ -0,0 +1,16 @@
+package test
+
+import "time"
+
+// NowRequest is the request of ClockService.Now
+type NowRequest struct {
+	Zone string `json:"zone"`
+}
+
+// ClockService tells the time
+type ClockService struct{}
+
+// Now returns the current time in a zone. Its reply type comes from another package.
+func (s *ClockService) Now(req NowRequest, reply *time.Time) error {
+	return nil
+}
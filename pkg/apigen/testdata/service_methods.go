@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// AddRequest is the request of NoteService.Add
+type AddRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// AddResponse is the reply of NoteService.Add
+type AddResponse struct {
+	ID int `json:"id"`
+}
+
+// SearchRequest is the request of NoteService.Search
+type SearchRequest struct {
+	Query string `json:"query"`
+}
+
+// SearchResponse is the reply of NoteService.Search
+type SearchResponse struct {
+	IDs []int `json:"ids"`
+}
+
+// NoteService stores notes
+type NoteService struct{}
+
+// Add stores a note and returns its ID
+func (s *NoteService) Add(req AddRequest, reply *AddResponse) error {
+	return nil
+}
+
+// Search finds the notes whose title contains the query
+func (s *NoteService) Search(ctx context.Context, req SearchRequest, reply *SearchResponse) error {
+	return nil
+}
+
+// Import reports its progress while importing notes
+func (s *NoteService) Import(ctx context.Context, req SearchRequest, stream server.Stream, reply *AddResponse) error {
+	return nil
+}
+
+// Reset clears all notes. It has no reply, so it is not a service method.
+func (s NoteService) Reset(req AddRequest) error {
+	return nil
+}
+
+// count is unexported, so it is not a service method
+func (s *NoteService) count(req SearchRequest, reply *SearchResponse) error {
+	return nil
+}
+
+// LookupNote returns the note with the given ID
+func LookupNote(id int) (AddRequest, error) {
+	return AddRequest{}, nil
+}
+
+// HandlePing is not a service method
+func HandlePing() {
+}
@@ -7,7 +7,9 @@
 // Key features:
 //   - Parse Go packages or individual files
 //   - Extract method descriptions from Go doc comments
+//   - Key service methods as Service.Method, the name they are called by
 //   - Analyze parameter types including complex structs
+//   - Describe what methods return: the reply of service methods, the result of functions
 //   - Parse struct tags and include them as annotations
 //   - Filter methods using various strategies (prefix, suffix, contains, explicit list)
 //   - Generate Go files with embedded API definitions for runtime use
@@ -57,6 +59,7 @@ type APIDescription struct {
 type MethodDescription struct {
 	Description string                   `json:"description"`
 	Parameters  map[string]ParameterInfo `json:"parameters"`
+	Returns     *ParameterInfo           `json:"returns,omitempty"` // The reply of a service method, or the result of a function
 }
 
 // ParameterInfo contains information about a parameter
@@ -103,11 +106,19 @@ const (
 // RawMethod represents a parsed method from AST
 type RawMethod struct {
 	Name     string
+	Receiver string // Receiver type name without "*" or type parameters; empty for functions
 	Doc      []string
 	Params   []RawParam
+	Results  []RawParam // Results of the method; Name is empty for unnamed results
 	Position token.Position
 }
 
+// QualifiedName returns the name a method is called by on the server: Service.Method for
+// methods, and the plain name for functions
+func (m RawMethod) QualifiedName() string {
+	return qualifiedName(m.Receiver, m.Name)
+}
+
 // RawParam represents a parsed parameter from AST
 type RawParam struct {
 	Name string
@@ -137,8 +148,24 @@ type ParsedField struct {
 // EnrichedMethod represents a method with fully resolved types
 type EnrichedMethod struct {
 	Name        string
+	Receiver    string // Receiver type name; empty for functions
 	Description string
-	Parameters  []EnrichedParam
+	Parameters  []EnrichedParam // Request parameters, without the reply of a service method
+	Returns     *EnrichedParam  // Type the reply argument points to, or the result of a function; nil if unknown
+}
+
+// QualifiedName returns the name a method is called by on the server: Service.Method for
+// methods, and the plain name for functions
+func (m EnrichedMethod) QualifiedName() string {
+	return qualifiedName(m.Receiver, m.Name)
+}
+
+// qualifiedName joins a receiver type name and a method name
+func qualifiedName(receiver, name string) string {
+	if receiver == "" {
+		return name
+	}
+	return receiver + "." + name
 }
 
 // EnrichedParam represents a parameter with fully resolved type
@@ -0,0 +1,423 @@
+// Package client calls the tools of a remote agent server over its HTTP API. Tools are
+// discovered at /tools and called at /execute, singly or in batches:
+//
+//	c := client.NewClient("http://localhost:8080",
+//	    client.WithPath("/agents/api/v1/"),
+//	    client.WithBearerToken(token),
+//	    client.WithRetries(3, 100*time.Millisecond),
+//	)
+//	var reply AddResponse
+//	err := c.Call(ctx, "Notes.Add", AddRequest{Text: "buy milk"}, &reply)
+//
+// Calls the server rejects fail with a *jsonrpc.Error carrying its code, message and data,
+// which jsonrpc.AsError extracts. The trace context of ctx is sent along with every request.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server/auth"
+	"github.com/pangobit/agent-sdk/pkg/server/tools"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
+)
+
+// maxBackoff bounds the wait between two attempts of a request
+const maxBackoff = 30 * time.Second
+
+// maxErrorBody bounds how much of an HTTP error response is kept in an HTTPError
+const maxErrorBody = 4096
+
+// ToolInfo describes a tool published by the server at /tools. It is the type the server's
+// tool registry publishes, so both sides agree on its fields.
+type ToolInfo = tools.ToolInfo
+
+// HTTPError is returned when the server answers with an HTTP error status instead of a
+// JSON-RPC response, e.g. 401 for a request without valid credentials
+type HTTPError struct {
+	StatusCode int
+	Body       string        // Start of the response body
+	RetryAfter time.Duration // From the Retry-After header, zero if there is none
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("server returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), strings.TrimSpace(e.Body))
+}
+
+// ClientOpts defines options for configuring a client
+type ClientOpts func(*Client)
+
+// Client calls the tools of an agent server over HTTP. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	path       string
+	httpClient *http.Client
+	headers    http.Header
+	sign       func(r *http.Request) error
+	maxRetries int
+	backoff    time.Duration
+	nextID     atomic.Int64
+}
+
+// NewClient creates a client for the server at baseURL, e.g. "http://localhost:8080".
+// Requests are sent with http.DefaultClient and are not retried unless opts say otherwise.
+func NewClient(baseURL string, opts ...ClientOpts) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		headers:    make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithPath sets the base path the server's HTTP transport is mounted at, the path given to
+// http.WithPath on the server, e.g. "/agents/api/v1/" for agentsdk.NewDefaultServer
+func WithPath(path string) ClientOpts {
+	return func(c *Client) {
+		c.path = strings.Trim(path, "/")
+	}
+}
+
+// WithHTTPClient sets the client used to send requests, e.g. to configure timeouts or TLS
+func WithHTTPClient(client *http.Client) ClientOpts {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithHeader adds a header to every request
+func WithHeader(name, value string) ClientOpts {
+	return func(c *Client) {
+		c.headers.Add(name, value)
+	}
+}
+
+// WithBearerToken authenticates requests with "Authorization: Bearer <token>",
+// as accepted by auth.NewBearerAuthenticator
+func WithBearerToken(token string) ClientOpts {
+	return func(c *Client) {
+		c.headers.Set("Authorization", "Bearer "+token)
+		c.sign = nil
+	}
+}
+
+// WithHMACKey signs requests with secret under keyID, as accepted by
+// auth.NewHMACAuthenticator. Every attempt of a request is signed anew.
+func WithHMACKey(keyID string, secret []byte) ClientOpts {
+	return func(c *Client) {
+		c.headers.Del("Authorization")
+		c.sign = func(r *http.Request) error {
+			return auth.SignRequest(r, keyID, secret)
+		}
+	}
+}
+
+// WithRetries retries a request up to n times when it fails in a way that may pass: the
+// server cannot be reached, answers 429, 502, 503 or 504, or rate limits the call. The first
+// retry waits for backoff and every further one twice as long as the previous, up to 30
+// seconds, or longer if the server asks for it. Calls carry an idempotency key, so that a
+// server with tools.WithIdempotency runs a call only once however often it is sent.
+func WithRetries(n int, backoff time.Duration) ClientOpts {
+	return func(c *Client) {
+		c.maxRetries = max(n, 0)
+		c.backoff = backoff
+	}
+}
+
+// Discover returns the tools the server publishes to this client, sorted by name
+func (c *Client) Discover(ctx context.Context) ([]ToolInfo, error) {
+	var response struct {
+		Tools map[string]ToolInfo `json:"tools"` // Key: tool name
+	}
+	err := c.withRetries(ctx, func() error {
+		body, err := c.send(ctx, http.MethodGet, "/tools", nil)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(body, &response)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover tools: %w", err)
+	}
+
+	toolInfos := make([]ToolInfo, 0, len(response.Tools))
+	for name, info := range response.Tools {
+		if info.Name == "" {
+			info.Name = name
+		}
+		toolInfos = append(toolInfos, info)
+	}
+	sort.Slice(toolInfos, func(i, j int) bool { return toolInfos[i].Name < toolInfos[j].Name })
+	return toolInfos, nil
+}
+
+// Call calls method, of the form "Service.Method", with params, which must encode to a JSON
+// object or be nil, and decodes the result into result unless it is nil.
+// Errors returned by the server are *jsonrpc.Error values.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	req := c.newRequest(method, params)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	var resp response
+	err = c.withRetries(ctx, func() error {
+		respBody, err := c.send(ctx, http.MethodPost, "/execute", body)
+		if err != nil {
+			return err
+		}
+		resp = response{}
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return fmt.Errorf("invalid response: %w", err)
+		}
+		return resp.err()
+	})
+	if err != nil {
+		return err
+	}
+	return resp.decode(result)
+}
+
+// BatchCall is a call sent as part of a batch
+type BatchCall struct {
+	Method string // Of the form "Service.Method"
+	Params any    // Must encode to a JSON object or be nil
+	Result any    // Receives the decoded result unless nil
+	Error  error  // Set to the call's *jsonrpc.Error if it failed
+}
+
+// Batch sends calls as a single JSON-RPC batch. The outcome of each call is stored in it;
+// the returned error only reports a failure of the batch as a whole. Whole batches are
+// retried as configured with WithRetries, but calls failing within a batch are not.
+func (c *Client) Batch(ctx context.Context, calls []*BatchCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	requests := make([]request, len(calls))
+	byID := make(map[int64]*BatchCall, len(calls))
+	for i, call := range calls {
+		requests[i] = c.newRequest(call.Method, call.Params)
+		byID[requests[i].ID] = call
+	}
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	var responses []response
+	err = c.withRetries(ctx, func() error {
+		respBody, err := c.send(ctx, http.MethodPost, "/execute", body)
+		if err != nil {
+			return err
+		}
+		responses = nil
+		if err := json.Unmarshal(respBody, &responses); err != nil {
+			// A batch rejected as a whole is answered with a single error response
+			var resp response
+			if json.Unmarshal(respBody, &resp) == nil && resp.Error != nil {
+				return resp.err()
+			}
+			return fmt.Errorf("invalid response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, call := range calls {
+		call.Error = errors.New("no response for call")
+	}
+	for _, resp := range responses {
+		if resp.ID == nil || byID[*resp.ID] == nil {
+			continue
+		}
+		call := byID[*resp.ID]
+		call.Error = resp.err()
+		if call.Error == nil {
+			call.Error = resp.decode(call.Result)
+		}
+	}
+	return nil
+}
+
+// request is a JSON-RPC 2.0 request object
+type request struct {
+	JSONRPC        string `json:"jsonrpc"`
+	Method         string `json:"method"`
+	Params         any    `json:"params,omitempty"`
+	ID             int64  `json:"id"`
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+}
+
+// newRequest builds a request with a fresh ID, and an idempotency key if requests are retried
+func (c *Client) newRequest(method string, params any) request {
+	req := request{JSONRPC: "2.0", Method: method, Params: params, ID: c.nextID.Add(1)}
+	if c.maxRetries > 0 {
+		req.IdempotencyKey = newIdempotencyKey()
+	}
+	return req
+}
+
+// response is a JSON-RPC 2.0 response object
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpc.Error  `json:"error"`
+	ID     *int64          `json:"id"`
+}
+
+// err returns the error of the response, or nil if it succeeded
+func (r response) err() error {
+	if r.Error == nil {
+		return nil
+	}
+	if r.Error.Message == "" {
+		r.Error.Message = jsonrpc.ErrorCode(r.Error.Code).Message()
+	}
+	return r.Error
+}
+
+// decode decodes the result of the response into result unless it is nil
+func (r response) decode(result any) error {
+	if result == nil || len(r.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// send sends a request to the endpoint at path and returns the body of a 2xx response
+func (c *Client) send(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	tracing.Inject(ctx, req.Header)
+	if c.sign != nil {
+		if err := c.sign(req); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Body:       string(errBody),
+			RetryAfter: time.Duration(seconds) * time.Second,
+		}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// url returns the URL of the endpoint at path below the base path
+func (c *Client) url(path string) string {
+	if c.path == "" {
+		return c.baseURL + path
+	}
+	return c.baseURL + "/" + c.path + path
+}
+
+// withRetries runs attempt until it succeeds, fails in a way that is not retryable or the
+// retries are used up, and returns its last error
+func (c *Client) withRetries(ctx context.Context, attempt func() error) error {
+	for n := 0; ; n++ {
+		err := attempt()
+		if err == nil || n >= c.maxRetries || ctx.Err() != nil {
+			return err
+		}
+		wait, ok := retryDelay(err)
+		if !ok {
+			return err
+		}
+
+		timer := time.NewTimer(max(wait, c.backoffFor(n)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoffFor returns the wait before retry n+1, doubling with every retry
+func (c *Client) backoffFor(n int) time.Duration {
+	wait := c.backoff
+	for i := 0; i < n && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// retryDelay reports whether a failed attempt may be retried, and how long the server
+// asked to wait before doing so
+func retryDelay(err error) (time.Duration, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return min(httpErr.RetryAfter, maxBackoff), true
+		}
+		return 0, false
+	}
+
+	if rpcErr, ok := jsonrpc.AsError(err); ok {
+		if rpcErr.Code != int(jsonrpc.ErrorCodeRateLimited) {
+			return 0, false
+		}
+		data, _ := rpcErr.Data.(map[string]any)
+		seconds, _ := data["retryAfter"].(float64)
+		return min(time.Duration(seconds*float64(time.Second)), maxBackoff), true
+	}
+
+	// Requests that did not reach the server, or got no response, may be sent again
+	var urlErr *url.Error
+	return 0, errors.As(err, &urlErr)
+}
+
+// newIdempotencyKey returns a random idempotency key
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
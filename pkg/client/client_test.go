@@ -0,0 +1,360 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/auth"
+	transport "github.com/pangobit/agent-sdk/pkg/server/http"
+	"github.com/pangobit/agent-sdk/pkg/server/tools"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
+)
+
+// NoteService is the service called by the tests
+type NoteService struct{}
+
+type AddRequest struct {
+	Text string `json:"text"`
+}
+
+type AddResponse struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+func (s *NoteService) Add(req AddRequest, resp *AddResponse) error {
+	if req.Text == "" {
+		return fmt.Errorf("%w: text is empty", server.ErrInvalidParams)
+	}
+	resp.ID = 1
+	resp.Text = req.Text
+	return nil
+}
+
+func (s *NoteService) Fail(req AddRequest, resp *AddResponse) error {
+	return errors.New("disk full")
+}
+
+// mockRegistry accepts every service
+type mockRegistry struct{}
+
+func (mockRegistry) Register(service any) error {
+	return nil
+}
+
+// newTestServer serves NoteService below /agents/api/v1/ to callers with a valid
+// bearer token or HMAC signature
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	toolService := tools.NewToolService()
+	executor := tools.NewJSONRPCMethodExecutor(mockRegistry{})
+	if err := executor.RegisterService(&NoteService{}); err != nil {
+		t.Fatalf("RegisterService failed: %v", err)
+	}
+	if err := toolService.DescribeService(&NoteService{}); err != nil {
+		t.Fatalf("DescribeService failed: %v", err)
+	}
+
+	httpTransport := transport.NewHTTPTransport(
+		transport.WithPath("/agents/api/v1/"),
+		transport.WithToolHandler(toolService.ToolDiscoveryHandler()),
+		transport.WithMethodHandler(tools.NewMethodExecutionHandler(executor)),
+		transport.WithAuthentication(
+			auth.NewBearerAuthenticator(map[string]server.Principal{"secret-token": {Subject: "alice"}}),
+			auth.NewHMACAuthenticator(map[string][]byte{"key-1": []byte("shared-secret")}),
+		),
+	)
+	srv := httptest.NewServer(httpTransport.HTTPHandler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_Call(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name         string
+		opts         []ClientOpts
+		method       string
+		params       any
+		expected     AddResponse
+		expectedCode int
+		expectedHTTP int
+	}{
+		{
+			name:     "success",
+			opts:     []ClientOpts{WithPath("/agents/api/v1/"), WithBearerToken("secret-token")},
+			method:   "NoteService.Add",
+			params:   AddRequest{Text: "buy milk"},
+			expected: AddResponse{ID: 1, Text: "buy milk"},
+		},
+		{
+			name:     "hmac_signed",
+			opts:     []ClientOpts{WithPath("agents/api/v1"), WithHMACKey("key-1", []byte("shared-secret"))},
+			method:   "NoteService.Add",
+			params:   map[string]any{"text": "buy milk"},
+			expected: AddResponse{ID: 1, Text: "buy milk"},
+		},
+		{
+			name:         "tool_error",
+			opts:         []ClientOpts{WithPath("/agents/api/v1/"), WithBearerToken("secret-token")},
+			method:       "NoteService.Fail",
+			expectedCode: -32000,
+		},
+		{
+			name:         "invalid_params",
+			opts:         []ClientOpts{WithPath("/agents/api/v1/"), WithBearerToken("secret-token")},
+			method:       "NoteService.Add",
+			params:       AddRequest{},
+			expectedCode: -32602,
+		},
+		{
+			name:         "method_not_found",
+			opts:         []ClientOpts{WithPath("/agents/api/v1/"), WithBearerToken("secret-token")},
+			method:       "NoteService.Missing",
+			expectedCode: -32601,
+		},
+		{
+			name:         "unauthenticated",
+			opts:         []ClientOpts{WithPath("/agents/api/v1/")},
+			method:       "NoteService.Add",
+			expectedHTTP: http.StatusUnauthorized,
+		},
+		{
+			name:         "wrong_base_path",
+			opts:         []ClientOpts{WithBearerToken("secret-token")},
+			method:       "NoteService.Add",
+			expectedHTTP: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(srv.URL+"/", tt.opts...)
+
+			var result AddResponse
+			err := c.Call(context.Background(), tt.method, tt.params, &result)
+
+			switch {
+			case tt.expectedCode != 0:
+				rpcErr, ok := jsonrpc.AsError(err)
+				if !ok || rpcErr.Code != tt.expectedCode {
+					t.Errorf("expected JSON-RPC error %d, got %v", tt.expectedCode, err)
+				}
+			case tt.expectedHTTP != 0:
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.expectedHTTP {
+					t.Errorf("expected HTTP error %d, got %v", tt.expectedHTTP, err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case result != tt.expected:
+				t.Errorf("expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestClient_Discover(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(srv.URL, WithPath("/agents/api/v1/"), WithBearerToken("secret-token"))
+
+	toolInfos, err := c.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	var names []string
+	for _, info := range toolInfos {
+		names = append(names, info.Name)
+	}
+	if expected := []string{"NoteService.Add", "NoteService.Fail"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected tools %v, got %v", expected, names)
+	}
+}
+
+func TestClient_Batch(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(srv.URL, WithPath("/agents/api/v1/"), WithBearerToken("secret-token"))
+
+	var first, second AddResponse
+	calls := []*BatchCall{
+		{Method: "NoteService.Add", Params: AddRequest{Text: "one"}, Result: &first},
+		{Method: "NoteService.Fail", Params: AddRequest{Text: "two"}},
+		{Method: "NoteService.Add", Params: AddRequest{Text: "three"}, Result: &second},
+	}
+	if err := c.Batch(context.Background(), calls); err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	if calls[0].Error != nil || first.Text != "one" {
+		t.Errorf("expected the first call to succeed, got %+v, %v", first, calls[0].Error)
+	}
+	if rpcErr, ok := jsonrpc.AsError(calls[1].Error); !ok || rpcErr.Code != -32000 {
+		t.Errorf("expected the second call to fail with -32000, got %v", calls[1].Error)
+	}
+	if calls[2].Error != nil || second.Text != "three" {
+		t.Errorf("expected the third call to succeed, got %+v, %v", second, calls[2].Error)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name             string
+		responses        []func(w http.ResponseWriter) // One per attempt; the last one repeats
+		retries          int
+		expectedAttempts int
+		expectError      bool
+	}{
+		{
+			name:             "retries_unavailable_server",
+			responses:        []func(w http.ResponseWriter){unavailable, unavailable, succeed},
+			retries:          3,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "retries_rate_limited_call",
+			responses:        []func(w http.ResponseWriter){rateLimited, succeed},
+			retries:          3,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "gives_up_after_retries",
+			responses:        []func(w http.ResponseWriter){unavailable},
+			retries:          2,
+			expectedAttempts: 3,
+			expectError:      true,
+		},
+		{
+			name:             "does_not_retry_bad_request",
+			responses:        []func(w http.ResponseWriter){badRequest, succeed},
+			retries:          3,
+			expectedAttempts: 1,
+			expectError:      true,
+		},
+		{
+			name:             "no_retries_by_default",
+			responses:        []func(w http.ResponseWriter){unavailable, succeed},
+			expectedAttempts: 1,
+			expectError:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mutex    sync.Mutex
+				attempts int
+				keys     = make(map[string]bool)
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req request
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &req)
+
+				mutex.Lock()
+				respond := tt.responses[min(attempts, len(tt.responses)-1)]
+				attempts++
+				keys[req.IdempotencyKey] = true
+				mutex.Unlock()
+				respond(w)
+			}))
+			defer srv.Close()
+
+			c := NewClient(srv.URL, WithRetries(tt.retries, time.Millisecond))
+			var result AddResponse
+			err := c.Call(context.Background(), "NoteService.Add", AddRequest{Text: "hi"}, &result)
+
+			if tt.expectError != (err != nil) {
+				t.Errorf("expected error: %v, got %v", tt.expectError, err)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, attempts)
+			}
+			if tt.retries > 0 && (len(keys) != 1 || keys[""]) {
+				t.Errorf("expected every attempt to carry the same idempotency key, got %v", keys)
+			}
+		})
+	}
+}
+
+func unavailable(w http.ResponseWriter) {
+	http.Error(w, "unavailable", http.StatusServiceUnavailable)
+}
+
+func badRequest(w http.ResponseWriter) {
+	http.Error(w, "invalid JSON", http.StatusBadRequest)
+}
+
+func rateLimited(w http.ResponseWriter) {
+	w.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32004,"message":"Rate limit exceeded","data":{"retryAfter":0}},"id":1}`))
+}
+
+func succeed(w http.ResponseWriter) {
+	w.Write([]byte(`{"jsonrpc":"2.0","result":{"id":1,"text":"hi"},"id":1}`))
+}
+
+func TestClient_RetriesStopWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unavailable(w)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := NewClient(srv.URL, WithRetries(10, time.Second))
+	start := time.Now()
+	err := c.Call(ctx, "NoteService.Add", nil, nil)
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Errorf("expected the last HTTP error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected retries to stop with the context, took %v", elapsed)
+	}
+}
+
+func TestClient_SendsHeaders(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		succeed(w)
+	}))
+	defer srv.Close()
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, _ := tracing.ParseTraceparent(traceparent)
+	ctx := tracing.ContextWithSpanContext(context.Background(), sc)
+
+	c := NewClient(srv.URL, WithHeader("X-Tenant", "acme"), WithBearerToken("secret-token"))
+	if err := c.Call(ctx, "NoteService.Add", nil, nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"X-Tenant":      "acme",
+		"Authorization": "Bearer secret-token",
+		"Traceparent":   traceparent,
+		"Content-Type":  "application/json",
+	}
+	got := make(map[string]string)
+	for name := range expected {
+		got[name] = header.Get(name)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected headers %v, got %v", expected, got)
+	}
+}
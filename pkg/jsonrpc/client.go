@@ -3,6 +3,7 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -47,16 +48,15 @@ func (c *clientCodec) WriteRequest(r *rpc.Request, param any) error {
 
 type clientResponse struct {
 	Result *json.RawMessage `json:"result"`
-	Error  any              `json:"error"`
+	Error  json.RawMessage  `json:"error"`
 	ID     uint64           `json:"id"`
 }
 
 func reset(r clientResponse) clientResponse {
-	req := r
 	r.ID = 0
 	r.Result = nil
 	r.Error = nil
-	return req
+	return r
 }
 
 func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
@@ -72,22 +72,41 @@ func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
 
 	r.Error = ""
 	r.Seq = c.response.ID
-	if c.response.Error != nil || c.response.Result == nil {
-		x, ok := c.response.Error.(string)
-		if !ok {
-			return fmt.Errorf("invalid error: %v", c.response.Error)
+	if len(c.response.Error) > 0 && !bytes.Equal(c.response.Error, jsonNull) {
+		rpcErr, err := parseResponseError(c.response.Error)
+		if err != nil {
+			return err
 		}
-		if x != "" {
-			r.Error = "unspecified error"
-		}
-		r.Error = x
+		// net/rpc surfaces this string as an rpc.ServerError; AsError recovers the *Error
+		r.Error = rpcErr.Error()
 	}
 
 	return nil
 }
 
+// parseResponseError decodes the error member of a response. Besides spec-compliant
+// error objects it accepts bare strings as sent by older servers.
+func parseResponseError(raw json.RawMessage) (*Error, error) {
+	var message string
+	if err := json.Unmarshal(raw, &message); err == nil {
+		if message == "" {
+			message = "unspecified error"
+		}
+		return NewError(ErrorCodeServerError, message, nil), nil
+	}
+
+	var rpcErr Error
+	if err := json.Unmarshal(raw, &rpcErr); err != nil {
+		return nil, fmt.Errorf("invalid error: %s", raw)
+	}
+	if rpcErr.Message == "" {
+		rpcErr.Message = ErrorCode(rpcErr.Code).Message()
+	}
+	return &rpcErr, nil
+}
+
 func (c *clientCodec) ReadResponseBody(body any) error {
-	if body == nil {
+	if body == nil || c.response.Result == nil {
 		return nil
 	}
 
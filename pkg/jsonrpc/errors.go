@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/rpc"
+	"strings"
+)
+
+// Error is a JSON-RPC 2.0 error object. It implements the error interface so that
+// service methods can return it directly to control the code, message and data
+// sent to the client:
+//
+//	func (s *QuotaService) Reserve(req ReserveRequest, reply *ReserveResponse) error {
+//	    return jsonrpc.NewError(-32001, "quota exceeded", map[string]any{"limit": 10})
+//	}
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// NewError creates a JSON-RPC error with the given code, message and optional data.
+// Codes from -32000 to -32099 are reserved for implementation-defined server errors.
+func NewError(code ErrorCode, message string, data any) *Error {
+	return &Error{
+		Code:    int(code),
+		Message: message,
+		Data:    data,
+	}
+}
+
+// Error returns the JSON encoding of the error object.
+// net/rpc only carries error strings between a service method and the codec,
+// so the encoded form is what allows the code and data to survive the trip.
+func (e *Error) Error() string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		b, _ = json.Marshal(&Error{Code: e.Code, Message: e.Message})
+	}
+	return string(b)
+}
+
+// AsError extracts a JSON-RPC error from err. It understands *Error values as well
+// as the rpc.ServerError strings produced by clients created with NewClient or Dial.
+func AsError(err error) (*Error, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr, true
+	}
+
+	var serverErr rpc.ServerError
+	if errors.As(err, &serverErr) {
+		if decoded, ok := decodeError(string(serverErr)); ok {
+			return decoded, true
+		}
+	}
+
+	return nil, false
+}
+
+type ErrorCode int
+
+const (
+	ErrorCodeParseError     ErrorCode = -32700
+	ErrorCodeInvalidRequest ErrorCode = -32600
+	ErrorCodeMethodNotFound ErrorCode = -32601
+	ErrorCodeInvalidParams  ErrorCode = -32602
+	ErrorCodeInternalError  ErrorCode = -32603
+	ErrorCodeServerError    ErrorCode = -32000
+	// ErrorCodeTimeout is returned when a method does not complete before its deadline
+	ErrorCodeTimeout ErrorCode = -32001
+	// ErrorCodeUnauthorized is returned when a method requires an authenticated caller
+	ErrorCodeUnauthorized ErrorCode = -32002
+	// ErrorCodeForbidden is returned when the caller is not allowed to call a method
+	ErrorCodeForbidden ErrorCode = -32003
+	// ErrorCodeRateLimited is returned when a call exceeds a rate limit or concurrency cap
+	ErrorCodeRateLimited ErrorCode = -32004
+)
+
+// Message returns the standard message for the predefined error codes
+func (c ErrorCode) Message() string {
+	switch c {
+	case ErrorCodeParseError:
+		return "Parse error"
+	case ErrorCodeInvalidRequest:
+		return "Invalid Request"
+	case ErrorCodeMethodNotFound:
+		return "Method not found"
+	case ErrorCodeInvalidParams:
+		return "Invalid params"
+	case ErrorCodeInternalError:
+		return "Internal error"
+	case ErrorCodeTimeout:
+		return "Request timeout"
+	case ErrorCodeUnauthorized:
+		return "Unauthorized"
+	case ErrorCodeForbidden:
+		return "Forbidden"
+	case ErrorCodeRateLimited:
+		return "Rate limit exceeded"
+	default:
+		return "Server error"
+	}
+}
+
+// Deprecated: the following types carry no code and are never sent on the wire.
+// Use NewError with the matching ErrorCode instead.
+type ErrorParseError error
+type ErrorInvalidRequest error
+type ErrorMethodNotFound error
+type ErrorInvalidParams error
+type ErrorInternalError error
+type ErrorServerError error
+
+// decodeError parses an error string produced by (*Error).Error
+func decodeError(s string) (*Error, bool) {
+	if !strings.HasPrefix(s, "{") {
+		return nil, false
+	}
+	var e Error
+	if err := json.Unmarshal([]byte(s), &e); err != nil || e.Code == 0 {
+		return nil, false
+	}
+	return &e, true
+}
+
+// errorFromMessage converts an error string reported by net/rpc into a JSON-RPC error.
+// Typed errors returned by service methods round-trip unchanged; net/rpc's own
+// lookup failures become "Method not found" and anything else is a server error.
+func errorFromMessage(msg string) *Error {
+	if e, ok := decodeError(msg); ok {
+		return e
+	}
+
+	switch {
+	case strings.HasPrefix(msg, "rpc: can't find service"),
+		strings.HasPrefix(msg, "rpc: can't find method"),
+		strings.HasPrefix(msg, "rpc: service/method request ill-formed"):
+		return NewError(ErrorCodeMethodNotFound, ErrorCodeMethodNotFound.Message(), msg)
+	case strings.HasPrefix(msg, "rpc: server cannot decode request"):
+		return NewError(ErrorCodeInvalidRequest, ErrorCodeInvalidRequest.Message(), msg)
+	}
+
+	return NewError(ErrorCodeServerError, msg, nil)
+}
@@ -6,7 +6,9 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/rpc"
@@ -23,10 +25,27 @@ type serverRequest struct {
 type Response struct {
 	JSONRPC string           `json:"jsonrpc"`
 	Result  any              `json:"result"`
-	Error   *Error           `json:"error"`
+	Error   *Error           `json:"error,omitempty"`
 	ID      *json.RawMessage `json:"id"`
 }
 
+// MarshalJSON encodes exactly one of result and error, as JSON-RPC 2.0 requires.
+// A successful call with a nil result still has a "result": null member.
+func (r Response) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string           `json:"jsonrpc"`
+			Error   *Error           `json:"error"`
+			ID      *json.RawMessage `json:"id"`
+		}{r.JSONRPC, r.Error, r.ID})
+	}
+	return json.Marshal(struct {
+		JSONRPC string           `json:"jsonrpc"`
+		Result  any              `json:"result"`
+		ID      *json.RawMessage `json:"id"`
+	}{r.JSONRPC, r.Result, r.ID})
+}
+
 func validateResponse(response Response) error {
 	if response.JSONRPC != "2.0" {
 		return fmt.Errorf("invalid JSON-RPC version")
@@ -53,61 +72,53 @@ func validateMethod(method string) error {
 	return nil
 }
 
-type Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    any    `json:"data"`
-}
-
-type ErrorCode int
-
-const (
-	ErrorCodeParseError     ErrorCode = -32700
-	ErrorCodeInvalidRequest ErrorCode = -32600
-	ErrorCodeMethodNotFound ErrorCode = -32601
-	ErrorCodeInvalidParams  ErrorCode = -32602
-	ErrorCodeInternalError  ErrorCode = -32603
-	ErrorCodeServerError    ErrorCode = -32000
-)
-
-type ErrorParseError error
-type ErrorInvalidRequest error
-type ErrorMethodNotFound error
-type ErrorInvalidParams error
-type ErrorInternalError error
-type ErrorServerError error
-
 type serverCodec struct {
 	decoder *json.Decoder
 	encoder *json.Encoder
 	closer  io.Closer
+	// writing serializes responses with the parse errors written by ReadRequestHeader
+	writing sync.Mutex
 
 	request serverRequest
 
 	mutex   sync.Mutex
 	seq     uint64
 	pending map[uint64]*json.RawMessage
+	// invalid holds errors detected while reading a request header, keyed by
+	// sequence number, so they can be reported in place of net/rpc's own message
+	invalid map[uint64]*Error
 }
 
 func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	c.request.JSONRPC = ""
 	c.request.Method = ""
 	c.request.Params = nil
 	c.request.ID = nil
 
 	if err := c.decoder.Decode(&c.request); err != nil {
-		return ErrorParseError(err)
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		// The decoder cannot find the start of the next request after malformed input,
+		// so the error is reported with a null id and the connection is closed
+		parseErr := NewError(ErrorCodeParseError, ErrorCodeParseError.Message(), err.Error())
+		c.encode(Response{JSONRPC: jsonrpcVersion, Error: parseErr, ID: &jsonNull})
+		return parseErr
 	}
 
-	switch c.request.Method {
-	case "":
-		return ErrorMethodNotFound(nil)
-	}
-
-	if c.request.JSONRPC != jsonrpcVersion || c.request.JSONRPC == "" {
-		return ErrorInvalidRequest(nil)
+	// Requests that fail validation are still assigned a sequence number so that
+	// net/rpc answers them; WriteResponse then reports the stored error instead.
+	var invalid *Error
+	if c.request.JSONRPC != jsonrpcVersion {
+		invalid = NewError(ErrorCodeInvalidRequest, "jsonrpc field must be '2.0'", nil)
+	} else if err := validateMethod(c.request.Method); err != nil {
+		invalid = NewError(ErrorCodeInvalidRequest, err.Error(), nil)
 	}
 
 	r.ServiceMethod = c.request.Method
+	if invalid != nil {
+		r.ServiceMethod = ""
+	}
 
 	// JSON-RPC 2.0 allows the ID to be a "number", a string, or null.
 	// Go's rpc package expects a uint64, so, like the old json1.0 rpc package,
@@ -115,40 +126,47 @@ func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
 	c.mutex.Lock()
 	c.seq++
 	c.pending[c.seq] = c.request.ID
+	if invalid != nil {
+		c.invalid[c.seq] = invalid
+	}
 	c.request.ID = nil
 	r.Seq = c.seq
 	c.mutex.Unlock()
+
 	return nil
 }
 
-type nilParams struct{}
-
 func (c *serverCodec) ReadRequestBody(body any) error {
-	if body == nil {
-		return ErrorInvalidRequest(nil)
+	if body == nil || c.request.Params == nil {
+		return nil
 	}
 
-	var params [1]any
-	if c.request.Params != nil {
-		params[0] = body
+	// Params may be given by position (a single-element array) or by name (an object)
+	var err error
+	if trimmed := bytes.TrimSpace(*c.request.Params); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, body)
 	} else {
-		params[0] = nilParams{}
+		params := [1]any{body}
+		err = json.Unmarshal(*c.request.Params, &params)
 	}
-
-	return json.Unmarshal(*c.request.Params, &params)
+	if err != nil {
+		return NewError(ErrorCodeInvalidParams, err.Error(), nil)
+	}
+	return nil
 }
 
 var jsonNull = json.RawMessage([]byte("null"))
 
 func (c *serverCodec) WriteResponse(r *rpc.Response, body any) error {
 	c.mutex.Lock()
-
 	b, ok := c.pending[r.Seq]
 	if !ok {
 		c.mutex.Unlock()
-		return ErrorInvalidRequest(nil)
+		return NewError(ErrorCodeInvalidRequest, "invalid sequence number in response", nil)
 	}
 	delete(c.pending, r.Seq)
+	invalid := c.invalid[r.Seq]
+	delete(c.invalid, r.Seq)
 	c.mutex.Unlock()
 
 	if b == nil {
@@ -159,13 +177,22 @@ func (c *serverCodec) WriteResponse(r *rpc.Response, body any) error {
 		JSONRPC: "2.0",
 		ID:      b,
 	}
-
-	if r.Error == "" {
+	switch {
+	case invalid != nil:
+		response.Error = invalid
+	case r.Error != "":
+		response.Error = errorFromMessage(r.Error)
+	default:
 		response.Result = body
-	} else {
-		response.Result = r.Error
 	}
 
+	return c.encode(response)
+}
+
+// encode writes a response to the connection
+func (c *serverCodec) encode(response Response) error {
+	c.writing.Lock()
+	defer c.writing.Unlock()
 	return c.encoder.Encode(response)
 }
 
@@ -1,8 +1,15 @@
 package jsonrpc
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
 	"testing"
+	"time"
 )
 
 // Helper functions to create ID values for testing
@@ -733,3 +740,437 @@ func BenchmarkValidateMethodEmpty(b *testing.B) {
 		_ = validateMethod(method)
 	}
 }
+
+// ArithService is a net/rpc style service used to exercise the codecs end to end
+type ArithService struct{}
+
+type DivideArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (s *ArithService) Divide(args DivideArgs, reply *int) error {
+	if args.B == 0 {
+		return NewError(-32001, "division by zero", map[string]any{"field": "b"})
+	}
+	*reply = args.A / args.B
+	return nil
+}
+
+func (s *ArithService) Fail(args DivideArgs, reply *int) error {
+	return fmt.Errorf("plain failure")
+}
+
+// startTestServer serves ArithService on a loopback listener
+func startTestServer(t *testing.T) string {
+	t.Helper()
+
+	srv := NewServer()
+	if err := srv.Register(&ArithService{}); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go srv.Serve(listener)
+
+	return listener.Addr().String()
+}
+
+func TestResponse_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response Response
+		expected string
+	}{
+		{
+			name:     "nil_result",
+			response: Response{JSONRPC: "2.0", ID: intID(1)},
+			expected: `{"jsonrpc":"2.0","result":null,"id":1}`,
+		},
+		{
+			name:     "result",
+			response: Response{JSONRPC: "2.0", Result: map[string]int{"sum": 3}, ID: intID(1)},
+			expected: `{"jsonrpc":"2.0","result":{"sum":3},"id":1}`,
+		},
+		{
+			name:     "error_without_result",
+			response: Response{JSONRPC: "2.0", Error: NewError(ErrorCodeMethodNotFound, "Method not found", nil), ID: nullID()},
+			expected: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.response)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestServerCodec_WriteResponseErrors(t *testing.T) {
+	addr := startTestServer(t)
+
+	tests := []struct {
+		name         string
+		request      string
+		expectedCode int
+		expectedData any
+		expectResult bool
+	}{
+		{
+			name:         "success_result",
+			request:      `{"jsonrpc":"2.0","method":"ArithService.Divide","params":[{"a":6,"b":3}],"id":1}`,
+			expectResult: true,
+		},
+		{
+			name:         "named_params",
+			request:      `{"jsonrpc":"2.0","method":"ArithService.Divide","params":{"a":6,"b":2},"id":"abc"}`,
+			expectResult: true,
+		},
+		{
+			name:         "typed_error_with_data",
+			request:      `{"jsonrpc":"2.0","method":"ArithService.Divide","params":[{"a":1,"b":0}],"id":2}`,
+			expectedCode: -32001,
+			expectedData: map[string]any{"field": "b"},
+		},
+		{
+			name:         "plain_error",
+			request:      `{"jsonrpc":"2.0","method":"ArithService.Fail","params":[{}],"id":3}`,
+			expectedCode: int(ErrorCodeServerError),
+		},
+		{
+			name:         "method_not_found",
+			request:      `{"jsonrpc":"2.0","method":"ArithService.Missing","params":[{}],"id":4}`,
+			expectedCode: int(ErrorCodeMethodNotFound),
+		},
+		{
+			name:         "invalid_version",
+			request:      `{"jsonrpc":"1.0","method":"ArithService.Divide","params":[{"a":1,"b":1}],"id":5}`,
+			expectedCode: int(ErrorCodeInvalidRequest),
+		},
+		{
+			name:         "reserved_method",
+			request:      `{"jsonrpc":"2.0","method":"rpc.discover","id":6}`,
+			expectedCode: int(ErrorCodeInvalidRequest),
+		},
+		{
+			name:         "invalid_params",
+			request:      `{"jsonrpc":"2.0","method":"ArithService.Divide","params":[{"a":"one"}],"id":7}`,
+			expectedCode: int(ErrorCodeInvalidParams),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer conn.Close()
+
+			if _, err := conn.Write([]byte(tt.request + "\n")); err != nil {
+				t.Fatalf("failed to write request: %v", err)
+			}
+
+			var response map[string]any
+			if err := json.NewDecoder(conn).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if response["jsonrpc"] != "2.0" {
+				t.Errorf("expected jsonrpc 2.0, got %v", response["jsonrpc"])
+			}
+
+			if tt.expectResult {
+				if _, ok := response["result"]; !ok {
+					t.Errorf("expected result, got %v", response)
+				}
+				if _, ok := response["error"]; ok {
+					t.Errorf("expected no error member, got %v", response["error"])
+				}
+				return
+			}
+
+			if _, ok := response["result"]; ok {
+				t.Errorf("expected no result member, got %v", response["result"])
+			}
+			errObj, ok := response["error"].(map[string]any)
+			if !ok {
+				t.Fatalf("expected error object, got %T", response["error"])
+			}
+			if code := int(errObj["code"].(float64)); code != tt.expectedCode {
+				t.Errorf("expected code %d, got %d", tt.expectedCode, code)
+			}
+			if msg, _ := errObj["message"].(string); msg == "" {
+				t.Error("expected non-empty error message")
+			}
+			if tt.expectedData != nil && !reflect.DeepEqual(errObj["data"], tt.expectedData) {
+				t.Errorf("expected data %v, got %v", tt.expectedData, errObj["data"])
+			}
+		})
+	}
+}
+
+func TestClientCodec_ReadResponseHeaderErrors(t *testing.T) {
+	client, err := Dial("tcp", startTestServer(t))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	var reply int
+	if err := client.Call("ArithService.Divide", DivideArgs{A: 9, B: 3}, &reply); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != 3 {
+		t.Errorf("expected 3, got %d", reply)
+	}
+
+	err = client.Call("ArithService.Divide", DivideArgs{A: 1, B: 0}, &reply)
+	rpcErr, ok := AsError(err)
+	if !ok {
+		t.Fatalf("expected JSON-RPC error, got %v", err)
+	}
+	if rpcErr.Code != -32001 || rpcErr.Message != "division by zero" {
+		t.Errorf("unexpected error: %+v", rpcErr)
+	}
+	if !reflect.DeepEqual(rpcErr.Data, map[string]any{"field": "b"}) {
+		t.Errorf("unexpected error data: %v", rpcErr.Data)
+	}
+
+	// The connection must stay usable after an error response
+	err = client.Call("ArithService.Missing", DivideArgs{}, &reply)
+	if rpcErr, ok := AsError(err); !ok || rpcErr.Code != int(ErrorCodeMethodNotFound) {
+		t.Errorf("expected method not found error, got %v", err)
+	}
+}
+
+func TestServer_SetDispatcher(t *testing.T) {
+	srv := NewServer()
+	srv.SetDispatcher(func(ctx context.Context, call *Call) (any, error) {
+		switch call.MethodName {
+		case "Echo":
+			return map[string]any{"service": call.ServiceName, "params": call.Params, "remote": call.RemoteAddr != ""}, nil
+		case "Nothing":
+			return nil, nil
+		case "Fail":
+			return nil, NewError(ErrorCodeInvalidParams, "bad input", "name")
+		}
+		return nil, fmt.Errorf("unknown method %s", call.MethodName)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go srv.Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	decoder := json.NewDecoder(conn)
+
+	tests := []struct {
+		name     string
+		request  string
+		expected string
+	}{
+		{
+			name:     "named_params",
+			request:  `{"jsonrpc":"2.0","method":"Notes.v1.Echo","params":{"a":1},"id":1}`,
+			expected: `{"jsonrpc":"2.0","result":{"service":"Notes.v1","params":{"a":1},"remote":true},"id":1}`,
+		},
+		{
+			name:     "positional_params",
+			request:  `{"jsonrpc":"2.0","method":"Notes.Echo","params":[{"a":2}],"id":2}`,
+			expected: `{"jsonrpc":"2.0","result":{"service":"Notes","params":{"a":2},"remote":true},"id":2}`,
+		},
+		{
+			name:     "missing_params",
+			request:  `{"jsonrpc":"2.0","method":"Notes.Echo","id":3}`,
+			expected: `{"jsonrpc":"2.0","result":{"service":"Notes","params":{},"remote":true},"id":3}`,
+		},
+		{
+			name:     "nil_result",
+			request:  `{"jsonrpc":"2.0","method":"Notes.Nothing","id":4}`,
+			expected: `{"jsonrpc":"2.0","result":null,"id":4}`,
+		},
+		{
+			name:     "typed_error",
+			request:  `{"jsonrpc":"2.0","method":"Notes.Fail","id":5}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"bad input","data":"name"},"id":5}`,
+		},
+		{
+			name:     "plain_error",
+			request:  `{"jsonrpc":"2.0","method":"Notes.Other","id":6}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32000,"message":"unknown method Other"},"id":6}`,
+		},
+		{
+			name:     "ill_formed_method",
+			request:  `{"jsonrpc":"2.0","method":"Echo","id":7}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"method name must be in format 'ServiceName.MethodName'"},"id":7}`,
+		},
+		{
+			name:     "invalid_version",
+			request:  `{"jsonrpc":"1.0","method":"Notes.Echo","id":8}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"jsonrpc field must be '2.0'"},"id":8}`,
+		},
+		{
+			name:     "invalid_params",
+			request:  `{"jsonrpc":"2.0","method":"Notes.Echo","params":"text","id":9}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"json: cannot unmarshal string into Go value of type [1]interface {}"},"id":9}`,
+		},
+	}
+
+	// Requests are sent one at a time over a single connection, which must stay usable after errors
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := conn.Write([]byte(tt.request + "\n")); err != nil {
+				t.Fatalf("failed to write request: %v", err)
+			}
+
+			var got, want any
+			if err := decoder.Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &want); err != nil {
+				t.Fatalf("invalid expected JSON: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %s, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServer_MalformedRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(srv *Server)
+	}{
+		{
+			name: "registered_services",
+			setup: func(srv *Server) {
+				if err := srv.Register(&ArithService{}); err != nil {
+					t.Fatalf("failed to register service: %v", err)
+				}
+			},
+		},
+		{
+			name: "dispatcher",
+			setup: func(srv *Server) {
+				srv.SetDispatcher(func(ctx context.Context, call *Call) (any, error) {
+					return nil, nil
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer()
+			tt.setup(srv)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			t.Cleanup(func() { listener.Close() })
+			go srv.Serve(listener)
+
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			if _, err := conn.Write([]byte("{not json\n")); err != nil {
+				t.Fatalf("failed to write request: %v", err)
+			}
+
+			decoder := json.NewDecoder(conn)
+			var got map[string]any
+			if err := decoder.Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if id, ok := got["id"]; !ok || id != nil {
+				t.Errorf("expected null id, got %v", got)
+			}
+			rpcErr, _ := got["error"].(map[string]any)
+			if got["jsonrpc"] != "2.0" || rpcErr["code"] != float64(ErrorCodeParseError) || rpcErr["message"] != "Parse error" || rpcErr["data"] == nil {
+				t.Errorf("expected parse error with data, got %v", got)
+			}
+
+			// The server closes the connection after a parse error
+			var next any
+			if err := decoder.Decode(&next); !errors.Is(err, io.EOF) {
+				t.Errorf("expected connection to be closed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseResponseError(t *testing.T) {
+	tests := []struct {
+		name            string
+		raw             string
+		expectedCode    int
+		expectedMessage string
+		expectedError   bool
+	}{
+		{
+			name:            "error_object",
+			raw:             `{"code":-32601,"message":"Method not found"}`,
+			expectedCode:    -32601,
+			expectedMessage: "Method not found",
+		},
+		{
+			name:            "error_object_without_message",
+			raw:             `{"code":-32602}`,
+			expectedCode:    -32602,
+			expectedMessage: "Invalid params",
+		},
+		{
+			name:            "legacy_string",
+			raw:             `"something broke"`,
+			expectedCode:    int(ErrorCodeServerError),
+			expectedMessage: "something broke",
+		},
+		{
+			name:          "invalid_error",
+			raw:           `[1,2]`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpcErr, err := parseResponseError(json.RawMessage(tt.raw))
+
+			if tt.expectedError && err == nil {
+				t.Error("expected error but got none")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.expectedError && err == nil {
+				if rpcErr.Code != tt.expectedCode || rpcErr.Message != tt.expectedMessage {
+					t.Errorf("expected %d %q, got %d %q", tt.expectedCode, tt.expectedMessage, rpcErr.Code, rpcErr.Message)
+				}
+			}
+		})
+	}
+}
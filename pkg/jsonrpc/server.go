@@ -1,17 +1,33 @@
 package jsonrpc
 
 import (
+	"context"
 	"encoding/json"
 	"net"
 	"net/rpc"
+	"strings"
+	"sync"
 )
 
+// Call is a request handed to a Dispatcher
+type Call struct {
+	ServiceName string
+	MethodName  string
+	Params      map[string]any
+	RemoteAddr  string
+}
+
+// Dispatcher executes calls in place of net/rpc's reflection-based dispatch.
+// Returning an *Error controls the code, message and data sent to the client.
+type Dispatcher func(ctx context.Context, call *Call) (any, error)
+
 // Server embeds the rpc.Server.
 // server is not exported, which inherently limits access to the server API.
 // This is a deliberate and opinionated design decision to make the mcp implementation
 // easier to understand and maintain.
 type Server struct {
-	server *rpc.Server
+	server     *rpc.Server
+	dispatcher Dispatcher
 }
 
 // NewServer creates a new json RPC server.
@@ -28,6 +44,14 @@ func (s *Server) Register(rcvr any) error {
 	return s.server.Register(rcvr)
 }
 
+// SetDispatcher routes every request to dispatcher instead of the registered services.
+// Params are decoded into a map and requests of a connection run concurrently;
+// the context of a call is cancelled when its connection closes.
+// It must be called before Serve.
+func (s *Server) SetDispatcher(dispatcher Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
 func (s *Server) Serve(listener net.Listener) error {
 	for {
 		conn, err := listener.Accept()
@@ -35,12 +59,87 @@ func (s *Server) Serve(listener net.Listener) error {
 			return err
 		}
 		go func(conn net.Conn) {
-			s.server.ServeCodec(&serverCodec{
+			codec := &serverCodec{
 				decoder: json.NewDecoder(conn),
 				encoder: json.NewEncoder(conn),
 				closer:  conn,
 				pending: make(map[uint64]*json.RawMessage),
-			})
+				invalid: make(map[uint64]*Error),
+			}
+			if s.dispatcher != nil {
+				s.serveDispatch(codec, conn.RemoteAddr().String())
+				return
+			}
+			s.server.ServeCodec(codec)
 		}(conn)
 	}
 }
+
+// serveDispatch reads requests from codec and answers them with the dispatcher
+// until the connection is closed
+func (s *Server) serveDispatch(codec *serverCodec, remoteAddr string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sending sync.Mutex
+	var wg sync.WaitGroup
+	send := func(resp *rpc.Response, body any) {
+		sending.Lock()
+		defer sending.Unlock()
+		codec.WriteResponse(resp, body)
+	}
+
+	for {
+		var req rpc.Request
+		if err := codec.ReadRequestHeader(&req); err != nil {
+			break
+		}
+
+		// ReadRequestHeader clears the method of invalid requests; WriteResponse
+		// reports the stored error for them as long as the response carries one
+		if req.ServiceMethod == "" {
+			send(&rpc.Response{Seq: req.Seq, Error: ErrorCodeInvalidRequest.Message()}, nil)
+			continue
+		}
+
+		var params map[string]any
+		if err := codec.ReadRequestBody(&params); err != nil {
+			send(&rpc.Response{Seq: req.Seq, Error: err.Error()}, nil)
+			continue
+		}
+		if params == nil {
+			params = make(map[string]any)
+		}
+
+		dot := strings.LastIndex(req.ServiceMethod, ".")
+		if dot <= 0 || dot == len(req.ServiceMethod)-1 {
+			err := NewError(ErrorCodeMethodNotFound, ErrorCodeMethodNotFound.Message(), "method name must be in format 'ServiceName.MethodName'")
+			send(&rpc.Response{Seq: req.Seq, Error: err.Error()}, nil)
+			continue
+		}
+		call := &Call{
+			ServiceName: req.ServiceMethod[:dot],
+			MethodName:  req.ServiceMethod[dot+1:],
+			Params:      params,
+			RemoteAddr:  remoteAddr,
+		}
+
+		wg.Add(1)
+		go func(seq uint64) {
+			defer wg.Done()
+			result, err := s.dispatcher(ctx, call)
+			if err != nil {
+				send(&rpc.Response{Seq: seq, Error: err.Error()}, nil)
+				return
+			}
+			if result == nil {
+				result = jsonNull
+			}
+			send(&rpc.Response{Seq: seq}, result)
+		}(req.Seq)
+	}
+
+	cancel()
+	wg.Wait()
+	codec.Close()
+}
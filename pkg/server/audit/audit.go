@@ -0,0 +1,202 @@
+// Package audit records every tool call in a persistent log: when it was made, by whom,
+// with which params, and what came of it. An Auditor's interceptor writes the records to a
+// Store, such as the SQLite store of package sqlitestore, and its handler serves them
+// read-only over HTTP.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// Redacted replaces the values of redacted fields
+const Redacted = "[REDACTED]"
+
+// Record is one tool call
+type Record struct {
+	ID         int64           `json:"id"`
+	Time       time.Time       `json:"time"`
+	Transport  string          `json:"transport"`
+	RemoteAddr string          `json:"remoteAddr,omitempty"`
+	Subject    string          `json:"subject,omitempty"` // Subject of the caller's principal
+	Tool       string          `json:"tool"`              // "ServiceName.MethodName"
+	Params     json.RawMessage `json:"params,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	ErrorCode  int             `json:"errorCode,omitempty"` // JSON-RPC code of the error, if it has one
+	DurationMs float64         `json:"durationMs"`
+}
+
+// Query selects records. Zero fields do not filter.
+type Query struct {
+	Subject    string
+	Tool       string // "ServiceName" or "ServiceName.MethodName"
+	Transport  string
+	OnlyErrors bool
+	Since      time.Time // Inclusive
+	Until      time.Time // Exclusive
+	Before     int64     // Only records with a smaller ID, for paging
+	Limit      int
+}
+
+// Store persists audit records. Implementations must be safe for concurrent use.
+type Store interface {
+	// Append adds a record and sets its ID
+	Append(ctx context.Context, record *Record) error
+	// Query returns the records matching q, newest first
+	Query(ctx context.Context, q Query) ([]*Record, error)
+}
+
+// errorMessage is the message of logged write failures
+const errorMessage = "audit record not written"
+
+// AuditorOpts defines options for configuring the auditor
+type AuditorOpts func(*Auditor)
+
+// Auditor records tool calls to a Store
+type Auditor struct {
+	store       Store
+	now         func() time.Time
+	redacted    map[string]bool // Key: lower-case field name
+	onError     func(error)
+	readerRoles []string
+}
+
+// NewAuditor creates an auditor writing to store
+func NewAuditor(store Store, opts ...AuditorOpts) *Auditor {
+	a := &Auditor{
+		store:    store,
+		now:      time.Now,
+		redacted: make(map[string]bool),
+		onError:  server.NewLogger(slog.Default()).ErrorHandler(errorMessage),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithRedactedFields replaces the values of fields with these names by Redacted, wherever
+// they occur in params and results. Names are matched case-insensitively.
+func WithRedactedFields(names ...string) AuditorOpts {
+	return func(a *Auditor) {
+		for _, name := range names {
+			a.redacted[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// WithErrorHandler sets the function called when a record cannot be written.
+// Calls are never failed because of the audit log; by default the error is logged to the
+// default slog logger.
+func WithErrorHandler(fn func(error)) AuditorOpts {
+	return func(a *Auditor) {
+		a.onError = fn
+	}
+}
+
+// WithLogger logs records that cannot be written to logger, at warn level
+func WithLogger(logger *slog.Logger) AuditorOpts {
+	return func(a *Auditor) {
+		a.onError = server.NewLogger(logger).ErrorHandler(errorMessage)
+	}
+}
+
+// WithReaderRoles allows principals with one of roles to read the log through the HTTP
+// handler. Without it, the handler denies every caller.
+func WithReaderRoles(roles ...string) AuditorOpts {
+	return func(a *Auditor) {
+		a.readerRoles = append(a.readerRoles, roles...)
+	}
+}
+
+// Interceptor returns an interceptor that records every call passing through it.
+// Install it with (*server.Server).UseFirst so that calls rejected by other interceptors,
+// such as authorization or rate limits, are recorded as well. Params are recorded as they
+// were before the inner interceptors ran.
+func (a *Auditor) Interceptor() server.Interceptor {
+	return func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		start := a.now()
+		record := &Record{
+			Time:       start,
+			Transport:  call.Caller.Transport,
+			RemoteAddr: call.Caller.RemoteAddr,
+			Tool:       call.ServiceName + "." + call.MethodName,
+			Params:     a.encode(call.Params),
+		}
+		if call.Caller.Principal != nil {
+			record.Subject = call.Caller.Principal.Subject
+		}
+
+		result, err := next(ctx, call)
+
+		record.DurationMs = float64(a.now().Sub(start)) / float64(time.Millisecond)
+		if err != nil {
+			record.Error = err.Error()
+			if rpcErr, ok := jsonrpc.AsError(err); ok {
+				record.ErrorCode = rpcErr.Code
+				record.Error = rpcErr.Message
+				if data, ok := rpcErr.Data.(string); ok && data != "" {
+					record.Error += ": " + data
+				}
+			}
+		} else {
+			record.Result = a.encode(result)
+		}
+
+		// The record is written even if the client has gone away
+		if writeErr := a.store.Append(context.WithoutCancel(ctx), record); writeErr != nil {
+			a.onError(writeErr)
+		}
+		return result, err
+	}
+}
+
+// encode returns v as JSON with redacted fields replaced, or nil if v cannot be encoded
+func (a *Auditor) encode(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	if len(a.redacted) == 0 {
+		return encoded
+	}
+
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil
+	}
+	encoded, err = json.Marshal(a.redact(decoded))
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
+
+// redact replaces the values of redacted fields in a decoded JSON value
+func (a *Auditor) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if a.redacted[strings.ToLower(key)] {
+				v[key] = Redacted
+			} else {
+				v[key] = a.redact(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = a.redact(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// memoryStore keeps records in memory; Query ignores its filters
+type memoryStore struct {
+	records []*Record
+}
+
+func (s *memoryStore) Append(ctx context.Context, record *Record) error {
+	record.ID = int64(len(s.records) + 1)
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memoryStore) Query(ctx context.Context, q Query) ([]*Record, error) {
+	return s.records, nil
+}
+
+// fakeClock advances by step every time it is read
+type fakeClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+func TestAuditor_Interceptor(t *testing.T) {
+	principal := &server.Principal{Subject: "ada"}
+
+	tests := []struct {
+		name              string
+		opts              []AuditorOpts
+		params            map[string]interface{}
+		result            any
+		err               error
+		expectedParams    string
+		expectedResult    string
+		expectedError     string
+		expectedErrorCode int
+	}{
+		{
+			name:           "result_is_recorded",
+			params:         map[string]interface{}{"text": "hi"},
+			result:         map[string]interface{}{"id": 7},
+			expectedParams: `{"text":"hi"}`,
+			expectedResult: `{"id":7}`,
+		},
+		{
+			name: "fields_are_redacted_at_any_depth",
+			opts: []AuditorOpts{WithRedactedFields("password", "APIKey")},
+			params: map[string]interface{}{
+				"user":     "ada",
+				"Password": "hunter2",
+				"accounts": []interface{}{map[string]interface{}{"apiKey": "k-123", "name": "billing"}},
+			},
+			result:         struct{ APIKey string }{APIKey: "k-456"},
+			expectedParams: `{"Password":"[REDACTED]","accounts":[{"apiKey":"[REDACTED]","name":"billing"}],"user":"ada"}`,
+			expectedResult: `{"APIKey":"[REDACTED]"}`,
+		},
+		{
+			name:              "jsonrpc_errors_keep_their_code",
+			params:            map[string]interface{}{},
+			err:               jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, "Forbidden", "missing scopes: notes:write"),
+			expectedParams:    `{}`,
+			expectedError:     "Forbidden: missing scopes: notes:write",
+			expectedErrorCode: int(jsonrpc.ErrorCodeForbidden),
+		},
+		{
+			name:           "other_errors",
+			params:         map[string]interface{}{},
+			err:            errors.New("disk full"),
+			expectedParams: `{}`,
+			expectedError:  "disk full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{}
+			auditor := NewAuditor(store, tt.opts...)
+			clock := &fakeClock{now: time.Unix(1700000000, 0), step: 1500 * time.Microsecond}
+			auditor.now = clock.Now
+
+			call := &server.ToolCall{
+				ServiceName: "NoteService",
+				MethodName:  "Add",
+				Params:      tt.params,
+				Caller:      server.Caller{Transport: server.TransportHTTP, RemoteAddr: "10.0.0.1:5000", Principal: principal},
+			}
+			result, err := auditor.Interceptor()(context.Background(), call, func(ctx context.Context, call *server.ToolCall) (any, error) {
+				// Inner interceptors may change params after they have been recorded
+				call.Params["tenant"] = "acme"
+				return tt.result, tt.err
+			})
+			if err != tt.err || (tt.err == nil && result == nil) {
+				t.Fatalf("expected the call's outcome to pass through, got %v, %v", result, err)
+			}
+
+			records, err := store.Query(context.Background(), Query{})
+			if err != nil || len(records) != 1 {
+				t.Fatalf("expected one record, got %v, %v", records, err)
+			}
+			record := records[0]
+			if record.Tool != "NoteService.Add" || record.Subject != "ada" || record.Transport != server.TransportHTTP || record.RemoteAddr != "10.0.0.1:5000" {
+				t.Errorf("unexpected caller in record %+v", record)
+			}
+			if !record.Time.Equal(time.Unix(1700000000, 0)) || record.DurationMs != 1.5 {
+				t.Errorf("expected time and duration of 1.5ms, got %v and %v", record.Time, record.DurationMs)
+			}
+			if string(record.Params) != tt.expectedParams {
+				t.Errorf("expected params %s, got %s", tt.expectedParams, record.Params)
+			}
+			if string(record.Result) != tt.expectedResult {
+				t.Errorf("expected result %s, got %s", tt.expectedResult, record.Result)
+			}
+			if record.Error != tt.expectedError || record.ErrorCode != tt.expectedErrorCode {
+				t.Errorf("expected error %q (%d), got %q (%d)", tt.expectedError, tt.expectedErrorCode, record.Error, record.ErrorCode)
+			}
+		})
+	}
+}
+
+// failingStore fails every write
+type failingStore struct{}
+
+func (failingStore) Append(ctx context.Context, record *Record) error {
+	return errors.New("disk full")
+}
+
+func (failingStore) Query(ctx context.Context, q Query) ([]*Record, error) {
+	return nil, nil
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	var reported error
+	auditor := NewAuditor(failingStore{}, WithErrorHandler(func(err error) { reported = err }))
+
+	result, err := auditor.Interceptor()(context.Background(), &server.ToolCall{}, func(ctx context.Context, call *server.ToolCall) (any, error) {
+		return "ok", nil
+	})
+	if result != "ok" || err != nil {
+		t.Errorf("expected the call to succeed despite the audit failure, got %v, %v", result, err)
+	}
+	if reported == nil || reported.Error() != "disk full" {
+		t.Errorf("expected the write error to be reported, got %v", reported)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	auditor := NewAuditor(failingStore{}, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	auditor.Interceptor()(context.Background(), &server.ToolCall{}, func(ctx context.Context, call *server.ToolCall) (any, error) {
+		return "ok", nil
+	})
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("failed to decode logged event %q: %v", buf.String(), err)
+	}
+	if event["level"] != "WARN" || event["msg"] != errorMessage || event["error"] != "disk full" {
+		t.Errorf("expected the write error to be logged, got %v", event)
+	}
+}
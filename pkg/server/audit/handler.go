@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// Page sizes of the HTTP handler
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Handler returns a read-only HTTP handler serving the audit log at GET /audit to principals
+// with one of the roles set by WithReaderRoles; without them every request is forbidden.
+// Records are returned newest first and filtered by the query parameters subject, tool
+// ("Service" or "Service.Method"), transport, errors=true, and since and until (RFC 3339).
+// limit sets the page size; the response's "next" value, passed as before, fetches the
+// following page:
+//
+//	GET /audit?tool=NoteService.Delete&since=2025-06-01T00:00:00Z&limit=20
+//	{"records": [...], "next": 1234}
+func (a *Auditor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /audit", a.handleQuery)
+	return mux
+}
+
+// handleQuery serves a page of records
+func (a *Auditor) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if !a.mayRead(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	q, err := parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := a.store.Query(r.Context(), q)
+	if err != nil {
+		http.Error(w, "failed to query audit log", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{"records": records}
+	if records == nil {
+		response["records"] = []*Record{}
+	}
+	if len(records) == q.Limit {
+		response["next"] = records[len(records)-1].ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// mayRead reports whether the caller may read the audit log. Without reader roles nobody may.
+func (a *Auditor) mayRead(r *http.Request) bool {
+	principal, ok := server.PrincipalFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	for _, role := range a.readerRoles {
+		if principal.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseQuery reads the filters of a request
+func parseQuery(r *http.Request) (Query, error) {
+	values := r.URL.Query()
+	q := Query{
+		Subject:    values.Get("subject"),
+		Tool:       values.Get("tool"),
+		Transport:  values.Get("transport"),
+		OnlyErrors: values.Get("errors") == "true",
+		Limit:      defaultPageSize,
+	}
+
+	var err error
+	if q.Since, err = parseTime(values.Get("since")); err != nil {
+		return Query{}, fmt.Errorf("since must be an RFC 3339 time")
+	}
+	if q.Until, err = parseTime(values.Get("until")); err != nil {
+		return Query{}, fmt.Errorf("until must be an RFC 3339 time")
+	}
+	if before := values.Get("before"); before != "" {
+		if q.Before, err = strconv.ParseInt(before, 10, 64); err != nil || q.Before < 1 {
+			return Query{}, fmt.Errorf("before must be a positive integer")
+		}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return Query{}, fmt.Errorf("limit must be a positive integer")
+		}
+		q.Limit = min(n, maxPageSize)
+	}
+	return q, nil
+}
+
+// parseTime parses an optional RFC 3339 time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
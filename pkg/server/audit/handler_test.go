@@ -0,0 +1,155 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/audit"
+	"github.com/pangobit/agent-sdk/pkg/server/audit/sqlitestore"
+)
+
+func TestAuditor_Handler(t *testing.T) {
+	store, err := sqlitestore.Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, tool := range []string{"NoteService.Add", "NoteService.Delete", "NoteService.Add"} {
+		store.Append(context.Background(), &audit.Record{Time: start.Add(time.Duration(i) * time.Hour), Transport: "http", Subject: "ada", Tool: tool})
+	}
+
+	auditor := &server.Principal{Subject: "ada", Roles: []string{"auditor"}}
+
+	tests := []struct {
+		name           string
+		readerRoles    []string
+		path           string
+		principal      *server.Principal
+		expectedStatus int
+		expectedIDs    []int64
+		expectedNext   any
+	}{
+		{
+			name:           "all_records",
+			readerRoles:    []string{"auditor"},
+			principal:      auditor,
+			path:           "/audit",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{3, 2, 1},
+		},
+		{
+			name:           "filters",
+			readerRoles:    []string{"auditor"},
+			principal:      auditor,
+			path:           "/audit?tool=NoteService.Add&since=2025-06-01T01:00:00Z",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{3},
+		},
+		{
+			name:           "full_page_has_next",
+			readerRoles:    []string{"auditor"},
+			principal:      auditor,
+			path:           "/audit?limit=2",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{3, 2},
+			expectedNext:   float64(2),
+		},
+		{
+			name:           "next_page",
+			readerRoles:    []string{"auditor"},
+			principal:      auditor,
+			path:           "/audit?limit=2&before=2",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{1},
+		},
+		{
+			name:           "no_records",
+			readerRoles:    []string{"auditor"},
+			principal:      auditor,
+			path:           "/audit?subject=bob",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{},
+		},
+		{
+			name:           "invalid_time",
+			readerRoles:    []string{"auditor"},
+			principal:      auditor,
+			path:           "/audit?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "reader_role_required",
+			readerRoles:    []string{"auditor"},
+			path:           "/audit",
+			principal:      &server.Principal{Subject: "ada", Roles: []string{"admin"}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no_principal",
+			readerRoles:    []string{"auditor"},
+			path:           "/audit",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no_reader_roles_denies_everyone",
+			path:           "/audit",
+			principal:      auditor,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "reader_role",
+			readerRoles:    []string{"auditor"},
+			path:           "/audit?limit=1",
+			principal:      auditor,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{3},
+			expectedNext:   float64(3),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := audit.NewAuditor(store, audit.WithReaderRoles(tt.readerRoles...)).Handler()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(server.ContextWithPrincipal(req.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Records []audit.Record `json:"records"`
+				Next    any            `json:"next"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			ids := []int64{}
+			for _, record := range response.Records {
+				ids = append(ids, record.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("expected records %v, got %v", tt.expectedIDs, ids)
+			}
+			if response.Next != tt.expectedNext {
+				t.Errorf("expected next %v, got %v", tt.expectedNext, response.Next)
+			}
+		})
+	}
+}
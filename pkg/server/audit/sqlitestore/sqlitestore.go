@@ -0,0 +1,160 @@
+// Package sqlitestore keeps the records of an audit.Auditor in a SQLite database. It is a
+// package of its own so that only programs using it link SQLite.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pangobit/agent-sdk/internal/sqlitedb"
+	"github.com/pangobit/agent-sdk/pkg/server/audit"
+)
+
+// sqliteSchema creates the audit table. Times are stored as Unix nanoseconds.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS audit_log (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	time        INTEGER NOT NULL,
+	transport   TEXT NOT NULL,
+	remote_addr TEXT NOT NULL,
+	subject     TEXT NOT NULL,
+	service     TEXT NOT NULL,
+	method      TEXT NOT NULL,
+	params      TEXT,
+	result      TEXT,
+	error       TEXT NOT NULL,
+	error_code  INTEGER NOT NULL,
+	duration_ms REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_time ON audit_log (time);
+CREATE INDEX IF NOT EXISTS audit_log_subject ON audit_log (subject, id);
+CREATE INDEX IF NOT EXISTS audit_log_tool ON audit_log (service, method, id);
+`
+
+// Store keeps audit records in a SQLite database. It implements audit.Store.
+type Store struct {
+	db    *sql.DB
+	owned bool // Whether Close closes db
+}
+
+// New stores audit records in db, which must use a SQLite driver such as
+// modernc.org/sqlite. The audit_log table is created if it does not exist.
+func New(db *sql.DB) (*Store, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create audit table: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Open opens or creates the SQLite database file at path and stores audit records in it
+func Open(path string) (*Store, error) {
+	db, err := sqlitedb.Open(path, sqliteSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	return &Store{db: db, owned: true}, nil
+}
+
+// Close closes the database if it was opened by Open
+func (s *Store) Close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Append implements audit.Store
+func (s *Store) Append(ctx context.Context, record *audit.Record) error {
+	serviceName, methodName, _ := strings.Cut(record.Tool, ".")
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO audit_log (time, transport, remote_addr, subject, service, method, params, result, error, error_code, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Time.UnixNano(), record.Transport, record.RemoteAddr, record.Subject, serviceName, methodName,
+		nullJSON(record.Params), nullJSON(record.Result), record.Error, record.ErrorCode, record.DurationMs)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	record.ID, err = result.LastInsertId()
+	return err
+}
+
+// Query implements audit.Store
+func (s *Store) Query(ctx context.Context, q audit.Query) ([]*audit.Record, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if q.Subject != "" {
+		where, args = append(where, "subject = ?"), append(args, q.Subject)
+	}
+	if q.Tool != "" {
+		serviceName, methodName, hasMethod := strings.Cut(q.Tool, ".")
+		where, args = append(where, "service = ?"), append(args, serviceName)
+		if hasMethod {
+			where, args = append(where, "method = ?"), append(args, methodName)
+		}
+	}
+	if q.Transport != "" {
+		where, args = append(where, "transport = ?"), append(args, q.Transport)
+	}
+	if q.OnlyErrors {
+		where = append(where, "error != ''")
+	}
+	if !q.Since.IsZero() {
+		where, args = append(where, "time >= ?"), append(args, q.Since.UnixNano())
+	}
+	if !q.Until.IsZero() {
+		where, args = append(where, "time < ?"), append(args, q.Until.UnixNano())
+	}
+	if q.Before > 0 {
+		where, args = append(where, "id < ?"), append(args, q.Before)
+	}
+
+	query := "SELECT id, time, transport, remote_addr, subject, service, method, params, result, error, error_code, duration_ms FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*audit.Record
+	for rows.Next() {
+		var (
+			record                  audit.Record
+			recorded                int64
+			serviceName, methodName string
+			params, result          sql.NullString
+		)
+		if err := rows.Scan(&record.ID, &recorded, &record.Transport, &record.RemoteAddr, &record.Subject,
+			&serviceName, &methodName, &params, &result, &record.Error, &record.ErrorCode, &record.DurationMs); err != nil {
+			return nil, fmt.Errorf("failed to read audit record: %w", err)
+		}
+		record.Time = time.Unix(0, recorded)
+		record.Tool = serviceName + "." + methodName
+		if params.Valid {
+			record.Params = []byte(params.String)
+		}
+		if result.Valid {
+			record.Result = []byte(result.String)
+		}
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
+
+// nullJSON stores an empty JSON value as NULL
+func nullJSON(v []byte) sql.NullString {
+	return sql.NullString{String: string(v), Valid: len(v) > 0}
+}
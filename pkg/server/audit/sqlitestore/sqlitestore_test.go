@@ -0,0 +1,64 @@
+package sqlitestore
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server/audit"
+)
+
+func TestStore_Query(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	start := time.Unix(1700000000, 0)
+	seed := []*audit.Record{
+		{Time: start, Transport: "http", Subject: "ada", Tool: "NoteService.Add"},
+		{Time: start.Add(time.Minute), Transport: "mcp", Subject: "bob", Tool: "NoteService.Delete", Error: "Forbidden", ErrorCode: -32003},
+		{Time: start.Add(2 * time.Minute), Transport: "http", Subject: "ada", Tool: "NoteService.Delete"},
+		{Time: start.Add(3 * time.Minute), Transport: "tcp", Subject: "ada", Tool: "SearchService.Query", Error: "timeout"},
+	}
+	for _, record := range seed {
+		if err := store.Append(context.Background(), record); err != nil {
+			t.Fatalf("failed to append record: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		query       audit.Query
+		expectedIDs []int64
+	}{
+		{name: "all_newest_first", query: audit.Query{}, expectedIDs: []int64{4, 3, 2, 1}},
+		{name: "subject", query: audit.Query{Subject: "bob"}, expectedIDs: []int64{2}},
+		{name: "service", query: audit.Query{Tool: "NoteService"}, expectedIDs: []int64{3, 2, 1}},
+		{name: "method", query: audit.Query{Tool: "NoteService.Delete"}, expectedIDs: []int64{3, 2}},
+		{name: "transport", query: audit.Query{Transport: "http"}, expectedIDs: []int64{3, 1}},
+		{name: "only_errors", query: audit.Query{OnlyErrors: true}, expectedIDs: []int64{4, 2}},
+		{name: "time_range", query: audit.Query{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}, expectedIDs: []int64{3, 2}},
+		{name: "first_page", query: audit.Query{Limit: 2}, expectedIDs: []int64{4, 3}},
+		{name: "second_page", query: audit.Query{Limit: 2, Before: 3}, expectedIDs: []int64{2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := store.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("failed to query: %v", err)
+			}
+			var ids []int64
+			for _, record := range records {
+				ids = append(ids, record.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("expected %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Package auth authenticates HTTP requests to the server.
+// Authenticators establish a server.Principal from static bearer tokens, HMAC-signed
+// requests or JWTs verified against a local JWKS; the HTTP transport stores it in the
+// request context, where interceptors and service methods can read it.
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// ErrNoCredentials is returned by authenticators when a request carries no credentials
+// for their scheme, as opposed to credentials that are invalid
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator establishes the principal behind an HTTP request
+type Authenticator interface {
+	// Authenticate returns the principal of r, ErrNoCredentials if r carries no
+	// credentials for the authenticator's scheme, or an error describing why the
+	// credentials were rejected
+	Authenticate(r *http.Request) (*server.Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface
+type AuthenticatorFunc func(r *http.Request) (*server.Principal, error)
+
+// Authenticate calls f(r)
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*server.Principal, error) {
+	return f(r)
+}
+
+// Challenger is implemented by authenticators that name their scheme in the
+// WWW-Authenticate header of 401 responses
+type Challenger interface {
+	Challenge() string
+}
+
+// authorizationCredentials returns the credentials of an Authorization header using scheme.
+// Scheme names are case-insensitive.
+func authorizationCredentials(r *http.Request, scheme string) (string, bool) {
+	header := r.Header.Get("Authorization")
+	prefix, credentials, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(prefix, scheme) {
+		return "", false
+	}
+	credentials = strings.TrimSpace(credentials)
+	return credentials, credentials != ""
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// BearerAuthenticator accepts a fixed set of bearer tokens
+type BearerAuthenticator struct {
+	tokens map[[sha256.Size]byte]server.Principal // Key: SHA-256 of the token
+}
+
+// NewBearerAuthenticator creates an authenticator for static bearer tokens, sent as
+// "Authorization: Bearer <token>". Each token maps to the principal it authenticates;
+// principals without a Scheme get "bearer".
+func NewBearerAuthenticator(tokens map[string]server.Principal) *BearerAuthenticator {
+	a := &BearerAuthenticator{tokens: make(map[[sha256.Size]byte]server.Principal, len(tokens))}
+	for token, principal := range tokens {
+		if principal.Scheme == "" {
+			principal.Scheme = "bearer"
+		}
+		// Tokens are looked up by hash so lookups do not leak how much of a guess matched
+		a.tokens[sha256.Sum256([]byte(token))] = principal
+	}
+	return a
+}
+
+// Authenticate implements Authenticator
+func (a *BearerAuthenticator) Authenticate(r *http.Request) (*server.Principal, error) {
+	token, ok := authorizationCredentials(r, "Bearer")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	principal, ok := a.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		return nil, errors.New("invalid bearer token")
+	}
+	return &principal, nil
+}
+
+// Challenge implements Challenger
+func (a *BearerAuthenticator) Challenge() string {
+	return "Bearer"
+}
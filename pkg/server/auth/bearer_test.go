@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+func TestBearerAuthenticator_Authenticate(t *testing.T) {
+	authenticator := NewBearerAuthenticator(map[string]server.Principal{
+		"s3cr3t": {Subject: "ci", Scopes: []string{"notes:write"}},
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      *server.Principal
+		expectedErr   error
+	}{
+		{
+			name:          "valid_token",
+			authorization: "Bearer s3cr3t",
+			expected:      &server.Principal{Subject: "ci", Scheme: "bearer", Scopes: []string{"notes:write"}},
+		},
+		{
+			name:          "scheme_is_case_insensitive",
+			authorization: "bearer s3cr3t",
+			expected:      &server.Principal{Subject: "ci", Scheme: "bearer", Scopes: []string{"notes:write"}},
+		},
+		{
+			name:          "unknown_token",
+			authorization: "Bearer guess",
+			expectedErr:   errors.New("invalid bearer token"),
+		},
+		{
+			name:        "missing_header",
+			expectedErr: ErrNoCredentials,
+		},
+		{
+			name:          "other_scheme",
+			authorization: "Basic czNjcjN0",
+			expectedErr:   ErrNoCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/execute", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			principal, err := authenticator.Authenticate(req)
+			if tt.expectedErr != nil {
+				if err == nil || err.Error() != tt.expectedErr.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(principal, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, principal)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+const (
+	// HMACScheme is the Authorization scheme of HMAC-signed requests
+	HMACScheme = "HMAC-SHA256"
+	// TimestampHeader carries the Unix time, in seconds, at which a request was signed
+	TimestampHeader = "X-Agent-Timestamp"
+)
+
+// HMACAuthenticatorOpts defines options for configuring the HMAC authenticator
+type HMACAuthenticatorOpts func(*HMACAuthenticator)
+
+// HMACAuthenticator accepts requests signed with a shared secret.
+// A request carries "Authorization: HMAC-SHA256 <key id>:<signature>" and the
+// X-Agent-Timestamp header, where the signature is the hex-encoded HMAC-SHA256 of
+//
+//	METHOD "\n" REQUEST-URI "\n" TIMESTAMP "\n" hex(SHA-256(body))
+//
+// Requests signed too far from the current time are rejected, which bounds replays.
+// SignRequest produces such requests.
+type HMACAuthenticator struct {
+	keys    map[string][]byte // Key: key id, value: secret
+	maxSkew time.Duration
+	now     func() time.Time
+}
+
+// NewHMACAuthenticator creates an authenticator for requests signed with one of keys,
+// a map of key id to secret. The principal's subject is the key id.
+func NewHMACAuthenticator(keys map[string][]byte, opts ...HMACAuthenticatorOpts) *HMACAuthenticator {
+	a := &HMACAuthenticator{
+		keys:    keys,
+		maxSkew: 5 * time.Minute,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithMaxClockSkew sets how far the signing time of a request may be from the
+// server's clock. The default is five minutes.
+func WithMaxClockSkew(d time.Duration) HMACAuthenticatorOpts {
+	return func(a *HMACAuthenticator) {
+		a.maxSkew = d
+	}
+}
+
+// Authenticate implements Authenticator. The request body is read to verify the
+// signature and replaced so that handlers can read it again.
+func (a *HMACAuthenticator) Authenticate(r *http.Request) (*server.Principal, error) {
+	credentials, ok := authorizationCredentials(r, HMACScheme)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	keyID, signature, found := strings.Cut(credentials, ":")
+	if !found {
+		return nil, errors.New("malformed HMAC credentials")
+	}
+	secret, ok := a.keys[keyID]
+	if !ok {
+		return nil, errors.New("unknown HMAC key")
+	}
+
+	timestamp := r.Header.Get(TimestampHeader)
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("missing or invalid %s header", TimestampHeader)
+	}
+	if skew := a.now().Sub(time.Unix(signedAt, 0)); skew > a.maxSkew || skew < -a.maxSkew {
+		return nil, errors.New("request timestamp outside the allowed window")
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := requestSignature(secret, r.Method, r.URL.RequestURI(), timestamp, body)
+	if got, err := hex.DecodeString(signature); err != nil || !hmac.Equal(got, expected) {
+		return nil, errors.New("invalid HMAC signature")
+	}
+
+	return &server.Principal{Subject: keyID, Scheme: "hmac"}, nil
+}
+
+// Challenge implements Challenger
+func (a *HMACAuthenticator) Challenge() string {
+	return HMACScheme
+}
+
+// SignRequest signs r for an HMACAuthenticator holding secret under keyID.
+// It must be called after the request's URL and body are final.
+func SignRequest(r *http.Request, keyID string, secret []byte) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := requestSignature(secret, r.Method, r.URL.RequestURI(), timestamp, body)
+
+	r.Header.Set(TimestampHeader, timestamp)
+	r.Header.Set("Authorization", HMACScheme+" "+keyID+":"+hex.EncodeToString(sig))
+	return nil
+}
+
+// requestSignature computes the HMAC of a request's canonical form
+func requestSignature(secret []byte, method, requestURI, timestamp string, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", method, requestURI, timestamp, hex.EncodeToString(bodyHash[:]))
+	return mac.Sum(nil)
+}
+
+// readBody reads the body of r and replaces it with an in-memory copy
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACAuthenticator_Authenticate(t *testing.T) {
+	secret := []byte("shared-secret")
+	body := `{"jsonrpc":"2.0","method":"Notes.Add","params":{},"id":1}`
+
+	tests := []struct {
+		name        string
+		modify      func(r *http.Request)
+		expectedErr string
+	}{
+		{
+			name: "valid_signature",
+		},
+		{
+			name: "tampered_body",
+			modify: func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","method":"Notes.Delete","id":1}`))
+			},
+			expectedErr: "invalid HMAC signature",
+		},
+		{
+			name:        "tampered_path",
+			modify:      func(r *http.Request) { r.URL.Path = "/admin" },
+			expectedErr: "invalid HMAC signature",
+		},
+		{
+			name: "stale_timestamp",
+			modify: func(r *http.Request) {
+				r.Header.Set(TimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+			},
+			expectedErr: "request timestamp outside the allowed window",
+		},
+		{
+			name:        "missing_timestamp",
+			modify:      func(r *http.Request) { r.Header.Del(TimestampHeader) },
+			expectedErr: "missing or invalid X-Agent-Timestamp header",
+		},
+		{
+			name:        "unknown_key",
+			modify:      func(r *http.Request) { r.Header.Set("Authorization", HMACScheme+" other:00") },
+			expectedErr: "unknown HMAC key",
+		},
+		{
+			name:        "malformed_credentials",
+			modify:      func(r *http.Request) { r.Header.Set("Authorization", HMACScheme+" ci") },
+			expectedErr: "malformed HMAC credentials",
+		},
+		{
+			name:        "no_credentials",
+			modify:      func(r *http.Request) { r.Header.Del("Authorization") },
+			expectedErr: ErrNoCredentials.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := NewHMACAuthenticator(map[string][]byte{"ci": secret})
+
+			req := httptest.NewRequest(http.MethodPost, "/agents/api/v1/execute?trace=1", strings.NewReader(body))
+			if err := SignRequest(req, "ci", secret); err != nil {
+				t.Fatalf("failed to sign request: %v", err)
+			}
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+
+			principal, err := authenticator.Authenticate(req)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.Subject != "ci" || principal.Scheme != "hmac" {
+				t.Errorf("unexpected principal %+v", principal)
+			}
+
+			// The body must still be readable by the handler
+			replayed, _ := io.ReadAll(req.Body)
+			if string(replayed) != body {
+				t.Errorf("expected body to be preserved, got %q", replayed)
+			}
+		})
+	}
+}
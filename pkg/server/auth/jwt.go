@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// JWTAuthenticatorOpts defines options for configuring the JWT authenticator
+type JWTAuthenticatorOpts func(*JWTAuthenticator)
+
+// JWTAuthenticator accepts bearer JWTs signed by a key of a JWKS.
+// Supported algorithms are RS256/384/512, PS256/384/512, ES256/384/512 and EdDSA.
+// Tokens must not be expired or used before their "nbf" time; the issuer and audience
+// are checked when configured.
+//
+// Tokens without an "exp" claim never expire: a leaked token stays valid until its signing
+// key is removed from the JWKS. Use WithRequiredExpiration to reject such tokens.
+type JWTAuthenticator struct {
+	keys              *JWKS
+	issuer            string
+	audience          string
+	leeway            time.Duration
+	requireExpiration bool
+	now               func() time.Time
+}
+
+// NewJWTAuthenticator creates an authenticator for JWTs sent as "Authorization: Bearer <token>".
+// The principal's subject is the "sub" claim and its scopes come from the "scope"
+// (space-separated) or "scp" claim, its roles from the "roles" claim; all claims are
+// kept in Principal.Claims.
+func NewJWTAuthenticator(keys *JWKS, opts ...JWTAuthenticatorOpts) *JWTAuthenticator {
+	a := &JWTAuthenticator{
+		keys:   keys,
+		leeway: time.Minute,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithIssuer requires the "iss" claim to equal issuer
+func WithIssuer(issuer string) JWTAuthenticatorOpts {
+	return func(a *JWTAuthenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudience requires the "aud" claim to contain audience
+func WithAudience(audience string) JWTAuthenticatorOpts {
+	return func(a *JWTAuthenticator) {
+		a.audience = audience
+	}
+}
+
+// WithLeeway sets the clock skew tolerated when checking "exp" and "nbf". The default is one minute.
+func WithLeeway(d time.Duration) JWTAuthenticatorOpts {
+	return func(a *JWTAuthenticator) {
+		a.leeway = d
+	}
+}
+
+// WithRequiredExpiration rejects tokens without an "exp" claim, which are otherwise
+// accepted indefinitely
+func WithRequiredExpiration() JWTAuthenticatorOpts {
+	return func(a *JWTAuthenticator) {
+		a.requireExpiration = true
+	}
+}
+
+// Authenticate implements Authenticator
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*server.Principal, error) {
+	token, ok := authorizationCredentials(r, "Bearer")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &server.Principal{Scheme: "jwt", Claims: claims}
+	principal.Subject, _ = claims["sub"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	} else if scp, ok := claims["scp"].(string); ok {
+		principal.Scopes = strings.Fields(scp)
+	} else {
+		principal.Scopes = stringList(claims["scp"])
+	}
+	principal.Roles = stringList(claims["roles"])
+	return principal, nil
+}
+
+// Challenge implements Challenger
+func (a *JWTAuthenticator) Challenge() string {
+	return "Bearer"
+}
+
+// verify checks the signature and registered claims of a compact JWT and returns its claims
+func (a *JWTAuthenticator) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+
+	verified := false
+	signed := []byte(parts[0] + "." + parts[1])
+	for _, key := range a.keys.candidates(header.Kid, header.Alg) {
+		ok, err := verifySignature(header.Alg, key, signed, sig)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("invalid token signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := a.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims validates the time, issuer and audience claims
+func (a *JWTAuthenticator) checkClaims(claims map[string]any) error {
+	now := a.now()
+	exp, hasExp, err := numericDate(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if !hasExp && a.requireExpiration {
+		return errors.New("token has no expiration")
+	}
+	if hasExp && now.After(exp.Add(a.leeway)) {
+		return errors.New("token expired")
+	}
+	nbf, hasNbf, err := numericDate(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	if hasNbf && now.Before(nbf.Add(-a.leeway)) {
+		return errors.New("token not valid yet")
+	}
+	if a.issuer != "" && claims["iss"] != a.issuer {
+		return errors.New("unexpected token issuer")
+	}
+	if a.audience != "" {
+		found := false
+		for _, aud := range stringList(claims["aud"]) {
+			if aud == a.audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("unexpected token audience")
+		}
+	}
+	return nil
+}
+
+// numericDate returns the time of a NumericDate claim, reporting whether it is present.
+// Claims of another type are an error rather than ignored.
+func numericDate(claims map[string]any, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("malformed token claim '%s'", name)
+	}
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// verifySignature checks sig over signed with key for alg. Keys that do not fit alg
+// yield false; unsupported algorithms are an error.
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) (bool, error) {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	case "EdDSA":
+		edKey, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(edKey, signed, sig), nil
+	default:
+		return false, fmt.Errorf("unsupported token algorithm '%s'", alg)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] == 'R' {
+			return rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil, nil
+		}
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(k, hash, digest, sig, nil) == nil, nil
+		}
+	case *ecdsa.PublicKey:
+		// ES signatures are the fixed-size concatenation of r and s
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[0] != 'E' || len(sig) != 2*size {
+			return false, nil
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, digest, r, s), nil
+	}
+	return false, nil
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a token
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stringList converts a claim that is a string or a list of strings to a list
+func stringList(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
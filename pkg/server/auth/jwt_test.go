@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeys holds the private keys behind the test JWKS
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	return &testKeys{rsa: rsaKey, ecdsa: ecKey, ed25519: edKey}
+}
+
+// jwks returns the JWKS document of the public keys
+func (k *testKeys) jwks() []byte {
+	b64 := base64.RawURLEncoding.EncodeToString
+	doc := map[string]any{"keys": []map[string]any{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "alg": "ES256", "x": b64(k.ecdsa.X.FillBytes(make([]byte, 32))), "y": b64(k.ecdsa.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(k.ed25519.Public().(ed25519.PublicKey))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+	data, _ := json.Marshal(doc)
+	return data
+}
+
+// sign creates a compact JWT with the given algorithm, key id and claims
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]any{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	var err error
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case "PS256":
+		sig, err = rsa.SignPSS(rand.Reader, k.rsa, crypto.SHA256, digest[:], nil)
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ecdsa, digest[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case "EdDSA":
+		sig = ed25519.Sign(k.ed25519, []byte(signed))
+	case "none":
+	}
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed + "." + b64(sig)
+}
+
+// swapClaims returns token with the claims of other
+func swapClaims(token, other string) string {
+	parts, otherParts := strings.Split(token, "."), strings.Split(other, ".")
+	return parts[0] + "." + otherParts[1] + "." + parts[2]
+}
+
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	keys := newTestKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keys.jwks(), 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+	jwks, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatalf("failed to load JWKS: %v", err)
+	}
+	now := time.Now().Unix()
+	// valid returns the claims of a valid token changed by extra; nil values remove a claim
+	valid := func(extra map[string]any) map[string]any {
+		claims := map[string]any{"sub": "ada", "iss": "https://id.example.com", "aud": "agents", "exp": now + 60}
+		for k, v := range extra {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name           string
+		opts           []JWTAuthenticatorOpts
+		token          string
+		expectedScopes []string
+		expectedErr    string
+	}{
+		{
+			name:           "rs256",
+			token:          keys.sign(t, "RS256", "rsa", valid(map[string]any{"scope": "notes:read notes:write"})),
+			expectedScopes: []string{"notes:read", "notes:write"},
+		},
+		{
+			name:           "ps256",
+			token:          keys.sign(t, "PS256", "rsa", valid(map[string]any{"scp": []any{"notes:read"}})),
+			expectedScopes: []string{"notes:read"},
+		},
+		{
+			name:  "es256",
+			token: keys.sign(t, "ES256", "ec", valid(nil)),
+		},
+		{
+			name:  "eddsa_without_kid",
+			token: keys.sign(t, "EdDSA", "", valid(map[string]any{"aud": []any{"other", "agents"}})),
+		},
+		{
+			name:        "expired",
+			token:       keys.sign(t, "RS256", "rsa", valid(map[string]any{"exp": now - 3600})),
+			expectedErr: "token expired",
+		},
+		{
+			name:  "no_expiration",
+			token: keys.sign(t, "RS256", "rsa", valid(map[string]any{"exp": nil})),
+		},
+		{
+			name:        "no_expiration_when_required",
+			opts:        []JWTAuthenticatorOpts{WithRequiredExpiration()},
+			token:       keys.sign(t, "RS256", "rsa", valid(map[string]any{"exp": nil})),
+			expectedErr: "token has no expiration",
+		},
+		{
+			name:        "malformed_expiration",
+			token:       keys.sign(t, "RS256", "rsa", valid(map[string]any{"exp": "tomorrow"})),
+			expectedErr: "malformed token claim 'exp'",
+		},
+		{
+			name:        "not_yet_valid",
+			token:       keys.sign(t, "RS256", "rsa", valid(map[string]any{"nbf": now + 3600})),
+			expectedErr: "token not valid yet",
+		},
+		{
+			name:        "wrong_issuer",
+			token:       keys.sign(t, "RS256", "rsa", valid(map[string]any{"iss": "https://evil.example.com"})),
+			expectedErr: "unexpected token issuer",
+		},
+		{
+			name:        "wrong_audience",
+			token:       keys.sign(t, "RS256", "rsa", valid(map[string]any{"aud": "billing"})),
+			expectedErr: "unexpected token audience",
+		},
+		{
+			name:        "key_restricted_to_other_algorithm",
+			token:       keys.sign(t, "RS256", "ec", valid(nil)),
+			expectedErr: "invalid token signature",
+		},
+		{
+			name:        "unsigned_token",
+			token:       keys.sign(t, "none", "", valid(nil)),
+			expectedErr: "unsupported token algorithm 'none'",
+		},
+		{
+			name:        "tampered_claims",
+			token:       swapClaims(keys.sign(t, "RS256", "rsa", valid(nil)), keys.sign(t, "RS256", "rsa", valid(map[string]any{"sub": "eve"}))),
+			expectedErr: "invalid token signature",
+		},
+		{
+			name:        "malformed",
+			token:       "not-a-jwt",
+			expectedErr: "malformed token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]JWTAuthenticatorOpts{WithIssuer("https://id.example.com"), WithAudience("agents")}, tt.opts...)
+			authenticator := NewJWTAuthenticator(jwks, opts...)
+			req := httptest.NewRequest(http.MethodPost, "/execute", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			principal, err := authenticator.Authenticate(req)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.Subject != "ada" || principal.Scheme != "jwt" || principal.Claims["iss"] != "https://id.example.com" {
+				t.Errorf("unexpected principal %+v", principal)
+			}
+			if !reflect.DeepEqual(principal.Scopes, tt.expectedScopes) {
+				t.Errorf("expected scopes %v, got %v", tt.expectedScopes, principal.Scopes)
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	tests := []struct {
+		name        string
+		jwks        string
+		expectedErr bool
+	}{
+		{
+			name:        "invalid_json",
+			jwks:        `{"keys":`,
+			expectedErr: true,
+		},
+		{
+			name:        "no_signing_keys",
+			jwks:        `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`,
+			expectedErr: true,
+		},
+		{
+			name:        "point_not_on_curve",
+			jwks:        `{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`,
+			expectedErr: true,
+		},
+		{
+			name: "rsa_key",
+			jwks: `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJWKS([]byte(tt.jwks))
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+)
+
+// Classes of call failures. Executors mark their errors with these classes, which are
+// matched with errors.Is, so that transports can report them with a fitting JSON-RPC code.
+var (
+	// ErrServiceNotFound is the class of calls to a service that is not registered
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrMethodNotFound is the class of calls to a method the service does not have
+	ErrMethodNotFound = errors.New("method not found")
+	// ErrInvalidParams is the class of calls whose params do not fit the method's request type
+	ErrInvalidParams = errors.New("invalid params")
+	// ErrToolFailed is the class of errors returned by the called method itself
+	ErrToolFailed = errors.New("tool failed")
+)
+
+// ClassifyError marks err as belonging to class, e.g. ErrMethodNotFound, without changing
+// its message. errors.Is and errors.As still find err and the errors it wraps.
+func ClassifyError(class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{class: class, err: err}
+}
+
+// classifiedError is an error marked with a class
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.err, e.class}
+}
+
+// PanicError is returned for a call whose method panicked
+type PanicError struct {
+	Value any    // The value passed to panic
+	Stack []byte // Stack of the panicking goroutine, as formatted by runtime/debug.Stack
+}
+
+// NewPanicError creates a PanicError for a recovered value with the stack of the current
+// goroutine. Call it from the deferred function that recovered.
+func NewPanicError(value any) *PanicError {
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// ErrorCode returns the JSON-RPC code a failed call is reported with.
+// JSON-RPC errors keep their code, timeouts are -32001, unknown services and methods -32601,
+// invalid params -32602 and errors returned by the method -32000. Anything else, including
+// panics, is an internal error.
+func ErrorCode(err error) jsonrpc.ErrorCode {
+	if rpcErr, ok := jsonrpc.AsError(err); ok {
+		return jsonrpc.ErrorCode(rpcErr.Code)
+	}
+
+	var panicErr *PanicError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return jsonrpc.ErrorCodeTimeout
+	case errors.Is(err, ErrServiceNotFound), errors.Is(err, ErrMethodNotFound):
+		return jsonrpc.ErrorCodeMethodNotFound
+	case errors.Is(err, ErrInvalidParams):
+		return jsonrpc.ErrorCodeInvalidParams
+	case errors.As(err, &panicErr):
+		return jsonrpc.ErrorCodeInternalError
+	case errors.Is(err, ErrToolFailed):
+		return jsonrpc.ErrorCodeServerError
+	default:
+		return jsonrpc.ErrorCodeInternalError
+	}
+}
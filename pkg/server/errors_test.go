@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected jsonrpc.ErrorCode
+	}{
+		{
+			name:     "jsonrpc_error_keeps_code",
+			err:      ClassifyError(ErrToolFailed, jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, "Forbidden", nil)),
+			expected: jsonrpc.ErrorCodeForbidden,
+		},
+		{
+			name:     "deadline",
+			err:      fmt.Errorf("method 'Sleep' did not complete: %w", context.DeadlineExceeded),
+			expected: jsonrpc.ErrorCodeTimeout,
+		},
+		{
+			name:     "service_not_found",
+			err:      ClassifyError(ErrServiceNotFound, errors.New("service 'Missing' not found")),
+			expected: jsonrpc.ErrorCodeMethodNotFound,
+		},
+		{
+			name:     "method_not_found",
+			err:      ClassifyError(ErrMethodNotFound, errors.New("method 'Missing' not found")),
+			expected: jsonrpc.ErrorCodeMethodNotFound,
+		},
+		{
+			name:     "invalid_params",
+			err:      ClassifyError(ErrInvalidParams, errors.New("name: cannot convert 42 to string")),
+			expected: jsonrpc.ErrorCodeInvalidParams,
+		},
+		{
+			name:     "invalid_params_returned_by_tool",
+			err:      ClassifyError(ErrToolFailed, fmt.Errorf("%w: name is empty", ErrInvalidParams)),
+			expected: jsonrpc.ErrorCodeInvalidParams,
+		},
+		{
+			name:     "tool_failed",
+			err:      ClassifyError(ErrToolFailed, errors.New("disk full")),
+			expected: jsonrpc.ErrorCodeServerError,
+		},
+		{
+			name:     "panic",
+			err:      fmt.Errorf("call failed: %w", NewPanicError("boom")),
+			expected: jsonrpc.ErrorCodeInternalError,
+		},
+		{
+			name:     "unclassified",
+			err:      errors.New("connection reset"),
+			expected: jsonrpc.ErrorCodeInternalError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.expected {
+				t.Errorf("expected code %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	cause := errors.New("disk full")
+	err := ClassifyError(ErrToolFailed, fmt.Errorf("write failed: %w", cause))
+
+	if err.Error() != "write failed: disk full" {
+		t.Errorf("expected the message to be kept, got %q", err.Error())
+	}
+	if !errors.Is(err, ErrToolFailed) || !errors.Is(err, cause) {
+		t.Errorf("expected err to match its class and its cause")
+	}
+	if ClassifyError(ErrToolFailed, nil) != nil {
+		t.Errorf("expected nil for a nil error")
+	}
+}
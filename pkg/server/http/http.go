@@ -3,18 +3,48 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/auth"
 )
 
+// Drainer is implemented by handlers that track in-flight calls, such as the
+// method execution handler. Shutdown waits for them after the listener is closed.
+type Drainer interface {
+	Wait(ctx context.Context) error
+}
+
+// Streamer is implemented by method handlers that can stream results, such as the
+// method execution handler. Its stream handler is served at /execute/stream.
+type Streamer interface {
+	StreamHandler() http.Handler
+}
+
 // HTTPTransport implements the [server.Transport interface
 type HTTPTransport struct {
-	readDeadline  time.Duration
-	writeDeadline time.Duration
-	basePath      string
-	toolHandler   http.Handler
-	methodHandler http.Handler
+	readDeadline   time.Duration
+	writeDeadline  time.Duration
+	basePath       string
+	toolHandler    http.Handler
+	methodHandler  http.Handler
+	jobHandler     http.Handler
+	auditHandler   http.Handler
+	metricsHandler http.Handler
+	authenticators []auth.Authenticator
+	logger         *server.Logger
+
+	// The http.Server shared by Serve and Shutdown, created on first use
+	lifecycleMutex sync.Mutex
+	httpSrv        *http.Server
+	cancelBase     context.CancelFunc
 }
 
 type HTTPTransportOpts func(*HTTPTransport)
@@ -70,24 +100,130 @@ func WithMethodHandler(handler http.Handler) HTTPTransportOpts {
 	}
 }
 
+// WithJobHandler sets the handler of the asynchronous job endpoints, served at /jobs
+// and /jobs/{id}. See (*jobs.Manager).Handler.
+func WithJobHandler(handler http.Handler) HTTPTransportOpts {
+	return func(t *HTTPTransport) {
+		t.jobHandler = handler
+	}
+}
+
+// WithAuditHandler sets the handler of the read-only audit log endpoint, served at /audit.
+// See (*audit.Auditor).Handler.
+func WithAuditHandler(handler http.Handler) HTTPTransportOpts {
+	return func(t *HTTPTransport) {
+		t.auditHandler = handler
+	}
+}
+
+// WithMetricsHandler sets the handler of the metrics endpoint, served at /metrics.
+// See (*metrics.Metrics).Handler.
+func WithMetricsHandler(handler http.Handler) HTTPTransportOpts {
+	return func(t *HTTPTransport) {
+		t.metricsHandler = handler
+	}
+}
+
+// WithAuthentication requires every request to be authenticated by one of authenticators,
+// tried in order. The principal of the first one that accepts the request is stored in the
+// request context, where server.PrincipalFromContext finds it. Requests that no authenticator
+// accepts are rejected with 401 Unauthorized.
+//
+// E.g., to accept both static tokens and JWTs:
+//
+//	keys, err := auth.LoadJWKS("/etc/agent/jwks.json")
+//	...
+//	http.WithAuthentication(
+//	    auth.NewBearerAuthenticator(map[string]server.Principal{"s3cr3t": {Subject: "ci"}}),
+//	    auth.NewJWTAuthenticator(keys, auth.WithIssuer("https://id.example.com")),
+//	)
+func WithAuthentication(authenticators ...auth.Authenticator) HTTPTransportOpts {
+	return func(t *HTTPTransport) {
+		t.authenticators = append(t.authenticators, authenticators...)
+	}
+}
+
+// WithLogger logs every request with its method, path, status and duration, and rejected
+// authentication attempts. See (*HTTPTransport).SetLogger.
+func WithLogger(logger *slog.Logger, opts ...server.LoggerOpts) HTTPTransportOpts {
+	return func(t *HTTPTransport) {
+		t.logger = server.NewLogger(logger, opts...)
+	}
+}
+
+// SetLogger sets the logger of the transport. It is called by server.Server for its
+// transport and must be called before the transport starts serving.
+func (s *HTTPTransport) SetLogger(logger *server.Logger) {
+	s.logger = logger
+}
+
 // ListenAndServe starts the HTTP transport and listens for incoming requests
 // the addr is the address to listen on
 // E.g., if the addr is ":8080", the HTTP transport will listen on port 8080
 func (s *HTTPTransport) ListenAndServe(addr string) error {
-	httpSrv := &http.Server{
-		Addr:         addr,
-		Handler:      s.HTTPHandler(),
-		ReadTimeout:  s.readDeadline,
-		WriteTimeout: s.writeDeadline,
+	if addr == "" {
+		addr = ":http"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
 	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Shutdown is called.
+// After Shutdown it returns http.ErrServerClosed.
+func (s *HTTPTransport) Serve(listener net.Listener) error {
+	httpSrv, _ := s.server()
+	return httpSrv.Serve(listener)
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests, including
+// calls tracked by a Drainer method handler, until ctx is done. Requests still running
+// when ctx is done have their context cancelled and ctx's error is returned.
+func (s *HTTPTransport) Shutdown(ctx context.Context) error {
+	httpSrv, cancelBase := s.server()
+	defer cancelBase()
 
-	return httpSrv.ListenAndServe()
+	if err := httpSrv.Shutdown(ctx); err != nil {
+		return err
+	}
+	if drainer, ok := s.methodHandler.(Drainer); ok {
+		return drainer.Wait(ctx)
+	}
+	return nil
+}
+
+// server returns the underlying http.Server, creating it on first use, and the
+// function that cancels the context of its requests
+func (s *HTTPTransport) server() (*http.Server, context.CancelFunc) {
+	s.lifecycleMutex.Lock()
+	defer s.lifecycleMutex.Unlock()
+
+	if s.httpSrv == nil {
+		baseCtx, cancel := context.WithCancel(context.Background())
+		s.cancelBase = cancel
+		s.httpSrv = &http.Server{
+			Handler:      s.HTTPHandler(),
+			ReadTimeout:  s.readDeadline,
+			WriteTimeout: s.writeDeadline,
+			BaseContext:  func(net.Listener) context.Context { return baseCtx },
+		}
+	}
+	return s.httpSrv, s.cancelBase
 }
 
 // HTTPHandler returns the HTTP handler for the HTTP transport
 // the handler is a mux that handles the base path and agent-related endpoints
 // the base path is the path that the HTTP transport will be mounted at
+// Every request is assigned an ID, taken from the X-Request-ID header if the client sent
+// one, which is echoed in the response and attached to the request's log events.
 func (s *HTTPTransport) HTTPHandler() http.Handler {
+	return withRequestID(s.authenticate(s.logRequests(s.routes())))
+}
+
+// routes returns the mux of the base path and agent-related endpoints
+func (s *HTTPTransport) routes() http.Handler {
 	// Create subroutes with common handlers
 	subroutes := s.createSubroutes()
 
@@ -111,6 +247,47 @@ func (s *HTTPTransport) HTTPHandler() http.Handler {
 	return baseMux
 }
 
+// authenticate wraps next so that only requests accepted by one of the
+// authenticators reach it. Without authenticators, next is returned unchanged.
+func (s *HTTPTransport) authenticate(next http.Handler) http.Handler {
+	if len(s.authenticators) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rejection error
+		for _, authenticator := range s.authenticators {
+			principal, err := authenticator.Authenticate(r)
+			if err == nil {
+				next.ServeHTTP(w, r.WithContext(server.ContextWithPrincipal(r.Context(), principal)))
+				return
+			}
+			if rejection == nil && !errors.Is(err, auth.ErrNoCredentials) {
+				rejection = err
+			}
+		}
+
+		challenged := make(map[string]bool)
+		for _, authenticator := range s.authenticators {
+			if challenger, ok := authenticator.(auth.Challenger); ok && !challenged[challenger.Challenge()] {
+				challenged[challenger.Challenge()] = true
+				w.Header().Add("WWW-Authenticate", challenger.Challenge())
+			}
+		}
+		if rejection == nil {
+			rejection = errors.New("authentication required")
+		}
+		// Clients only learn that they were rejected; the reason is for the operator's log
+		s.logger.Warn(r.Context(), "authentication failed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("reason", rejection.Error()),
+		)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+}
+
 // createSubroutes creates the subroutes with common handlers
 func (s *HTTPTransport) createSubroutes() http.Handler {
 	subroutes := http.NewServeMux()
@@ -135,6 +312,25 @@ func (s *HTTPTransport) createSubroutes() http.Handler {
 	// Method execution handler
 	if s.methodHandler != nil {
 		subroutes.Handle("/execute", s.methodHandler)
+		if streamer, ok := s.methodHandler.(Streamer); ok {
+			subroutes.Handle("/execute/stream", streamer.StreamHandler())
+		}
+	}
+
+	// Asynchronous job handler
+	if s.jobHandler != nil {
+		subroutes.Handle("/jobs", s.jobHandler)
+		subroutes.Handle("/jobs/", s.jobHandler)
+	}
+
+	// Audit log handler
+	if s.auditHandler != nil {
+		subroutes.Handle("/audit", s.auditHandler)
+	}
+
+	// Metrics handler
+	if s.metricsHandler != nil {
+		subroutes.Handle("/metrics", s.metricsHandler)
 	}
 
 	return subroutes
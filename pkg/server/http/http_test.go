@@ -2,12 +2,17 @@ package http
 
 import (
 	"context"
+	"errors"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/auth"
 )
 
 // TestNewHTTPTransport tests the creation of a new HTTP transport
@@ -15,12 +20,12 @@ func TestNewHTTPTransport(t *testing.T) {
 	tests := []struct {
 		name     string
 		opts     []HTTPTransportOpts
-		expected HTTPTransport
+		expected *HTTPTransport
 	}{
 		{
 			name: "default transport",
 			opts: []HTTPTransportOpts{},
-			expected: HTTPTransport{
+			expected: &HTTPTransport{
 				readDeadline:  0,
 				writeDeadline: 0,
 				basePath:      "",
@@ -33,7 +38,7 @@ func TestNewHTTPTransport(t *testing.T) {
 			opts: []HTTPTransportOpts{
 				WithReadDeadline(5 * time.Second),
 			},
-			expected: HTTPTransport{
+			expected: &HTTPTransport{
 				readDeadline:  5 * time.Second,
 				writeDeadline: 0,
 				basePath:      "",
@@ -46,7 +51,7 @@ func TestNewHTTPTransport(t *testing.T) {
 			opts: []HTTPTransportOpts{
 				WithWriteDeadline(10 * time.Second),
 			},
-			expected: HTTPTransport{
+			expected: &HTTPTransport{
 				readDeadline:  0,
 				writeDeadline: 10 * time.Second,
 				basePath:      "",
@@ -59,7 +64,7 @@ func TestNewHTTPTransport(t *testing.T) {
 			opts: []HTTPTransportOpts{
 				WithPath("/api/v1"),
 			},
-			expected: HTTPTransport{
+			expected: &HTTPTransport{
 				readDeadline:  0,
 				writeDeadline: 0,
 				basePath:      "/api/v1",
@@ -74,7 +79,7 @@ func TestNewHTTPTransport(t *testing.T) {
 				WithWriteDeadline(10 * time.Second),
 				WithPath("/api/v1"),
 			},
-			expected: HTTPTransport{
+			expected: &HTTPTransport{
 				readDeadline:  5 * time.Second,
 				writeDeadline: 10 * time.Second,
 				basePath:      "/api/v1",
@@ -694,3 +699,321 @@ func TestHTTPHandlerConcurrentRequests(t *testing.T) {
 		<-done
 	}
 }
+
+// drainingHandler is a method handler that blocks until released or cancelled
+type drainingHandler struct {
+	started  chan struct{}
+	release  chan struct{}
+	finished chan error
+}
+
+func newDrainingHandler() *drainingHandler {
+	return &drainingHandler{
+		started:  make(chan struct{}, 1),
+		release:  make(chan struct{}),
+		finished: make(chan error, 1),
+	}
+}
+
+func (d *drainingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.started <- struct{}{}
+	select {
+	case <-d.release:
+		d.finished <- nil
+		w.WriteHeader(http.StatusOK)
+	case <-r.Context().Done():
+		d.finished <- r.Context().Err()
+	}
+}
+
+// TestServeAndShutdown tests that Shutdown drains in-flight requests before Serve returns
+func TestServeAndShutdown(t *testing.T) {
+	tests := []struct {
+		name            string
+		release         bool
+		shutdownTimeout time.Duration
+		expectedErr     error
+		expectedHandler error
+	}{
+		{
+			name:            "in_flight_request_completes",
+			release:         true,
+			shutdownTimeout: 2 * time.Second,
+		},
+		{
+			name:            "deadline_cancels_in_flight_request",
+			shutdownTimeout: 50 * time.Millisecond,
+			expectedErr:     context.DeadlineExceeded,
+			expectedHandler: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newDrainingHandler()
+			transport := NewHTTPTransport(WithMethodHandler(handler))
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+
+			served := make(chan error, 1)
+			go func() { served <- transport.Serve(listener) }()
+
+			go http.Post("http://"+listener.Addr().String()+"/execute", "application/json", strings.NewReader("{}"))
+			<-handler.started
+
+			if tt.release {
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					close(handler.release)
+				}()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.shutdownTimeout)
+			defer cancel()
+			if err := transport.Shutdown(ctx); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Shutdown error = %v, want %v", err, tt.expectedErr)
+			}
+
+			if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+				t.Errorf("Serve error = %v, want %v", err, http.ErrServerClosed)
+			}
+			if err := <-handler.finished; !errors.Is(err, tt.expectedHandler) {
+				t.Errorf("handler finished with %v, want %v", err, tt.expectedHandler)
+			}
+		})
+	}
+}
+
+// TestShutdownBeforeServe tests that a transport shut down before serving refuses to serve
+func TestShutdownBeforeServe(t *testing.T) {
+	transport := NewHTTPTransport()
+	if err := transport.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if err := transport.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Serve error = %v, want %v", err, http.ErrServerClosed)
+	}
+}
+
+// TestShutdownIsPerTransport tests that shutting down one transport leaves another serving
+func TestShutdownIsPerTransport(t *testing.T) {
+	stopped := NewHTTPTransport()
+	if err := stopped.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown error = %v", err)
+	}
+
+	running := NewHTTPTransport(WithToolHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go running.Serve(listener)
+	defer running.Shutdown(context.Background())
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/tools")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+// TestWithAuthentication tests that requests must be accepted by an authenticator
+func TestWithAuthentication(t *testing.T) {
+	secret := []byte("shared-secret")
+	authenticators := []auth.Authenticator{
+		auth.NewBearerAuthenticator(map[string]server.Principal{"s3cr3t": {Subject: "ci"}}),
+		auth.NewHMACAuthenticator(map[string][]byte{"worker": secret}),
+	}
+
+	tests := []struct {
+		name              string
+		sign              func(r *http.Request)
+		expectedStatus    int
+		expectedSubject   string
+		expectedBody      string
+		expectedChallenge []string
+	}{
+		{
+			name:            "bearer_token",
+			sign:            func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t") },
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "ci",
+		},
+		{
+			name: "hmac_signature_over_full_path",
+			sign: func(r *http.Request) {
+				if err := auth.SignRequest(r, "worker", secret); err != nil {
+					t.Fatalf("failed to sign request: %v", err)
+				}
+			},
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "worker",
+		},
+		{
+			name:              "invalid_token",
+			sign:              func(r *http.Request) { r.Header.Set("Authorization", "Bearer guess") },
+			expectedStatus:    http.StatusUnauthorized,
+			expectedBody:      "unauthorized",
+			expectedChallenge: []string{"Bearer", "HMAC-SHA256"},
+		},
+		{
+			name:              "no_credentials",
+			sign:              func(r *http.Request) {},
+			expectedStatus:    http.StatusUnauthorized,
+			expectedBody:      "unauthorized",
+			expectedChallenge: []string{"Bearer", "HMAC-SHA256"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			methodHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if principal, ok := server.PrincipalFromContext(r.Context()); ok {
+					subject = principal.Subject
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			transport := NewHTTPTransport(
+				WithPath("/agents/api/v1/"),
+				WithMethodHandler(methodHandler),
+				WithAuthentication(authenticators...),
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/agents/api/v1/execute", strings.NewReader(`{}`))
+			tt.sign(req)
+			w := httptest.NewRecorder()
+
+			transport.HTTPHandler().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if subject != tt.expectedSubject {
+				t.Errorf("expected subject %q, got %q", tt.expectedSubject, subject)
+			}
+			if tt.expectedBody != "" && strings.TrimSpace(w.Body.String()) != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if challenge := w.Header().Values("WWW-Authenticate"); !reflect.DeepEqual(challenge, tt.expectedChallenge) {
+				t.Errorf("expected challenge %v, got %v", tt.expectedChallenge, challenge)
+			}
+		})
+	}
+}
+
+// streamingHandler is a method handler that also serves a stream handler
+type streamingHandler struct {
+	http.Handler
+}
+
+func (s streamingHandler) StreamHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("stream response")) })
+}
+
+// TestHTTPHandlerStreamer tests that /execute/stream is only served by method handlers that stream
+func TestHTTPHandlerStreamer(t *testing.T) {
+	executeHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("execute response")) })
+
+	tests := []struct {
+		name           string
+		methodHandler  http.Handler
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "streamer",
+			methodHandler:  streamingHandler{executeHandler},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "stream response",
+		},
+		{
+			name:           "plain handler",
+			methodHandler:  executeHandler,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Not Found!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &HTTPTransport{basePath: "/api", methodHandler: tt.methodHandler}
+			handler := transport.HTTPHandler()
+
+			req := httptest.NewRequest("POST", "/api/execute/stream", nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if body := strings.TrimSpace(w.Body.String()); body != tt.expectedBody {
+				t.Errorf("body = %q, want %q", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+// TestHTTPHandlerJobs tests that the job handler serves /jobs and the paths below it
+func TestHTTPHandlerJobs(t *testing.T) {
+	jobHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("jobs " + r.URL.Path)) })
+	transport := &HTTPTransport{basePath: "/api", jobHandler: jobHandler}
+	handler := transport.HTTPHandler()
+
+	for _, path := range []string{"/jobs", "/jobs/abc123"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api"+path, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if body := w.Body.String(); body != "jobs "+path {
+				t.Errorf("body = %q, want %q", body, "jobs "+path)
+			}
+		})
+	}
+}
+
+// TestHTTPHandlerAudit tests that the audit handler is served at /audit
+func TestHTTPHandlerAudit(t *testing.T) {
+	auditHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("audit")) })
+	transport := &HTTPTransport{basePath: "/api", auditHandler: auditHandler}
+
+	req := httptest.NewRequest("GET", "/api/audit?tool=NoteService", nil)
+	w := httptest.NewRecorder()
+	transport.HTTPHandler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "audit" {
+		t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, "audit")
+	}
+}
+
+func TestHTTPHandlerMetrics(t *testing.T) {
+	metricsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("metrics")) })
+	transport := &HTTPTransport{basePath: "/api", metricsHandler: metricsHandler}
+
+	req := httptest.NewRequest("GET", "/api/metrics", nil)
+	w := httptest.NewRecorder()
+	transport.HTTPHandler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "metrics" {
+		t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, "metrics")
+	}
+}
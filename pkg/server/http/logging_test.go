@@ -0,0 +1,122 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/auth"
+)
+
+func TestHTTPHandlerRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		expectNew bool
+	}{
+		{name: "client_id_is_kept", requestID: "trace-42"},
+		{name: "missing_id_is_generated", requestID: "", expectNew: true},
+		{name: "invalid_id_is_replaced", requestID: "has spaces", expectNew: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := ""
+			toolHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = server.RequestIDFromContext(r.Context())
+			})
+			transport := NewHTTPTransport(WithToolHandler(toolHandler))
+
+			req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			transport.HTTPHandler().ServeHTTP(w, req)
+
+			echoed := w.Header().Get(RequestIDHeader)
+			if echoed != seen {
+				t.Errorf("expected the response to echo %q, got %q", seen, echoed)
+			}
+			if tt.expectNew {
+				if len(seen) != 32 || seen == tt.requestID {
+					t.Errorf("expected a generated request ID, got %q", seen)
+				}
+			} else if seen != tt.requestID {
+				t.Errorf("expected request ID %q, got %q", tt.requestID, seen)
+			}
+		})
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected map[string]any
+	}{
+		{
+			name:   "request_is_logged",
+			header: http.Header{"Authorization": {"Bearer s3cr3t"}, "X-Request-Id": {"req-1"}},
+			expected: map[string]any{
+				"level":       "INFO",
+				"msg":         "http request",
+				"method":      "GET",
+				"path":        "/tools",
+				"status":      float64(http.StatusTeapot),
+				"bytes":       float64(len("tools")),
+				"remote_addr": "192.0.2.1:1234",
+				"request_id":  "req-1",
+				"subject":     "ci",
+			},
+		},
+		{
+			name:   "rejected_authentication_is_logged",
+			header: http.Header{"Authorization": {"Bearer wrong"}, "X-Request-Id": {"req-2"}},
+			expected: map[string]any{
+				"level":       "WARN",
+				"msg":         "authentication failed",
+				"method":      "GET",
+				"path":        "/tools",
+				"remote_addr": "192.0.2.1:1234",
+				"reason":      "invalid bearer token",
+				"request_id":  "req-2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+			toolHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+				w.Write([]byte("tools"))
+			})
+			transport := NewHTTPTransport(
+				WithToolHandler(toolHandler),
+				WithAuthentication(auth.NewBearerAuthenticator(map[string]server.Principal{"s3cr3t": {Subject: "ci"}})),
+				WithLogger(logger),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+			req.Header = tt.header
+			transport.HTTPHandler().ServeHTTP(httptest.NewRecorder(), req)
+
+			var event map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+				t.Fatalf("expected one log line, got %q", buf.String())
+			}
+			delete(event, "time")
+			delete(event, "duration_ms")
+			if !reflect.DeepEqual(event, tt.expected) {
+				t.Errorf("expected event %v, got %v", tt.expected, event)
+			}
+		})
+	}
+}
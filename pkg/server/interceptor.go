@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// Transports reported in Caller.Transport
+const (
+	TransportHTTP   = "http"
+	TransportTCP    = "tcp"
+	TransportMCP    = "mcp"
+	TransportDirect = "direct"
+)
+
+// Caller identifies where a call came from
+type Caller struct {
+	Transport  string     // One of the Transport constants
+	RemoteAddr string     // Network address of the client, if known
+	Principal  *Principal // Authenticated identity, nil for unauthenticated calls
+}
+
+// ToolCall describes a single method invocation passing through the interceptor chain.
+// Interceptors may modify Params before calling the next invoker.
+type ToolCall struct {
+	ServiceName string
+	MethodName  string
+	Params      map[string]interface{}
+	Caller      Caller
+}
+
+// Invoker executes a call, either by running the next interceptor or the method itself
+type Invoker func(ctx context.Context, call *ToolCall) (any, error)
+
+// Interceptor runs around a call. It may inspect or modify the call, short-circuit it by
+// returning without calling next, or inspect the result and error that next returns.
+//
+//	func logCalls(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+//	    start := time.Now()
+//	    result, err := next(ctx, call)
+//	    log.Printf("%s.%s took %v: %v", call.ServiceName, call.MethodName, time.Since(start), err)
+//	    return result, err
+//	}
+type Interceptor func(ctx context.Context, call *ToolCall, next Invoker) (any, error)
+
+// Interceptors holds the interceptor chain shared by all transports of a server.
+// Global interceptors run first, then those of the call's service, then those of its method,
+// each in registration order. It is safe for concurrent use; a nil *Interceptors runs no interceptors.
+type Interceptors struct {
+	mutex    sync.RWMutex
+	global   []Interceptor
+	services map[string][]Interceptor // Key: service name
+	methods  map[string][]Interceptor // Key: "ServiceName.MethodName"
+}
+
+// NewInterceptors creates an empty interceptor chain
+func NewInterceptors() *Interceptors {
+	return &Interceptors{
+		services: make(map[string][]Interceptor),
+		methods:  make(map[string][]Interceptor),
+	}
+}
+
+// Use adds interceptors that run for every call
+func (i *Interceptors) Use(interceptors ...Interceptor) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.global = append(i.global, interceptors...)
+}
+
+// UseFirst adds interceptors that run for every call, before all interceptors added so far.
+// It is meant for interceptors that must observe every call, such as an audit log, when
+// others like an authorization check have already been installed.
+func (i *Interceptors) UseFirst(interceptors ...Interceptor) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.global = append(append([]Interceptor(nil), interceptors...), i.global...)
+}
+
+// UseService adds interceptors that run for every method of a service
+func (i *Interceptors) UseService(serviceName string, interceptors ...Interceptor) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.services[serviceName] = append(i.services[serviceName], interceptors...)
+}
+
+// UseMethod adds interceptors that run for a single method
+func (i *Interceptors) UseMethod(serviceName, methodName string, interceptors ...Interceptor) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	key := serviceName + "." + methodName
+	i.methods[key] = append(i.methods[key], interceptors...)
+}
+
+// Invoke runs call through the interceptors that apply to it, ending with invoker
+func (i *Interceptors) Invoke(ctx context.Context, call *ToolCall, invoker Invoker) (any, error) {
+	chain := i.chainFor(call.ServiceName, call.MethodName)
+
+	next := invoker
+	for j := len(chain) - 1; j >= 0; j-- {
+		interceptor, inner := chain[j], next
+		next = func(ctx context.Context, call *ToolCall) (any, error) {
+			return interceptor(ctx, call, inner)
+		}
+	}
+	return next(ctx, call)
+}
+
+// Execute runs call through the interceptors and then executes it on executor
+func (i *Interceptors) Execute(ctx context.Context, executor MethodExecutor, call *ToolCall) (any, error) {
+	return i.Invoke(ctx, call, func(ctx context.Context, call *ToolCall) (any, error) {
+		return Execute(ctx, executor, call.ServiceName, call.MethodName, call.Params)
+	})
+}
+
+// chainFor returns the interceptors that apply to a method, outermost first
+func (i *Interceptors) chainFor(serviceName, methodName string) []Interceptor {
+	if i == nil {
+		return nil
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	services := i.services[serviceName]
+	methods := i.methods[serviceName+"."+methodName]
+
+	chain := make([]Interceptor, 0, len(i.global)+len(services)+len(methods))
+	chain = append(chain, i.global...)
+	chain = append(chain, services...)
+	return append(chain, methods...)
+}
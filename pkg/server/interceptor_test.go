@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// paramsExecutor returns the params it is called with
+type paramsExecutor struct{}
+
+func (paramsExecutor) ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	return params, nil
+}
+
+// tracing returns an interceptor that appends its name to log before and after the call
+func tracing(name string, log *[]string) Interceptor {
+	return func(ctx context.Context, call *ToolCall, next Invoker) (any, error) {
+		*log = append(*log, name)
+		result, err := next(ctx, call)
+		*log = append(*log, "/"+name)
+		return result, err
+	}
+}
+
+func TestInterceptors_Invoke(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceName string
+		methodName  string
+		expectedLog []string
+	}{
+		{
+			name:        "global_service_and_method_interceptors_nest",
+			serviceName: "NoteService",
+			methodName:  "Add",
+			expectedLog: []string{"global 1", "global 2", "service", "method", "call", "/method", "/service", "/global 2", "/global 1"},
+		},
+		{
+			name:        "method_interceptors_only_apply_to_their_method",
+			serviceName: "NoteService",
+			methodName:  "List",
+			expectedLog: []string{"global 1", "global 2", "service", "call", "/service", "/global 2", "/global 1"},
+		},
+		{
+			name:        "other_services_only_run_global_interceptors",
+			serviceName: "HelloService",
+			methodName:  "Add",
+			expectedLog: []string{"global 1", "global 2", "call", "/global 2", "/global 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			interceptors := NewInterceptors()
+			interceptors.UseMethod("NoteService", "Add", tracing("method", &log))
+			interceptors.UseService("NoteService", tracing("service", &log))
+			interceptors.Use(tracing("global 1", &log), tracing("global 2", &log))
+
+			call := &ToolCall{ServiceName: tt.serviceName, MethodName: tt.methodName}
+			_, err := interceptors.Invoke(context.Background(), call, func(ctx context.Context, call *ToolCall) (any, error) {
+				log = append(log, "call")
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(log, tt.expectedLog) {
+				t.Errorf("expected %v, got %v", tt.expectedLog, log)
+			}
+		})
+	}
+}
+
+func TestInterceptors_UseFirst(t *testing.T) {
+	tests := []struct {
+		name        string
+		install     func(interceptors *Interceptors, log *[]string)
+		expectedLog []string
+	}{
+		{
+			name: "runs_before_interceptors_added_earlier",
+			install: func(interceptors *Interceptors, log *[]string) {
+				interceptors.Use(tracing("global", log))
+				interceptors.UseFirst(tracing("first", log))
+			},
+			expectedLog: []string{"first", "global", "call", "/global", "/first"},
+		},
+		{
+			name: "keeps_the_order_of_its_interceptors",
+			install: func(interceptors *Interceptors, log *[]string) {
+				interceptors.Use(tracing("global", log))
+				interceptors.UseFirst(tracing("first 1", log), tracing("first 2", log))
+			},
+			expectedLog: []string{"first 1", "first 2", "global", "call", "/global", "/first 2", "/first 1"},
+		},
+		{
+			name: "interceptors_added_later_run_after_it",
+			install: func(interceptors *Interceptors, log *[]string) {
+				interceptors.UseFirst(tracing("first", log))
+				interceptors.Use(tracing("global", log))
+			},
+			expectedLog: []string{"first", "global", "call", "/global", "/first"},
+		},
+		{
+			name: "runs_before_service_and_method_interceptors",
+			install: func(interceptors *Interceptors, log *[]string) {
+				interceptors.UseMethod("NoteService", "Add", tracing("method", log))
+				interceptors.UseService("NoteService", tracing("service", log))
+				interceptors.UseFirst(tracing("first", log))
+			},
+			expectedLog: []string{"first", "service", "method", "call", "/method", "/service", "/first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			interceptors := NewInterceptors()
+			tt.install(interceptors, &log)
+
+			call := &ToolCall{ServiceName: "NoteService", MethodName: "Add"}
+			_, err := interceptors.Invoke(context.Background(), call, func(ctx context.Context, call *ToolCall) (any, error) {
+				log = append(log, "call")
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(log, tt.expectedLog) {
+				t.Errorf("expected %v, got %v", tt.expectedLog, log)
+			}
+		})
+	}
+}
+
+func TestInterceptors_ShortCircuit(t *testing.T) {
+	errDenied := errors.New("denied")
+	interceptors := NewInterceptors()
+	interceptors.Use(func(ctx context.Context, call *ToolCall, next Invoker) (any, error) {
+		return nil, errDenied
+	})
+
+	called := false
+	_, err := interceptors.Invoke(context.Background(), &ToolCall{}, func(ctx context.Context, call *ToolCall) (any, error) {
+		called = true
+		return nil, nil
+	})
+	if !errors.Is(err, errDenied) {
+		t.Errorf("expected errDenied, got %v", err)
+	}
+	if called {
+		t.Error("expected the invoker not to be called")
+	}
+}
+
+func TestInterceptors_NilRunsInvoker(t *testing.T) {
+	var interceptors *Interceptors
+	result, err := interceptors.Execute(context.Background(), paramsExecutor{}, &ToolCall{Params: map[string]interface{}{"a": 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, map[string]interface{}{"a": 1}) {
+		t.Errorf("unexpected result %v", result)
+	}
+}
+
+func TestServer_ExecuteMethodInterceptors(t *testing.T) {
+	s := NewServer(WithMethodExecutor(paramsExecutor{}))
+
+	var caller Caller
+	s.UseService("NoteService", func(ctx context.Context, call *ToolCall, next Invoker) (any, error) {
+		caller = call.Caller
+		call.Params["tenant"] = "acme"
+		result, err := next(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"wrapped": result}, nil
+	})
+
+	result, err := s.ExecuteMethod("NoteService", "Add", map[string]interface{}{"text": "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]interface{}{"wrapped": map[string]interface{}{"text": "hi", "tenant": "acme"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+	if caller.Transport != TransportDirect {
+		t.Errorf("expected transport %q, got %q", TransportDirect, caller.Transport)
+	}
+}
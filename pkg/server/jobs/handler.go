@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// maxListLimit bounds the number of jobs returned by one list request
+const maxListLimit = 100
+
+// Handler returns the HTTP handler of the job endpoints. Jobs belong to the principal that
+// submitted them, see server.PrincipalFromContext; unauthenticated callers share the jobs
+// submitted without a principal.
+//
+//	GET    /jobs?status=running&limit=20   lists jobs, newest first
+//	GET    /jobs/{id}                      returns a job with its result or error
+//	DELETE /jobs/{id}                      cancels a job
+func (m *Manager) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /jobs", m.handleList)
+	mux.HandleFunc("GET /jobs/{id}", m.handleGet)
+	mux.HandleFunc("DELETE /jobs/{id}", m.handleCancel)
+	return mux
+}
+
+// handleList lists the caller's jobs
+func (m *Manager) handleList(w http.ResponseWriter, r *http.Request) {
+	filter := Filter{Status: Status(r.URL.Query().Get("status")), Limit: maxListLimit}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(n, maxListLimit)
+	}
+
+	jobs, err := m.List(r.Context(), owner(r), filter)
+	if err != nil {
+		http.Error(w, "failed to list jobs", http.StatusInternalServerError)
+		return
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+	writeJSON(w, map[string]interface{}{"jobs": jobs})
+}
+
+// handleGet returns one of the caller's jobs
+func (m *Manager) handleGet(w http.ResponseWriter, r *http.Request) {
+	job, err := m.Get(r.Context(), owner(r), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, job)
+}
+
+// handleCancel cancels one of the caller's jobs
+func (m *Manager) handleCancel(w http.ResponseWriter, r *http.Request) {
+	job, err := m.Cancel(r.Context(), owner(r), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, job)
+}
+
+// owner returns the job owner of a request: the subject of its principal, if any
+func owner(r *http.Request) string {
+	if principal, ok := server.PrincipalFromContext(r.Context()); ok {
+		return principal.Subject
+	}
+	return ""
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError maps job errors to HTTP status codes
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrJobFinished):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+func TestManager_Handler(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	store.Create(ctx, &Job{ID: "done", Owner: "ada", Method: "Notes.Add", Status: StatusSucceeded})
+	store.Create(ctx, &Job{ID: "other", Owner: "bob", Method: "Notes.Add", Status: StatusRunning})
+	store.Create(ctx, &Job{ID: "orphan", Owner: "ada", Method: "Build.Run", Status: StatusRunning})
+	handler := NewManager(store).Handler()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		principal      *server.Principal
+		expectedStatus int
+		expectedJobs   []string
+		expectedJob    string
+	}{
+		{
+			name:           "list_own_jobs",
+			method:         http.MethodGet,
+			path:           "/jobs",
+			principal:      &server.Principal{Subject: "ada"},
+			expectedStatus: http.StatusOK,
+			expectedJobs:   []string{"orphan", "done"},
+		},
+		{
+			name:           "list_by_status",
+			method:         http.MethodGet,
+			path:           "/jobs?status=succeeded",
+			principal:      &server.Principal{Subject: "ada"},
+			expectedStatus: http.StatusOK,
+			expectedJobs:   []string{"done"},
+		},
+		{
+			name:           "list_without_jobs",
+			method:         http.MethodGet,
+			path:           "/jobs",
+			expectedStatus: http.StatusOK,
+			expectedJobs:   []string{},
+		},
+		{
+			name:           "invalid_limit",
+			method:         http.MethodGet,
+			path:           "/jobs?limit=zero",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "get_job",
+			method:         http.MethodGet,
+			path:           "/jobs/done",
+			principal:      &server.Principal{Subject: "ada"},
+			expectedStatus: http.StatusOK,
+			expectedJob:    "done",
+		},
+		{
+			name:           "get_job_of_other_owner",
+			method:         http.MethodGet,
+			path:           "/jobs/other",
+			principal:      &server.Principal{Subject: "ada"},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "cancel_job",
+			method:         http.MethodDelete,
+			path:           "/jobs/orphan",
+			principal:      &server.Principal{Subject: "ada"},
+			expectedStatus: http.StatusOK,
+			expectedJob:    "orphan",
+		},
+		{
+			name:           "cancel_finished_job",
+			method:         http.MethodDelete,
+			path:           "/jobs/done",
+			principal:      &server.Principal{Subject: "ada"},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "method_not_allowed",
+			method:         http.MethodPost,
+			path:           "/jobs",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(server.ContextWithPrincipal(req.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedJobs != nil {
+				var response struct {
+					Jobs []Job `json:"jobs"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				ids := []string{}
+				for _, job := range response.Jobs {
+					ids = append(ids, job.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.expectedJobs) {
+					t.Errorf("expected jobs %v, got %v", tt.expectedJobs, ids)
+				}
+			}
+			if tt.expectedJob != "" {
+				var job Job
+				if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if job.ID != tt.expectedJob {
+					t.Errorf("expected job %q, got %q", tt.expectedJob, job.ID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Package jobs runs tool calls in the background. A Manager executes submitted calls,
+// records their status and result in a Store, and lets their owner poll or cancel them.
+// Stores ship for memory (NewMemoryStore) and SQLite (package sqlitestore).
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+)
+
+// Status is the state of a job
+type Status string
+
+// Job states. Pending jobs wait for a free slot, see WithMaxRunning; succeeded, failed
+// and cancelled jobs are finished.
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Finished reports whether a job in status s has stopped for good
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// ErrJobNotFound is returned for jobs that do not exist or belong to another owner
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobFinished is returned when cancelling a job that has already finished
+var ErrJobFinished = errors.New("job already finished")
+
+// Job is a tool call executed in the background
+type Job struct {
+	ID         string                 `json:"id"`
+	Owner      string                 `json:"owner,omitempty"` // Subject of the principal that submitted the job
+	Method     string                 `json:"method"`          // "ServiceName.MethodName"
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Status     Status                 `json:"status"`
+	Result     json.RawMessage        `json:"result,omitempty"`
+	Error      *jsonrpc.Error         `json:"error,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	StartedAt  time.Time              `json:"startedAt,omitzero"`
+	FinishedAt time.Time              `json:"finishedAt,omitzero"`
+}
+
+// Filter selects the jobs returned by Store.List
+type Filter struct {
+	Owner  string // Only jobs of this owner; "" selects the jobs of unauthenticated callers
+	Status Status // Only jobs in this status, if set
+	Limit  int    // At most this many jobs, if positive
+}
+
+// Store persists jobs. Implementations must be safe for concurrent use.
+type Store interface {
+	// Create adds a new job
+	Create(ctx context.Context, job *Job) error
+	// Update replaces a stored job, returning ErrJobNotFound if it does not exist
+	Update(ctx context.Context, job *Job) error
+	// Get returns a job by ID, or ErrJobNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+	// List returns the jobs matching filter, newest first
+	List(ctx context.Context, filter Filter) ([]*Job, error)
+	// DeleteFinished removes jobs that finished before the given time and returns how many
+	DeleteFinished(ctx context.Context, before time.Time) (int, error)
+}
+
+// UnfinishedFailer is implemented by stores that keep jobs across restarts. NewManager calls
+// FailUnfinished so that jobs left pending or running by an earlier process, which no
+// manager will finish, are recorded as failed instead of staying unfinished forever.
+type UnfinishedFailer interface {
+	// FailUnfinished records every pending or running job as failed with jobErr, finished
+	// at the given time, and returns how many
+	FailUnfinished(ctx context.Context, jobErr *jsonrpc.Error, at time.Time) (int, error)
+}
@@ -0,0 +1,381 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// ErrManagerClosed is returned by Submit after Shutdown
+var ErrManagerClosed = errors.New("job manager is shut down")
+
+// errJobCancelled and errShutdown are the causes of a job's context being cancelled
+var (
+	errJobCancelled = errors.New("job cancelled")
+	errShutdown     = errors.New("server shut down before the job finished")
+	errAbandoned    = errors.New("server stopped before the job finished")
+)
+
+// errorMessage is the message of logged store failures
+const errorMessage = "job not recorded"
+
+// storeRetries is how many times a failed job update is retried before it is reported
+const storeRetries = 2
+
+// RunFunc executes the call of a job. A returned *jsonrpc.Error is recorded as is;
+// other errors are recorded as internal errors.
+type RunFunc func(ctx context.Context) (any, error)
+
+// ManagerOpts defines options for configuring the job manager
+type ManagerOpts func(*Manager)
+
+// Manager runs jobs in the background and records them in a Store.
+// Owners only see and cancel their own jobs.
+type Manager struct {
+	store      Store
+	now        func() time.Time
+	retention  time.Duration
+	maxRunning chan struct{} // Semaphore of running jobs, nil when unbounded
+	onError    func(error)
+
+	mutex   sync.Mutex // Guards closed and running; never held while the store is used
+	closed  bool
+	running map[string]*runningJob // Key: job ID
+	wg      sync.WaitGroup
+}
+
+// runningJob is a job executed by this manager that has not finished yet
+type runningJob struct {
+	cancel context.CancelCauseFunc
+
+	// storing is held while the job changes and is stored, which keeps its updates in order
+	storing sync.Mutex
+	job     Job
+}
+
+// NewManager creates a manager that records jobs in store. If the store keeps jobs across
+// restarts (see UnfinishedFailer), jobs left unfinished by an earlier process are recorded
+// as failed.
+func NewManager(store Store, opts ...ManagerOpts) *Manager {
+	m := &Manager{
+		store:   store,
+		now:     time.Now,
+		running: make(map[string]*runningJob),
+		onError: server.NewLogger(slog.Default()).ErrorHandler(errorMessage),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if failer, ok := store.(UnfinishedFailer); ok {
+		jobErr := jsonrpc.NewError(jsonrpc.ErrorCodeInternalError, jsonrpc.ErrorCodeInternalError.Message(), errAbandoned.Error())
+		if _, err := failer.FailUnfinished(context.Background(), jobErr, m.now()); err != nil {
+			m.onError(err)
+		}
+	}
+	return m
+}
+
+// WithMaxRunning bounds how many jobs run at the same time; further jobs stay pending
+// until a running job finishes. By default every job starts immediately.
+func WithMaxRunning(n int) ManagerOpts {
+	return func(m *Manager) {
+		if n > 0 {
+			m.maxRunning = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithRetention deletes finished jobs from the store once they are older than d.
+// Old jobs are removed whenever a job is submitted. By default jobs are kept forever.
+func WithRetention(d time.Duration) ManagerOpts {
+	return func(m *Manager) {
+		m.retention = d
+	}
+}
+
+// WithErrorHandler sets the function called when the outcome of a job cannot be stored.
+// By default errors are logged to the default slog logger.
+func WithErrorHandler(fn func(error)) ManagerOpts {
+	return func(m *Manager) {
+		if fn != nil {
+			m.onError = fn
+		}
+	}
+}
+
+// WithLogger logs job outcomes that cannot be stored to logger, at warn level
+func WithLogger(logger *slog.Logger) ManagerOpts {
+	return func(m *Manager) {
+		m.onError = server.NewLogger(logger).ErrorHandler(errorMessage)
+	}
+}
+
+// Submit records a pending job for method and starts run in the background.
+// The job's context keeps the values of ctx, such as the principal, but not its
+// cancellation: the job continues after the request that submitted it has ended.
+func (m *Manager) Submit(ctx context.Context, owner, method string, params map[string]interface{}, run RunFunc) (*Job, error) {
+	if m.retention > 0 {
+		if _, err := m.store.DeleteFinished(ctx, m.now().Add(-m.retention)); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	job := Job{
+		ID:        id,
+		Owner:     owner,
+		Method:    method,
+		Params:    params,
+		Status:    StatusPending,
+		CreatedAt: m.now(),
+	}
+
+	// The job is registered before it is stored, so that Shutdown waits for it
+	jobCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		cancel(ErrManagerClosed)
+		return nil, ErrManagerClosed
+	}
+	m.running[id] = &runningJob{cancel: cancel, job: job}
+	m.wg.Add(1)
+	m.mutex.Unlock()
+
+	if err := m.store.Create(ctx, &job); err != nil {
+		m.mutex.Lock()
+		delete(m.running, id)
+		m.mutex.Unlock()
+		cancel(err)
+		m.wg.Done()
+		return nil, err
+	}
+	go m.run(jobCtx, id, run)
+
+	submitted := job
+	return &submitted, nil
+}
+
+// Get returns a job of owner, or ErrJobNotFound
+func (m *Manager) Get(ctx context.Context, owner, id string) (*Job, error) {
+	job, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Owner != owner {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// List returns the jobs of owner matching filter, newest first. The owner of filter is ignored.
+func (m *Manager) List(ctx context.Context, owner string, filter Filter) ([]*Job, error) {
+	filter.Owner = owner
+	return m.store.List(ctx, filter)
+}
+
+// Cancel stops a job of owner and records it as cancelled. A running job's context is
+// cancelled; whatever its method returns afterwards is discarded. Jobs left unfinished by
+// an earlier process are marked cancelled as well. Finished jobs return ErrJobFinished.
+func (m *Manager) Cancel(ctx context.Context, owner, id string) (*Job, error) {
+	running, isRunning := m.runningJob(id)
+	if !isRunning {
+		stored, err := m.store.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return m.cancelStored(ctx, owner, stored)
+	}
+
+	running.storing.Lock()
+	defer running.storing.Unlock()
+	if current, _ := m.runningJob(id); current != running {
+		// The job finished while its last update was stored
+		if running.job.Owner != owner {
+			return nil, ErrJobNotFound
+		}
+		return nil, ErrJobFinished
+	}
+
+	job, err := m.cancelStored(ctx, owner, &running.job)
+	if err != nil {
+		return nil, err
+	}
+	m.mutex.Lock()
+	delete(m.running, id)
+	m.mutex.Unlock()
+	running.job = *job
+	running.cancel(errJobCancelled)
+	return job, nil
+}
+
+// cancelStored records an unfinished job of owner as cancelled
+func (m *Manager) cancelStored(ctx context.Context, owner string, stored *Job) (*Job, error) {
+	if stored.Owner != owner {
+		return nil, ErrJobNotFound
+	}
+	if stored.Status.Finished() {
+		return nil, ErrJobFinished
+	}
+
+	job := *stored
+	job.Status = StatusCancelled
+	job.FinishedAt = m.now()
+	if err := m.store.Update(ctx, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// runningJob returns a job executed by this manager that has not finished yet
+func (m *Manager) runningJob(id string) (*runningJob, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	running, ok := m.running[id]
+	return running, ok
+}
+
+// Shutdown stops accepting jobs, cancels the running ones and waits until they have been
+// recorded as failed, or until ctx is done. It can be registered with (*server.Server).OnStop.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mutex.Lock()
+	m.closed = true
+	for _, running := range m.running {
+		running.cancel(errShutdown)
+	}
+	m.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run waits for a slot, executes a job and records its outcome
+func (m *Manager) run(ctx context.Context, id string, run RunFunc) {
+	defer m.wg.Done()
+
+	if m.maxRunning != nil {
+		select {
+		case m.maxRunning <- struct{}{}:
+			defer func() { <-m.maxRunning }()
+		case <-ctx.Done():
+			m.finish(ctx, id, nil, ctx.Err())
+			return
+		}
+	}
+
+	if !m.transition(ctx, id, func(job *Job) {
+		job.Status = StatusRunning
+		job.StartedAt = m.now()
+	}) {
+		return
+	}
+
+	result, err := run(ctx)
+	m.finish(ctx, id, result, err)
+}
+
+// finish records the result or error of a job that was not cancelled
+func (m *Manager) finish(ctx context.Context, id string, result any, err error) {
+	if err == nil {
+		encoded, encodeErr := json.Marshal(result)
+		if encodeErr != nil {
+			err = fmt.Errorf("failed to encode result: %w", encodeErr)
+		} else {
+			m.transition(ctx, id, func(job *Job) {
+				job.Status = StatusSucceeded
+				job.Result = encoded
+				job.FinishedAt = m.now()
+			})
+			return
+		}
+	}
+
+	if errors.Is(context.Cause(ctx), errShutdown) {
+		err = errShutdown
+	}
+	rpcErr, ok := jsonrpc.AsError(err)
+	if !ok {
+		rpcErr = jsonrpc.NewError(jsonrpc.ErrorCodeInternalError, jsonrpc.ErrorCodeInternalError.Message(), err.Error())
+	}
+	m.transition(ctx, id, func(job *Job) {
+		job.Status = StatusFailed
+		job.Error = rpcErr
+		job.FinishedAt = m.now()
+	})
+}
+
+// transition applies update to a running job and stores it. It reports false if the job
+// has been cancelled in the meantime. Finished jobs are removed from the running jobs.
+// Failed updates are retried, then reported to the error handler; the job keeps running,
+// and if the process stops the next manager on a persistent store records it as failed.
+func (m *Manager) transition(ctx context.Context, id string, update func(job *Job)) bool {
+	running, ok := m.runningJob(id)
+	if !ok {
+		return false
+	}
+	running.storing.Lock()
+	defer running.storing.Unlock()
+
+	m.mutex.Lock()
+	if m.running[id] != running {
+		// Cancelled while waiting for the lock
+		m.mutex.Unlock()
+		return false
+	}
+	update(&running.job)
+	if running.job.Status.Finished() {
+		delete(m.running, id)
+	}
+	m.mutex.Unlock()
+
+	// The job's context may be cancelled by Shutdown; its outcome is still recorded
+	if err := m.update(context.WithoutCancel(ctx), &running.job); err != nil {
+		m.onError(fmt.Errorf("failed to record job %s as %s: %w", id, running.job.Status, err))
+	}
+	return true
+}
+
+// update stores job, retrying with a short backoff. The caller holds the job's storing
+// mutex, which keeps the updates of a job in order.
+func (m *Manager) update(ctx context.Context, job *Job) error {
+	backoff := 10 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := m.store.Update(ctx, job)
+		if err == nil || errors.Is(err, ErrJobNotFound) || attempt == storeRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// newJobID returns a random job ID
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,388 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// waitForJob polls a job until it has finished
+func waitForJob(t *testing.T, m *Manager, owner, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(context.Background(), owner, id)
+		if err != nil {
+			t.Fatalf("failed to get job: %v", err)
+		}
+		if job.Status.Finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+// blockUntilCancelled is a RunFunc that returns once its context is done
+func blockUntilCancelled(ctx context.Context) (any, error) {
+	<-ctx.Done()
+	return "ignored", ctx.Err()
+}
+
+func TestManager_Submit(t *testing.T) {
+	tests := []struct {
+		name           string
+		run            RunFunc
+		expectedStatus Status
+		expectedResult string
+		expectedError  *jsonrpc.Error
+	}{
+		{
+			name:           "result_is_recorded",
+			run:            func(ctx context.Context) (any, error) { return map[string]int{"total": 3}, nil },
+			expectedStatus: StatusSucceeded,
+			expectedResult: `{"total":3}`,
+		},
+		{
+			name: "jsonrpc_errors_are_kept",
+			run: func(ctx context.Context) (any, error) {
+				return nil, jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, "Forbidden", "missing scopes: notes:write")
+			},
+			expectedStatus: StatusFailed,
+			expectedError:  jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, "Forbidden", "missing scopes: notes:write"),
+		},
+		{
+			name:           "other_errors_are_internal",
+			run:            func(ctx context.Context) (any, error) { return nil, errors.New("disk full") },
+			expectedStatus: StatusFailed,
+			expectedError:  jsonrpc.NewError(jsonrpc.ErrorCodeInternalError, "Internal error", "disk full"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(NewMemoryStore())
+
+			// The job keeps running after the submitting request is cancelled
+			ctx, cancel := context.WithCancel(context.Background())
+			job, err := m.Submit(ctx, "ada", "Notes.Add", map[string]interface{}{"text": "hi"}, tt.run)
+			cancel()
+			if err != nil {
+				t.Fatalf("failed to submit job: %v", err)
+			}
+			if job.Status != StatusPending || job.ID == "" {
+				t.Errorf("expected pending job with an ID, got %+v", job)
+			}
+
+			job = waitForJob(t, m, "ada", job.ID)
+			if job.Status != tt.expectedStatus {
+				t.Errorf("expected status %q, got %q", tt.expectedStatus, job.Status)
+			}
+			if string(job.Result) != tt.expectedResult {
+				t.Errorf("expected result %s, got %s", tt.expectedResult, job.Result)
+			}
+			if tt.expectedError != nil && (job.Error == nil || *job.Error != *tt.expectedError) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, job.Error)
+			}
+			if job.StartedAt.IsZero() || job.FinishedAt.IsZero() {
+				t.Errorf("expected start and finish times, got %+v", job)
+			}
+		})
+	}
+}
+
+func TestManager_SubmitKeepsContextValues(t *testing.T) {
+	m := NewManager(NewMemoryStore())
+	principal := &server.Principal{Subject: "ada"}
+
+	seen := make(chan *server.Principal, 1)
+	ctx := server.ContextWithPrincipal(context.Background(), principal)
+	job, err := m.Submit(ctx, "ada", "Notes.Add", nil, func(ctx context.Context) (any, error) {
+		p, _ := server.PrincipalFromContext(ctx)
+		seen <- p
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to submit job: %v", err)
+	}
+	waitForJob(t, m, "ada", job.ID)
+
+	if p := <-seen; p != principal {
+		t.Errorf("expected principal %+v in job context, got %+v", principal, p)
+	}
+}
+
+func TestManager_Cancel(t *testing.T) {
+	m := NewManager(NewMemoryStore())
+	ctx := context.Background()
+
+	job, err := m.Submit(ctx, "ada", "Build.Run", nil, blockUntilCancelled)
+	if err != nil {
+		t.Fatalf("failed to submit job: %v", err)
+	}
+
+	if _, err := m.Cancel(ctx, "bob", job.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected other owners to get ErrJobNotFound, got %v", err)
+	}
+
+	cancelled, err := m.Cancel(ctx, "ada", job.ID)
+	if err != nil {
+		t.Fatalf("failed to cancel job: %v", err)
+	}
+	if cancelled.Status != StatusCancelled {
+		t.Errorf("expected cancelled job, got %q", cancelled.Status)
+	}
+
+	// The method's late return must not overwrite the cancellation
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
+	}
+	stored, err := m.Get(ctx, "ada", job.ID)
+	if err != nil {
+		t.Fatalf("failed to get job: %v", err)
+	}
+	if stored.Status != StatusCancelled || stored.Result != nil {
+		t.Errorf("expected cancelled job without result, got %+v", stored)
+	}
+
+	if _, err := m.Cancel(ctx, "ada", job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("expected ErrJobFinished, got %v", err)
+	}
+}
+
+func TestManager_CancelOrphanedJob(t *testing.T) {
+	store := NewMemoryStore()
+	store.Create(context.Background(), &Job{ID: "orphan", Owner: "ada", Status: StatusRunning})
+	m := NewManager(store)
+
+	job, err := m.Cancel(context.Background(), "ada", "orphan")
+	if err != nil {
+		t.Fatalf("failed to cancel job: %v", err)
+	}
+	if job.Status != StatusCancelled {
+		t.Errorf("expected cancelled job, got %q", job.Status)
+	}
+}
+
+func TestManager_Get(t *testing.T) {
+	m := NewManager(NewMemoryStore())
+	job, err := m.Submit(context.Background(), "ada", "Notes.Add", nil, func(ctx context.Context) (any, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("failed to submit job: %v", err)
+	}
+	waitForJob(t, m, "ada", job.ID)
+
+	tests := []struct {
+		name          string
+		owner         string
+		expectedError error
+	}{
+		{name: "owner", owner: "ada"},
+		{name: "other_owner", owner: "bob", expectedError: ErrJobNotFound},
+		{name: "unauthenticated", owner: "", expectedError: ErrJobNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Get(context.Background(), tt.owner, job.ID)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestWithMaxRunning(t *testing.T) {
+	m := NewManager(NewMemoryStore(), WithMaxRunning(1))
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	first, _ := m.Submit(ctx, "ada", "Build.Run", nil, func(ctx context.Context) (any, error) {
+		close(started)
+		return blockUntilCancelled(ctx)
+	})
+	<-started
+	second, _ := m.Submit(ctx, "ada", "Build.Run", nil, func(ctx context.Context) (any, error) { return "done", nil })
+
+	// The second job waits for the first one's slot
+	time.Sleep(20 * time.Millisecond)
+	if job, _ := m.Get(ctx, "ada", second.ID); job.Status != StatusPending {
+		t.Errorf("expected second job to be pending, got %q", job.Status)
+	}
+
+	m.Cancel(ctx, "ada", first.ID)
+	if job := waitForJob(t, m, "ada", second.ID); job.Status != StatusSucceeded {
+		t.Errorf("expected second job to succeed, got %q", job.Status)
+	}
+}
+
+func TestWithRetention(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+	store.Create(context.Background(), &Job{ID: "old", Owner: "ada", Status: StatusSucceeded, FinishedAt: now.Add(-2 * time.Hour)})
+
+	m := NewManager(store, WithRetention(time.Hour))
+	m.now = func() time.Time { return now }
+	job, err := m.Submit(context.Background(), "ada", "Notes.Add", nil, func(ctx context.Context) (any, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("failed to submit job: %v", err)
+	}
+	waitForJob(t, m, "ada", job.ID)
+
+	if _, err := store.Get(context.Background(), "old"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected expired job to be deleted, got %v", err)
+	}
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	m := NewManager(NewMemoryStore())
+	ctx := context.Background()
+
+	job, err := m.Submit(ctx, "ada", "Build.Run", nil, blockUntilCancelled)
+	if err != nil {
+		t.Fatalf("failed to submit job: %v", err)
+	}
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
+	}
+
+	stored, _ := m.Get(ctx, "ada", job.ID)
+	if stored.Status != StatusFailed || stored.Error == nil || stored.Error.Data != errShutdown.Error() {
+		t.Errorf("expected job to fail with shutdown error, got %+v", stored)
+	}
+	if _, err := m.Submit(ctx, "ada", "Build.Run", nil, blockUntilCancelled); !errors.Is(err, ErrManagerClosed) {
+		t.Errorf("expected ErrManagerClosed, got %v", err)
+	}
+}
+
+// slowStore blocks the updates of jobs of a method until release is closed, and
+// reports on blocked when an update starts waiting
+type slowStore struct {
+	*MemoryStore
+	method  string
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (s *slowStore) Update(ctx context.Context, job *Job) error {
+	if job.Method == s.method {
+		select {
+		case s.blocked <- struct{}{}:
+		default:
+		}
+		<-s.release
+	}
+	return s.MemoryStore.Update(ctx, job)
+}
+
+func TestManager_SlowStoreDoesNotBlockOtherJobs(t *testing.T) {
+	store := &slowStore{MemoryStore: NewMemoryStore(), method: "Slow.Run", blocked: make(chan struct{}, 1), release: make(chan struct{})}
+	m := NewManager(store)
+	ctx := context.Background()
+
+	slow, err := m.Submit(ctx, "ada", "Slow.Run", nil, func(ctx context.Context) (any, error) { return "done", nil })
+	if err != nil {
+		t.Fatalf("failed to submit job: %v", err)
+	}
+
+	// The slow job is stuck storing its status; other jobs are submitted and cancelled meanwhile
+	<-store.blocked
+	done := make(chan error, 1)
+	go func() {
+		job, err := m.Submit(ctx, "ada", "Build.Run", nil, blockUntilCancelled)
+		if err == nil {
+			_, err = m.Cancel(ctx, "ada", job.ID)
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("failed to submit and cancel job: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("submitting and cancelling a job waited for the slow store")
+	}
+
+	close(store.release)
+	if job := waitForJob(t, m, "ada", slow.ID); job.Status != StatusSucceeded {
+		t.Errorf("expected slow job to succeed, got %q", job.Status)
+	}
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
+	}
+}
+
+// flakyStore fails the first updates of a MemoryStore
+type flakyStore struct {
+	*MemoryStore
+	mutex    sync.Mutex
+	failures int
+}
+
+func (s *flakyStore) Update(ctx context.Context, job *Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("database is locked")
+	}
+	return s.MemoryStore.Update(ctx, job)
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int
+		expectedStatus Status
+		expectedErrors int
+	}{
+		{
+			name:           "retried_update_succeeds",
+			failures:       storeRetries,
+			expectedStatus: StatusSucceeded,
+		},
+		{
+			name:           "failed_updates_are_reported",
+			failures:       1000,
+			expectedStatus: StatusPending,
+			expectedErrors: 2, // Running and succeeded
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &flakyStore{MemoryStore: NewMemoryStore(), failures: tt.failures}
+			var mutex sync.Mutex
+			var reported []error
+			m := NewManager(store, WithErrorHandler(func(err error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				reported = append(reported, err)
+			}))
+
+			ctx := context.Background()
+			job, err := m.Submit(ctx, "ada", "Notes.Add", nil, func(ctx context.Context) (any, error) { return "done", nil })
+			if err != nil {
+				t.Fatalf("failed to submit job: %v", err)
+			}
+			if err := m.Shutdown(ctx); err != nil {
+				t.Fatalf("failed to shut down: %v", err)
+			}
+
+			stored, _ := m.Get(ctx, "ada", job.ID)
+			if stored.Status != tt.expectedStatus {
+				t.Errorf("expected stored status %q, got %q", tt.expectedStatus, stored.Status)
+			}
+			if len(reported) != tt.expectedErrors {
+				t.Errorf("expected %d reported errors, got %v", tt.expectedErrors, reported)
+			}
+		})
+	}
+}
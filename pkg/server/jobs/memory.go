@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps jobs in memory; they are lost when the process exits
+type MemoryStore struct {
+	mutex sync.RWMutex
+	jobs  map[string]Job // Key: job ID
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]Job)}
+}
+
+// Create implements Store
+func (s *MemoryStore) Create(ctx context.Context, job *Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.jobs[job.ID]; exists {
+		return fmt.Errorf("job %s already exists", job.ID)
+	}
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+// Update implements Store
+func (s *MemoryStore) Update(ctx context.Context, job *Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.jobs[job.ID]; !exists {
+		return ErrJobNotFound
+	}
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return &job, nil
+}
+
+// List implements Store
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]*Job, error) {
+	s.mutex.RLock()
+	var jobs []*Job
+	for _, job := range s.jobs {
+		if job.Owner != filter.Owner || (filter.Status != "" && job.Status != filter.Status) {
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+	if filter.Limit > 0 && len(jobs) > filter.Limit {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, nil
+}
+
+// DeleteFinished implements Store
+func (s *MemoryStore) DeleteFinished(ctx context.Context, before time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	deleted := 0
+	for id, job := range s.jobs {
+		if job.Status.Finished() && job.FinishedAt.Before(before) {
+			delete(s.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,241 @@
+// Package sqlitestore keeps the jobs of a jobs.Manager in a SQLite database, so that they
+// survive restarts. It is a package of its own so that only programs using it link SQLite.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pangobit/agent-sdk/internal/sqlitedb"
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server/jobs"
+)
+
+// sqliteSchema creates the jobs table. Times are stored as Unix nanoseconds, NULL when unset.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id          TEXT PRIMARY KEY,
+	owner       TEXT NOT NULL,
+	method      TEXT NOT NULL,
+	params      TEXT,
+	status      TEXT NOT NULL,
+	result      TEXT,
+	error       TEXT,
+	created_at  INTEGER NOT NULL,
+	started_at  INTEGER,
+	finished_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS jobs_owner_created ON jobs (owner, created_at);
+CREATE INDEX IF NOT EXISTS jobs_finished ON jobs (finished_at);
+`
+
+// sqliteColumns lists the columns read by scanJob, in order
+const sqliteColumns = "id, owner, method, params, status, result, error, created_at, started_at, finished_at"
+
+// Store keeps jobs in a SQLite database. It implements jobs.Store and jobs.UnfinishedFailer.
+type Store struct {
+	db    *sql.DB
+	owned bool // Whether Close closes db
+}
+
+// New stores jobs in db, which must use a SQLite driver such as
+// modernc.org/sqlite. The jobs table is created if it does not exist.
+func New(db *sql.DB) (*Store, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create jobs table: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Open opens or creates the SQLite database file at path and stores jobs in it
+func Open(path string) (*Store, error) {
+	db, err := sqlitedb.Open(path, sqliteSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job database: %w", err)
+	}
+	return &Store{db: db, owned: true}, nil
+}
+
+// Close closes the database if it was opened by Open
+func (s *Store) Close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Create implements jobs.Store
+func (s *Store) Create(ctx context.Context, job *jobs.Job) error {
+	row, err := encodeJob(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO jobs ("+sqliteColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", row...)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+// Update implements jobs.Store
+func (s *Store) Update(ctx context.Context, job *jobs.Job) error {
+	row, err := encodeJob(job)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE jobs SET owner = ?, method = ?, params = ?, status = ?, result = ?, error = ?, created_at = ?, started_at = ?, finished_at = ? WHERE id = ?",
+		append(row[1:], row[0])...)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return jobs.ErrJobNotFound
+	}
+	return nil
+}
+
+// Get implements jobs.Store
+func (s *Store) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	job, err := scanJob(s.db.QueryRowContext(ctx, "SELECT "+sqliteColumns+" FROM jobs WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, jobs.ErrJobNotFound
+	}
+	return job, err
+}
+
+// List implements jobs.Store
+func (s *Store) List(ctx context.Context, filter jobs.Filter) ([]*jobs.Job, error) {
+	var query strings.Builder
+	args := []any{filter.Owner}
+	query.WriteString("SELECT " + sqliteColumns + " FROM jobs WHERE owner = ?")
+	if filter.Status != "" {
+		query.WriteString(" AND status = ?")
+		args = append(args, string(filter.Status))
+	}
+	query.WriteString(" ORDER BY created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query.WriteString(" LIMIT ?")
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var listed []*jobs.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		listed = append(listed, job)
+	}
+	return listed, rows.Err()
+}
+
+// DeleteFinished implements jobs.Store
+func (s *Store) DeleteFinished(ctx context.Context, before time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM jobs WHERE status IN (?, ?, ?) AND finished_at < ?",
+		string(jobs.StatusSucceeded), string(jobs.StatusFailed), string(jobs.StatusCancelled), before.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete jobs: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// FailUnfinished implements jobs.UnfinishedFailer
+func (s *Store) FailUnfinished(ctx context.Context, jobErr *jsonrpc.Error, at time.Time) (int, error) {
+	encoded, err := json.Marshal(jobErr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode job error: %w", err)
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE jobs SET status = ?, error = ?, finished_at = ? WHERE status IN (?, ?)",
+		string(jobs.StatusFailed), string(encoded), at.UnixNano(), string(jobs.StatusPending), string(jobs.StatusRunning))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail unfinished jobs: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// encodeJob returns the column values of job in sqliteColumns order
+func encodeJob(job *jobs.Job) ([]any, error) {
+	var params, jobErr sql.NullString
+	if job.Params != nil {
+		encoded, err := json.Marshal(job.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job params: %w", err)
+		}
+		params = sql.NullString{String: string(encoded), Valid: true}
+	}
+	if job.Error != nil {
+		encoded, err := json.Marshal(job.Error)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job error: %w", err)
+		}
+		jobErr = sql.NullString{String: string(encoded), Valid: true}
+	}
+	result := sql.NullString{String: string(job.Result), Valid: job.Result != nil}
+
+	return []any{
+		job.ID, job.Owner, job.Method, params, string(job.Status), result, jobErr,
+		job.CreatedAt.UnixNano(), nullTime(job.StartedAt), nullTime(job.FinishedAt),
+	}, nil
+}
+
+// scanJob reads a job selected with sqliteColumns
+func scanJob(row interface{ Scan(...any) error }) (*jobs.Job, error) {
+	var (
+		job                  jobs.Job
+		status               string
+		params, result, errs sql.NullString
+		created              int64
+		started, finished    sql.NullInt64
+	)
+	if err := row.Scan(&job.ID, &job.Owner, &job.Method, &params, &status, &result, &errs, &created, &started, &finished); err != nil {
+		return nil, err
+	}
+
+	job.Status = jobs.Status(status)
+	job.CreatedAt = time.Unix(0, created)
+	if started.Valid {
+		job.StartedAt = time.Unix(0, started.Int64)
+	}
+	if finished.Valid {
+		job.FinishedAt = time.Unix(0, finished.Int64)
+	}
+	if result.Valid {
+		job.Result = json.RawMessage(result.String)
+	}
+	if params.Valid {
+		if err := json.Unmarshal([]byte(params.String), &job.Params); err != nil {
+			return nil, fmt.Errorf("failed to decode params of job %s: %w", job.ID, err)
+		}
+	}
+	if errs.Valid {
+		job.Error = &jsonrpc.Error{}
+		if err := json.Unmarshal([]byte(errs.String), job.Error); err != nil {
+			return nil, fmt.Errorf("failed to decode error of job %s: %w", job.ID, err)
+		}
+	}
+	return &job, nil
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
@@ -0,0 +1,46 @@
+package sqlitestore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server/jobs"
+)
+
+func TestStore_FailUnfinished(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store, err := Open(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("failed to open SQLite store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	for _, job := range []*jobs.Job{
+		{ID: "pending", Owner: "ada", Status: jobs.StatusPending, CreatedAt: now},
+		{ID: "running", Owner: "ada", Status: jobs.StatusRunning, CreatedAt: now, StartedAt: now},
+		{ID: "done", Owner: "ada", Status: jobs.StatusSucceeded, CreatedAt: now, FinishedAt: now},
+	} {
+		if err := store.Create(ctx, job); err != nil {
+			t.Fatalf("failed to create job: %v", err)
+		}
+	}
+
+	// A new manager records the jobs left unfinished by an earlier process as failed
+	m := jobs.NewManager(store, jobs.WithErrorHandler(func(err error) { t.Errorf("unexpected error: %v", err) }))
+
+	for id, expected := range map[string]jobs.Status{"pending": jobs.StatusFailed, "running": jobs.StatusFailed, "done": jobs.StatusSucceeded} {
+		job, err := m.Get(ctx, "ada", id)
+		if err != nil {
+			t.Fatalf("failed to get job %s: %v", id, err)
+		}
+		if job.Status != expected {
+			t.Errorf("expected job %s to be %q, got %q", id, expected, job.Status)
+		}
+		if expected == jobs.StatusFailed && (job.Error == nil || job.Error.Data != "server stopped before the job finished" || job.FinishedAt.IsZero()) {
+			t.Errorf("expected job %s to record why it failed, got %+v", id, job)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server/jobs"
+	"github.com/pangobit/agent-sdk/pkg/server/jobs/sqlitestore"
+)
+
+// stores returns a fresh instance of every store implementation
+func stores(t *testing.T) map[string]jobs.Store {
+	t.Helper()
+	sqliteStore, err := sqlitestore.Open(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("failed to open SQLite store: %v", err)
+	}
+	t.Cleanup(func() { sqliteStore.Close() })
+
+	return map[string]jobs.Store{
+		"memory": jobs.NewMemoryStore(),
+		"sqlite": sqliteStore,
+	}
+}
+
+func TestStore_CreateGetUpdate(t *testing.T) {
+	created := time.Unix(1700000000, 0)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			job := &jobs.Job{
+				ID:        "job-1",
+				Owner:     "ada",
+				Method:    "Notes.Add",
+				Params:    map[string]interface{}{"text": "hi"},
+				Status:    jobs.StatusPending,
+				CreatedAt: created,
+			}
+			if err := store.Create(ctx, job); err != nil {
+				t.Fatalf("failed to create job: %v", err)
+			}
+			if err := store.Create(ctx, job); err == nil {
+				t.Error("expected duplicate job to be rejected")
+			}
+
+			job.Status = jobs.StatusFailed
+			job.Error = jsonrpc.NewError(jsonrpc.ErrorCodeInternalError, "Internal error", "disk full")
+			job.StartedAt = created.Add(time.Second)
+			job.FinishedAt = created.Add(2 * time.Second)
+			if err := store.Update(ctx, job); err != nil {
+				t.Fatalf("failed to update job: %v", err)
+			}
+
+			got, err := store.Get(ctx, "job-1")
+			if err != nil {
+				t.Fatalf("failed to get job: %v", err)
+			}
+			if !got.CreatedAt.Equal(job.CreatedAt) || !got.StartedAt.Equal(job.StartedAt) || !got.FinishedAt.Equal(job.FinishedAt) {
+				t.Errorf("times not preserved: got %+v", got)
+			}
+			got.CreatedAt, got.StartedAt, got.FinishedAt = job.CreatedAt, job.StartedAt, job.FinishedAt
+			if !reflect.DeepEqual(got, job) {
+				t.Errorf("expected %+v, got %+v", job, got)
+			}
+
+			if _, err := store.Get(ctx, "missing"); !errors.Is(err, jobs.ErrJobNotFound) {
+				t.Errorf("expected ErrJobNotFound, got %v", err)
+			}
+			if err := store.Update(ctx, &jobs.Job{ID: "missing"}); !errors.Is(err, jobs.ErrJobNotFound) {
+				t.Errorf("expected ErrJobNotFound on update, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	created := time.Unix(1700000000, 0)
+	seed := []*jobs.Job{
+		{ID: "a", Owner: "ada", Method: "Notes.Add", Status: jobs.StatusSucceeded, Result: json.RawMessage(`{"ok":true}`), CreatedAt: created},
+		{ID: "b", Owner: "ada", Method: "Notes.Add", Status: jobs.StatusRunning, CreatedAt: created.Add(time.Minute)},
+		{ID: "c", Owner: "bob", Method: "Notes.Add", Status: jobs.StatusRunning, CreatedAt: created.Add(2 * time.Minute)},
+		{ID: "d", Owner: "", Method: "Notes.Add", Status: jobs.StatusPending, CreatedAt: created.Add(3 * time.Minute)},
+		{ID: "e", Owner: "ada", Method: "Notes.Add", Status: jobs.StatusRunning, CreatedAt: created.Add(4 * time.Minute)},
+	}
+
+	tests := []struct {
+		name        string
+		filter      jobs.Filter
+		expectedIDs []string
+	}{
+		{
+			name:        "owner_newest_first",
+			filter:      jobs.Filter{Owner: "ada"},
+			expectedIDs: []string{"e", "b", "a"},
+		},
+		{
+			name:        "status",
+			filter:      jobs.Filter{Owner: "ada", Status: jobs.StatusRunning},
+			expectedIDs: []string{"e", "b"},
+		},
+		{
+			name:        "limit",
+			filter:      jobs.Filter{Owner: "ada", Limit: 1},
+			expectedIDs: []string{"e"},
+		},
+		{
+			name:        "unauthenticated_owner",
+			filter:      jobs.Filter{},
+			expectedIDs: []string{"d"},
+		},
+		{
+			name:   "no_jobs",
+			filter: jobs.Filter{Owner: "eve"},
+		},
+	}
+
+	for name, store := range stores(t) {
+		for _, job := range seed {
+			if err := store.Create(context.Background(), job); err != nil {
+				t.Fatalf("failed to seed %s store: %v", name, err)
+			}
+		}
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				listed, err := store.List(context.Background(), tt.filter)
+				if err != nil {
+					t.Fatalf("failed to list jobs: %v", err)
+				}
+				var ids []string
+				for _, job := range listed {
+					ids = append(ids, job.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.expectedIDs) {
+					t.Errorf("expected %v, got %v", tt.expectedIDs, ids)
+				}
+			})
+		}
+	}
+}
+
+func TestStore_DeleteFinished(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed := []*jobs.Job{
+				{ID: "old", Status: jobs.StatusSucceeded, CreatedAt: now.Add(-2 * time.Hour), FinishedAt: now.Add(-2 * time.Hour)},
+				{ID: "recent", Status: jobs.StatusFailed, CreatedAt: now.Add(-2 * time.Hour), FinishedAt: now.Add(-time.Minute)},
+				{ID: "running", Status: jobs.StatusRunning, CreatedAt: now.Add(-2 * time.Hour)},
+			}
+			for _, job := range seed {
+				if err := store.Create(ctx, job); err != nil {
+					t.Fatalf("failed to seed store: %v", err)
+				}
+			}
+
+			deleted, err := store.DeleteFinished(ctx, now.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("failed to delete jobs: %v", err)
+			}
+			if deleted != 1 {
+				t.Errorf("expected 1 deleted job, got %d", deleted)
+			}
+			if _, err := store.Get(ctx, "old"); !errors.Is(err, jobs.ErrJobNotFound) {
+				t.Errorf("expected old job to be deleted, got %v", err)
+			}
+			for _, id := range []string{"recent", "running"} {
+				if _, err := store.Get(ctx, id); err != nil {
+					t.Errorf("expected job %s to be kept, got %v", id, err)
+				}
+			}
+		})
+	}
+}
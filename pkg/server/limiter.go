@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+)
+
+// maxCallerStates bounds the per-caller state kept before idle entries are pruned
+const maxCallerStates = 4096
+
+// Limit bounds how often and how many times concurrently a tool may be called.
+// Rate is the sustained number of calls per second and Burst the number of calls that
+// may be made at once after a quiet period; Burst defaults to Rate rounded up.
+// MaxInFlight caps the calls running at the same time. Zero values disable a bound.
+type Limit struct {
+	Rate        float64
+	Burst       int
+	MaxInFlight int
+}
+
+// LimiterOpts defines options for configuring the limiter
+type LimiterOpts func(*Limiter)
+
+// Limiter enforces rate limits and concurrency caps per tool, per service and per caller.
+// A call must be admitted by every limit that applies to it; rejected calls fail with a
+// jsonrpc.ErrorCodeRateLimited error whose data holds a "retryAfter" hint in seconds.
+// Install it with Interceptor.
+type Limiter struct {
+	mutex    sync.Mutex
+	now      func() time.Time
+	tools    map[string]*limitState // Key: "ServiceName.MethodName"
+	services map[string]*limitState // Key: service name
+	caller   *Limit
+	callers  map[string]*limitState // Key: caller identity
+}
+
+// limitState is the bucket and in-flight count of one limited key
+type limitState struct {
+	limit    Limit
+	tokens   float64
+	last     time.Time
+	inFlight int
+}
+
+// NewLimiter creates a limiter and applies the given options
+func NewLimiter(opts ...LimiterOpts) *Limiter {
+	l := &Limiter{
+		now:      time.Now,
+		tools:    make(map[string]*limitState),
+		services: make(map[string]*limitState),
+		callers:  make(map[string]*limitState),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithToolLimit limits the calls to a single method, across all callers
+func WithToolLimit(serviceName, methodName string, limit Limit) LimiterOpts {
+	return func(l *Limiter) {
+		l.SetToolLimit(serviceName, methodName, limit)
+	}
+}
+
+// WithServiceLimit limits the calls to all methods of a service together, across all callers
+func WithServiceLimit(serviceName string, limit Limit) LimiterOpts {
+	return func(l *Limiter) {
+		l.SetServiceLimit(serviceName, limit)
+	}
+}
+
+// WithCallerLimit limits the calls of each caller separately, see SetCallerLimit
+func WithCallerLimit(limit Limit) LimiterOpts {
+	return func(l *Limiter) {
+		l.SetCallerLimit(limit)
+	}
+}
+
+// SetToolLimit sets the limit of a single method, replacing any previous one
+func (l *Limiter) SetToolLimit(serviceName, methodName string, limit Limit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tools[serviceName+"."+methodName] = l.newState(limit)
+}
+
+// SetServiceLimit sets the limit shared by all methods of a service, replacing any previous one
+func (l *Limiter) SetServiceLimit(serviceName string, limit Limit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.services[serviceName] = l.newState(limit)
+}
+
+// SetCallerLimit sets the limit applied to each caller. Authenticated callers are told
+// apart by their principal's subject, others by their remote IP address.
+func (l *Limiter) SetCallerLimit(limit Limit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.caller = &limit
+	l.callers = make(map[string]*limitState)
+}
+
+// Interceptor returns an interceptor that admits calls within the limits and rejects the rest
+// before they are executed
+func (l *Limiter) Interceptor() Interceptor {
+	return func(ctx context.Context, call *ToolCall, next Invoker) (any, error) {
+		release, err := l.acquire(call)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return next(ctx, call)
+	}
+}
+
+// acquire admits call under every applicable limit or returns a rate limit error.
+// The returned function ends the call's in-flight period.
+func (l *Limiter) acquire(call *ToolCall) (func(), error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	type applied struct {
+		name  string
+		state *limitState
+	}
+	var states []applied
+	if state, ok := l.tools[call.ServiceName+"."+call.MethodName]; ok {
+		states = append(states, applied{"tool " + call.ServiceName + "." + call.MethodName, state})
+	}
+	if state, ok := l.services[call.ServiceName]; ok {
+		states = append(states, applied{"service " + call.ServiceName, state})
+	}
+	if identity := callerIdentity(call.Caller); l.caller != nil && identity != "" {
+		state, ok := l.callers[identity]
+		if !ok {
+			if len(l.callers) >= maxCallerStates {
+				l.pruneCallers(now)
+			}
+			state = l.newState(*l.caller)
+			l.callers[identity] = state
+		}
+		states = append(states, applied{"caller " + identity, state})
+	}
+
+	// Check every limit before consuming any, so a rejected call costs nothing
+	for _, a := range states {
+		a.state.refill(now)
+		if wait, ok := a.state.admits(); !ok {
+			return nil, rateLimitError(a.name, wait)
+		}
+	}
+	for _, a := range states {
+		a.state.take()
+	}
+
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		for _, a := range states {
+			a.state.inFlight--
+		}
+	}, nil
+}
+
+// newState creates the state of a limit with a full bucket
+func (l *Limiter) newState(limit Limit) *limitState {
+	if limit.Rate > 0 && limit.Burst <= 0 {
+		limit.Burst = int(math.Ceil(limit.Rate))
+	}
+	return &limitState{limit: limit, tokens: float64(limit.Burst), last: l.now()}
+}
+
+// pruneCallers drops callers that have no calls in flight and a full bucket,
+// since a fresh state for them behaves the same
+func (l *Limiter) pruneCallers(now time.Time) {
+	for identity, state := range l.callers {
+		state.refill(now)
+		if state.inFlight == 0 && state.tokens >= float64(state.limit.Burst) {
+			delete(l.callers, identity)
+		}
+	}
+}
+
+// refill adds the tokens earned since the last refill
+func (s *limitState) refill(now time.Time) {
+	if s.limit.Rate <= 0 {
+		return
+	}
+	elapsed := now.Sub(s.last).Seconds()
+	s.last = now
+	if elapsed > 0 {
+		s.tokens = math.Min(float64(s.limit.Burst), s.tokens+elapsed*s.limit.Rate)
+	}
+}
+
+// admits reports whether a call fits the limit, or how long to wait until it may
+func (s *limitState) admits() (time.Duration, bool) {
+	if s.limit.MaxInFlight > 0 && s.inFlight >= s.limit.MaxInFlight {
+		// There is no telling when a running call ends; suggest a short pause
+		return time.Second, false
+	}
+	if s.limit.Rate > 0 && s.tokens < 1 {
+		return time.Duration((1 - s.tokens) / s.limit.Rate * float64(time.Second)), false
+	}
+	return 0, true
+}
+
+// take consumes a token and records a call in flight
+func (s *limitState) take() {
+	if s.limit.Rate > 0 {
+		s.tokens--
+	}
+	s.inFlight++
+}
+
+// callerIdentity returns the key of a caller's limit: its principal's subject if
+// authenticated, otherwise its IP address
+func callerIdentity(caller Caller) string {
+	if caller.Principal != nil && caller.Principal.Subject != "" {
+		return "principal:" + caller.Principal.Subject
+	}
+	if caller.RemoteAddr == "" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(caller.RemoteAddr)
+	if err != nil {
+		host = caller.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimitError reports a rejected call. retryAfter is rounded up to whole seconds,
+// the unit of the HTTP Retry-After header.
+func rateLimitError(limit string, wait time.Duration) *jsonrpc.Error {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return jsonrpc.NewError(jsonrpc.ErrorCodeRateLimited, fmt.Sprintf("%s: %s", jsonrpc.ErrorCodeRateLimited.Message(), limit),
+		map[string]any{"retryAfter": retryAfter})
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+)
+
+// fakeClock is a manually advanced time source
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestLimiter_RateLimits(t *testing.T) {
+	alice := Caller{Transport: TransportHTTP, RemoteAddr: "10.0.0.1:5000", Principal: &Principal{Subject: "alice"}}
+	bob := Caller{Transport: TransportHTTP, RemoteAddr: "10.0.0.1:5001", Principal: &Principal{Subject: "bob"}}
+	anonymous := Caller{Transport: TransportHTTP, RemoteAddr: "10.0.0.2:6000"}
+
+	type step struct {
+		advance            time.Duration
+		caller             Caller
+		method             string
+		expectedRetryAfter int // 0 when the call is admitted
+	}
+	tests := []struct {
+		name  string
+		opts  []LimiterOpts
+		steps []step
+	}{
+		{
+			name: "tool_limit_refills_over_time",
+			opts: []LimiterOpts{WithToolLimit("Search", "Query", Limit{Rate: 0.5, Burst: 2})},
+			steps: []step{
+				{caller: alice, method: "Query"},
+				{caller: bob, method: "Query"},
+				{caller: alice, method: "Query", expectedRetryAfter: 2},
+				{caller: alice, method: "Other"},
+				{advance: time.Second, caller: alice, method: "Query", expectedRetryAfter: 1},
+				{advance: time.Second, caller: alice, method: "Query"},
+			},
+		},
+		{
+			name: "service_limit_is_shared_by_methods",
+			opts: []LimiterOpts{WithServiceLimit("Search", Limit{Rate: 1})},
+			steps: []step{
+				{caller: alice, method: "Query"},
+				{caller: alice, method: "Other", expectedRetryAfter: 1},
+			},
+		},
+		{
+			name: "caller_limit_is_per_principal_or_address",
+			opts: []LimiterOpts{WithCallerLimit(Limit{Rate: 1})},
+			steps: []step{
+				{caller: alice, method: "Query"},
+				{caller: alice, method: "Query", expectedRetryAfter: 1},
+				{caller: bob, method: "Query"},
+				{caller: anonymous, method: "Query"},
+				{caller: Caller{Transport: TransportHTTP, RemoteAddr: "10.0.0.2:6001"}, method: "Query", expectedRetryAfter: 1},
+				{caller: Caller{Transport: TransportDirect}, method: "Query"},
+			},
+		},
+		{
+			name: "rejected_calls_consume_no_tokens",
+			opts: []LimiterOpts{
+				WithToolLimit("Search", "Query", Limit{Rate: 1, Burst: 2}),
+				WithCallerLimit(Limit{Rate: 1}),
+			},
+			steps: []step{
+				{caller: alice, method: "Query"},
+				{caller: alice, method: "Query", expectedRetryAfter: 1},
+				{caller: bob, method: "Query"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(1700000000, 0)}
+			limiter := NewLimiter(func(l *Limiter) { l.now = clock.Now })
+			for _, opt := range tt.opts {
+				opt(limiter)
+			}
+			interceptors := NewInterceptors()
+			interceptors.Use(limiter.Interceptor())
+
+			for i, s := range tt.steps {
+				clock.now = clock.now.Add(s.advance)
+				call := &ToolCall{ServiceName: "Search", MethodName: s.method, Caller: s.caller}
+				_, err := interceptors.Execute(context.Background(), paramsExecutor{}, call)
+
+				if s.expectedRetryAfter == 0 {
+					if err != nil {
+						t.Fatalf("step %d: expected call to be admitted, got %v", i, err)
+					}
+					continue
+				}
+				rpcErr, ok := jsonrpc.AsError(err)
+				if !ok || rpcErr.Code != int(jsonrpc.ErrorCodeRateLimited) {
+					t.Fatalf("step %d: expected rate limit error, got %v", i, err)
+				}
+				if got := rpcErr.Data.(map[string]any)["retryAfter"]; got != s.expectedRetryAfter {
+					t.Errorf("step %d: expected retryAfter %d, got %v", i, s.expectedRetryAfter, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_MaxInFlight(t *testing.T) {
+	limiter := NewLimiter(WithToolLimit("Search", "Query", Limit{MaxInFlight: 1}))
+	interceptors := NewInterceptors()
+	interceptors.Use(limiter.Interceptor())
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	call := &ToolCall{ServiceName: "Search", MethodName: "Query"}
+	go func() {
+		_, err := interceptors.Invoke(context.Background(), call, func(ctx context.Context, call *ToolCall) (any, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	_, err := interceptors.Execute(context.Background(), paramsExecutor{}, call)
+	if rpcErr, ok := jsonrpc.AsError(err); !ok || rpcErr.Code != int(jsonrpc.ErrorCodeRateLimited) {
+		t.Errorf("expected concurrent call to be rejected, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := interceptors.Execute(context.Background(), paramsExecutor{}, call); err != nil {
+		t.Errorf("expected call after release to be admitted, got %v", err)
+	}
+}
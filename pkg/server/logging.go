@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LoggerOpts defines options for configuring a logger
+type LoggerOpts func(*Logger)
+
+// Logger writes the structured events of the server and its components to a slog.Logger.
+// Events carry the request ID and the caller's subject found in their context.
+// Routine events such as registrations, requests and calls are logged at the configured
+// level, failures at warn and panics at error. A nil *Logger discards all events.
+// The logger options of the server and its components take a *slog.Logger and wrap it;
+// the server hands the wrapped logger to its components through their SetLogger method.
+type Logger struct {
+	logger   *slog.Logger
+	level    slog.Level
+	payloads bool
+}
+
+// NewLogger creates a logger writing to logger. Routine events are logged at info level
+// and without params or results unless opts say otherwise.
+func NewLogger(logger *slog.Logger, opts ...LoggerOpts) *Logger {
+	l := &Logger{logger: logger, level: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithLogLevel sets the level of routine events, e.g. slog.LevelDebug to keep them out of
+// production logs that are filtered at info
+func WithLogLevel(level slog.Level) LoggerOpts {
+	return func(l *Logger) {
+		l.level = level
+	}
+}
+
+// WithPayloads adds the params and results of calls to call events. Payloads may contain
+// sensitive data and make events large, so they are not logged by default.
+func WithPayloads() LoggerOpts {
+	return func(l *Logger) {
+		l.payloads = true
+	}
+}
+
+// Payloads reports whether params and results are logged
+func (l *Logger) Payloads() bool {
+	return l != nil && l.payloads
+}
+
+// Event logs a routine event at the configured level
+func (l *Logger) Event(ctx context.Context, msg string, attrs ...slog.Attr) {
+	if l != nil {
+		l.log(ctx, l.level, msg, attrs)
+	}
+}
+
+// Warn logs a failure
+func (l *Logger) Warn(ctx context.Context, msg string, attrs ...slog.Attr) {
+	if l != nil {
+		l.log(ctx, slog.LevelWarn, msg, attrs)
+	}
+}
+
+// Error logs a failure that needs attention, such as a panic
+func (l *Logger) Error(ctx context.Context, msg string, attrs ...slog.Attr) {
+	if l != nil {
+		l.log(ctx, slog.LevelError, msg, attrs)
+	}
+}
+
+// ErrorHandler returns a function logging the errors passed to it as failures with msg,
+// for components that report background failures through a func(error)
+func (l *Logger) ErrorHandler(msg string) func(error) {
+	return func(err error) {
+		l.Warn(context.Background(), msg, slog.String("error", err.Error()))
+	}
+}
+
+// log writes an event with the request ID and caller of ctx
+func (l *Logger) log(ctx context.Context, level slog.Level, msg string, attrs []slog.Attr) {
+	if l.logger == nil || !l.logger.Enabled(ctx, level) {
+		return
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("subject", principal.Subject))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// requestIDKey is the context key for the ID of the current request
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the ID of the current request,
+// used by transports so that every event of a request can be correlated
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID of the current request, or "" if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// loggerSetter is implemented by components that accept the server's logger
+type loggerSetter interface {
+	SetLogger(logger *Logger)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+// decodeEvents parses the JSON lines written by a slog.JSONHandler, dropping the time
+func decodeEvents(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var event map[string]any
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatalf("invalid log line: %v", err)
+		}
+		delete(event, "time")
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestLogger(t *testing.T) {
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ctx = ContextWithPrincipal(ctx, &Principal{Subject: "alice"})
+
+	tests := []struct {
+		name     string
+		opts     []LoggerOpts
+		handler  slog.Level
+		log      func(l *Logger)
+		expected []map[string]any
+	}{
+		{
+			name:    "routine_event_at_info",
+			handler: slog.LevelInfo,
+			log:     func(l *Logger) { l.Event(ctx, "tool registered", slog.String("tool", "Notes.Add")) },
+			expected: []map[string]any{
+				{"level": "INFO", "msg": "tool registered", "tool": "Notes.Add", "request_id": "req-1", "subject": "alice"},
+			},
+		},
+		{
+			name:     "routine_event_below_handler_level",
+			opts:     []LoggerOpts{WithLogLevel(slog.LevelDebug)},
+			handler:  slog.LevelInfo,
+			log:      func(l *Logger) { l.Event(ctx, "tool registered") },
+			expected: nil,
+		},
+		{
+			name:    "failures_ignore_routine_level",
+			opts:    []LoggerOpts{WithLogLevel(slog.LevelDebug)},
+			handler: slog.LevelInfo,
+			log: func(l *Logger) {
+				l.Warn(context.Background(), "call failed")
+				l.Error(context.Background(), "call panicked")
+			},
+			expected: []map[string]any{
+				{"level": "WARN", "msg": "call failed"},
+				{"level": "ERROR", "msg": "call panicked"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: tt.handler})), tt.opts...)
+			tt.log(logger)
+
+			if got := decodeEvents(t, &buf); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected events %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLogger_Nil(t *testing.T) {
+	var logger *Logger
+	logger.Event(context.Background(), "ignored")
+	logger.Warn(context.Background(), "ignored")
+	logger.Error(context.Background(), "ignored")
+	if logger.Payloads() {
+		t.Error("expected a nil logger not to log payloads")
+	}
+}
+
+// loggedComponent records the logger it is given
+type loggedComponent struct {
+	MethodExecutor
+	logger *Logger
+}
+
+func (c *loggedComponent) SetLogger(logger *Logger) {
+	c.logger = logger
+}
+
+func TestServer_SetLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		install func(executor *loggedComponent) *Server
+	}{
+		{
+			name: "option",
+			install: func(executor *loggedComponent) *Server {
+				return NewServer(WithMethodExecutor(executor), WithLogger(slog.Default(), WithPayloads()))
+			},
+		},
+		{
+			name: "setter",
+			install: func(executor *loggedComponent) *Server {
+				s := NewServer(WithMethodExecutor(executor))
+				s.SetLogger(slog.Default(), WithPayloads())
+				return s
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &loggedComponent{}
+			s := tt.install(executor)
+
+			if executor.logger == nil || executor.logger != s.logger {
+				t.Errorf("expected the executor to receive the server's logger")
+			}
+			if !executor.logger.Payloads() {
+				t.Errorf("expected payloads to be logged")
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+)
+
+// ErrClientClosed is returned for calls on a client whose connection has ended
+var ErrClientClosed = errors.New("mcp: client closed")
+
+// Client talks to a remote MCP server over a newline-delimited JSON-RPC stream,
+// such as the stdio of a subprocess. It is safe for concurrent use.
+type Client struct {
+	encoder *json.Encoder
+	closer  func() error
+	info    Implementation
+
+	writeMutex sync.Mutex
+	mutex      sync.Mutex
+	nextID     int64
+	pending    map[string]chan *message
+	err        error
+}
+
+// ClientOpts defines options for configuring an MCP client
+type ClientOpts func(*Client)
+
+// WithClientInfo sets the name and version reported to the server during initialization
+func WithClientInfo(name, version string) ClientOpts {
+	return func(c *Client) {
+		c.info = Implementation{Name: name, Version: version}
+	}
+}
+
+// NewClient creates a client that reads messages from r and writes messages to w.
+// closer, if not nil, is called by Close to release the connection.
+func NewClient(r io.Reader, w io.Writer, closer func() error, opts ...ClientOpts) *Client {
+	c := &Client{
+		encoder: json.NewEncoder(w),
+		closer:  closer,
+		info:    Implementation{Name: "agent-sdk", Version: "0.1.0"},
+		pending: make(map[string]chan *message),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	go c.readLoop(r)
+	return c
+}
+
+// NewCommandClient starts cmd and connects to the MCP server on its stdin and stdout.
+// Close closes the server's stdin and waits for the process to exit.
+func NewCommandClient(cmd *exec.Cmd, opts ...ClientOpts) (*Client, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start MCP server: %w", err)
+	}
+
+	var (
+		once    sync.Once
+		waitErr error
+	)
+	closer := func() error {
+		once.Do(func() {
+			stdin.Close()
+			waitErr = cmd.Wait()
+		})
+		return waitErr
+	}
+	return NewClient(stdout, stdin, closer, opts...), nil
+}
+
+// NewInProcessClient connects to an MCPTransport running in the same process over the stdio
+// transport. It is a stand-in for a subprocess, e.g. in tests.
+func NewInProcessClient(transport *MCPTransport, opts ...ClientOpts) *Client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	served := make(chan error, 1)
+	go func() {
+		err := transport.ServeStream(context.Background(), serverIn, serverOut)
+		serverOut.Close()
+		served <- err
+	}()
+
+	var (
+		once     sync.Once
+		serveErr error
+	)
+	closer := func() error {
+		once.Do(func() {
+			clientOut.Close()
+			serveErr = <-served
+		})
+		return serveErr
+	}
+	return NewClient(clientIn, clientOut, closer, opts...)
+}
+
+// Initialize performs the MCP handshake and returns the server's capabilities
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	params := InitializeParams{
+		ProtocolVersion: LatestProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      c.info,
+	}
+
+	var result InitializeResult
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return nil, err
+	}
+	if !isSupportedVersion(result.ProtocolVersion) {
+		return nil, fmt.Errorf("server selected unsupported protocol version %q", result.ProtocolVersion)
+	}
+
+	if err := c.notify("notifications/initialized", nil); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListTools returns every tool of the server, following pagination cursors
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		var result ListToolsResult
+		if err := c.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// CallTool calls a tool of the server. Tool failures are reported in the result's IsError;
+// the returned error is set for protocol and connection failures only.
+// The trace context of ctx, if any, is sent in the request's _meta.
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*CallToolResult, error) {
+	params := CallToolParams{Name: name, Arguments: arguments, Meta: metaTrace(ctx)}
+
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close ends the connection. Pending calls fail with ErrClientClosed.
+// Calling Close more than once returns the result of the first call.
+func (c *Client) Close() error {
+	c.fail(ErrClientClosed)
+	if c.closer != nil {
+		return c.closer()
+	}
+	return nil
+}
+
+// call sends a request and decodes its result into result.
+// If ctx is done first, the server is told to cancel the request.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	c.mutex.Unlock()
+
+	request := message{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method, Params: encodedParams}
+	if err := c.write(request); err != nil {
+		c.removePending(id)
+		return err
+	}
+
+	select {
+	case msg := <-ch:
+		if msg == nil {
+			return c.closedErr()
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.removePending(id)
+		c.notify("notifications/cancelled", map[string]any{
+			"requestId": json.RawMessage(id),
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification
+func (c *Client) notify(method string, params any) error {
+	msg := message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode params: %w", err)
+		}
+		msg.Params = encoded
+	}
+	return c.write(msg)
+}
+
+// write sends one message on its own line
+func (c *Client) write(msg message) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if err := c.encoder.Encode(msg); err != nil {
+		return fmt.Errorf("failed to send %s: %w", msg.Method, err)
+	}
+	return nil
+}
+
+// readLoop delivers responses to pending calls until the stream ends
+func (c *Client) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		if msg.Method != "" {
+			c.handleServerMessage(msg)
+			continue
+		}
+
+		c.mutex.Lock()
+		ch, ok := c.pending[string(msg.ID)]
+		delete(c.pending, string(msg.ID))
+		c.mutex.Unlock()
+		if ok {
+			ch <- &msg
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	c.fail(fmt.Errorf("%w: %w", ErrClientClosed, err))
+}
+
+// handleServerMessage answers requests sent by the server. Only ping is supported;
+// notifications such as progress and log messages are ignored.
+func (c *Client) handleServerMessage(msg message) {
+	if msg.ID == nil {
+		return
+	}
+
+	reply := response{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		reply.Result = map[string]any{}
+	} else {
+		reply.Error = jsonrpc.NewError(jsonrpc.ErrorCodeMethodNotFound, jsonrpc.ErrorCodeMethodNotFound.Message(), msg.Method)
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	c.encoder.Encode(reply)
+}
+
+// fail ends all pending calls with err. Only the first error is kept.
+func (c *Client) fail(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+// closedErr returns the error that ended the connection
+func (c *Client) closedErr() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// removePending forgets a call that will not wait for its response
+func (c *Client) removePending(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.pending, id)
+}
@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
+)
+
+// LatestProtocolVersion is the newest MCP revision implemented by this package
+const LatestProtocolVersion = "2025-06-18"
+
+// supportedProtocolVersions lists the MCP revisions accepted during initialization, newest first
+var supportedProtocolVersions = []string{LatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// nullID is the id sent with errors for messages whose id could not be read
+var nullID = json.RawMessage("null")
+
+// message is a JSON-RPC 2.0 request, notification or response as exchanged by MCP peers.
+// A missing ID marks a notification; a null ID is kept as the literal "null".
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc.Error  `json:"error,omitempty"`
+}
+
+// response is an outgoing JSON-RPC 2.0 response
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *jsonrpc.Error  `json:"error,omitempty"`
+}
+
+// Implementation identifies an MCP client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// InitializeParams are sent by the client to open a session
+type InitializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+// InitializeResult is the server's answer to initialize
+type InitializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// Tool is an MCP tool definition
+type Tool struct {
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description,omitempty"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+}
+
+// ListToolsResult is the result of tools/list
+type ListToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// CallToolParams are the params of tools/call.
+// Meta carries request metadata such as the W3C trace context ("traceparent", "tracestate").
+type CallToolParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      map[string]any         `json:"_meta,omitempty"`
+}
+
+// Content is a content block of a tool result. Only text content is produced by this package;
+// Data and MimeType carry the image and audio content of remote tools.
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// CallToolResult is the result of tools/call.
+// Failures of the tool itself are reported with IsError rather than as a JSON-RPC error.
+type CallToolResult struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// negotiateVersion returns the protocol version to use for a client's requested version
+func negotiateVersion(requested string) string {
+	if isSupportedVersion(requested) {
+		return requested
+	}
+	return LatestProtocolVersion
+}
+
+// isSupportedVersion reports whether version is an MCP revision implemented by this package
+func isSupportedVersion(version string) bool {
+	for _, supported := range supportedProtocolVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// metaTrace returns the _meta members carrying the trace context of ctx, or nil if it has none
+func metaTrace(ctx context.Context) map[string]any {
+	sc, ok := tracing.SpanContextFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	meta := map[string]any{tracing.TraceparentHeader: sc.Traceparent()}
+	if sc.TraceState != "" {
+		meta[tracing.TracestateHeader] = sc.TraceState
+	}
+	return meta
+}
+
+// contextWithMetaTrace returns a copy of ctx carrying the trace context of a request's _meta.
+// ctx is returned unchanged if _meta has no valid traceparent.
+func contextWithMetaTrace(ctx context.Context, meta map[string]any) context.Context {
+	traceparent, _ := meta[tracing.TraceparentHeader].(string)
+	sc, err := tracing.ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState, _ = meta[tracing.TracestateHeader].(string)
+	return tracing.ContextWithSpanContext(ctx, sc)
+}
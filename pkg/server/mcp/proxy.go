@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/tools"
+)
+
+// MethodRegistry publishes tool descriptions, e.g. a *tools.ToolService
+type MethodRegistry interface {
+	RegisterMethod(serviceName, methodName, description string, parameters map[string]interface{}) error
+}
+
+// HandlerRegistry routes calls of a service to a handler, e.g. a *tools.JSONRPCMethodExecutor
+type HandlerRegistry interface {
+	RegisterServiceHandler(serviceName string, handler tools.ServiceHandler, opts ...tools.ServiceOpts) error
+}
+
+// Proxy re-exports the tools of a remote MCP server as methods of a local service.
+// Remote tools are published under the namespace as "Namespace.tool_name" and calls
+// to them are forwarded to the remote server with the client.
+type Proxy struct {
+	client    *Client
+	namespace string
+
+	mutex sync.RWMutex
+	tools map[string]string // Key: local method name, value: remote tool name
+}
+
+// NewProxy creates a proxy publishing the tools reachable through client under namespace.
+// The namespace is used as the service name, so it must not contain ".".
+func NewProxy(client *Client, namespace string) *Proxy {
+	return &Proxy{
+		client:    client,
+		namespace: namespace,
+		tools:     make(map[string]string),
+	}
+}
+
+// Register initializes the connection, discovers the remote tools and registers them.
+// Descriptions are published to registry and calls are routed to the proxy by executor;
+// opts such as tools.WithTimeout bound forwarded calls. If a remote tool has an input
+// schema that RegisterMethod would reject, nothing is registered.
+func (p *Proxy) Register(ctx context.Context, registry MethodRegistry, executor HandlerRegistry, opts ...tools.ServiceOpts) error {
+	if p.namespace == "" || strings.Contains(p.namespace, ".") {
+		return fmt.Errorf("invalid namespace '%s'", p.namespace)
+	}
+
+	if _, err := p.client.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize MCP connection: %w", err)
+	}
+	remoteTools, err := p.client.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list remote tools: %w", err)
+	}
+
+	// Every tool is checked before anything is registered, and the handler comes last, so
+	// that no handler is left behind for tools that could not be described
+	methods := make(map[string]Tool, len(remoteTools))
+	for _, tool := range remoteTools {
+		methodName := localMethodName(tool.Name)
+		if existing, ok := methods[methodName]; ok {
+			return fmt.Errorf("remote tools '%s' and '%s' both map to method '%s'", existing.Name, tool.Name, methodName)
+		}
+		if err := tools.CheckParameters(tool.InputSchema); err != nil {
+			return fmt.Errorf("invalid input schema of remote tool '%s': %w", tool.Name, err)
+		}
+		methods[methodName] = tool
+	}
+
+	names := make([]string, 0, len(methods))
+	for methodName := range methods {
+		names = append(names, methodName)
+	}
+	sort.Strings(names)
+
+	for _, methodName := range names {
+		tool := methods[methodName]
+		if err := registry.RegisterMethod(p.namespace, methodName, tool.Description, tool.InputSchema); err != nil {
+			return fmt.Errorf("failed to register tool '%s': %w", tool.Name, err)
+		}
+	}
+
+	p.mutex.Lock()
+	for methodName, tool := range methods {
+		p.tools[methodName] = tool.Name
+	}
+	p.mutex.Unlock()
+
+	if err := executor.RegisterServiceHandler(p.namespace, p, opts...); err != nil {
+		p.mutex.Lock()
+		for methodName := range methods {
+			delete(p.tools, methodName)
+		}
+		p.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Tools returns the registered method names and the remote tools they forward to
+func (p *Proxy) Tools() map[string]string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	tools := make(map[string]string, len(p.tools))
+	for methodName, toolName := range p.tools {
+		tools[methodName] = toolName
+	}
+	return tools
+}
+
+// ExecuteMethodContext forwards a call to the remote tool registered as methodName.
+// Structured content is returned when the tool provides it, otherwise the text of a
+// single text block, parsed as JSON when possible, or the content blocks themselves.
+// Tool errors are returned as errors carrying the tool's text.
+func (p *Proxy) ExecuteMethodContext(ctx context.Context, methodName string, params map[string]interface{}) (interface{}, error) {
+	p.mutex.RLock()
+	toolName, ok := p.tools[methodName]
+	p.mutex.RUnlock()
+	if !ok {
+		return nil, server.ClassifyError(server.ErrMethodNotFound, fmt.Errorf("method '%s' not found in service '%s'", methodName, p.namespace))
+	}
+
+	result, err := p.client.CallTool(ctx, toolName, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call remote tool '%s': %w", toolName, err)
+	}
+
+	if result.IsError {
+		return nil, errors.New(contentText(result.Content))
+	}
+	if result.StructuredContent != nil {
+		return result.StructuredContent, nil
+	}
+	if len(result.Content) == 1 && result.Content[0].Type == "text" {
+		var decoded interface{}
+		if json.Unmarshal([]byte(result.Content[0].Text), &decoded) == nil {
+			return decoded, nil
+		}
+		return result.Content[0].Text, nil
+	}
+	return result.Content, nil
+}
+
+// localMethodName maps a remote tool name to a method name that fits "Service.Method"
+func localMethodName(toolName string) string {
+	return strings.ReplaceAll(toolName, ".", "_")
+}
+
+// contentText joins the text blocks of a tool result
+func contentText(content []Content) string {
+	var texts []string
+	for _, block := range content {
+		if block.Type == "text" && block.Text != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return "remote tool failed"
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,244 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/tools"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
+)
+
+// routingExecutor answers calls of the remote test server by method name
+type routingExecutor map[string]func(params map[string]interface{}) (interface{}, error)
+
+func (r routingExecutor) ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	handler, ok := r[serviceName+"."+methodName]
+	if !ok {
+		return nil, fmt.Errorf("no handler for %s.%s", serviceName, methodName)
+	}
+	return handler(params)
+}
+
+// newRemoteClient starts an in-process MCP server with a few tools and connects to it
+func newRemoteClient(t *testing.T) *Client {
+	t.Helper()
+
+	remoteTools := tools.NewToolService()
+	remoteTools.RegisterMethod("Files", "read", "Reads a file", map[string]interface{}{
+		"path": map[string]interface{}{"type": "string", "required": true},
+	})
+	remoteTools.RegisterMethod("Files", "fail", "Always fails", nil)
+	remoteTools.RegisterMethodLLM("Clock.now", "Returns the time as text")
+
+	executor := routingExecutor{
+		"Files.read": func(params map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{"content": "contents of " + params["path"].(string)}, nil
+		},
+		"Files.fail": func(params map[string]interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("permission denied")
+		},
+		"Clock.now": func(params map[string]interface{}) (interface{}, error) {
+			return "noon", nil
+		},
+	}
+
+	client := NewInProcessClient(NewMCPTransport(remoteTools, executor))
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestProxy_Register(t *testing.T) {
+	client := newRemoteClient(t)
+	localTools := tools.NewToolService()
+	localExecutor := tools.NewJSONRPCMethodExecutor(jsonrpc.NewServer())
+
+	proxy := NewProxy(client, "Legacy")
+	if err := proxy.Register(context.Background(), localTools, localExecutor); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+
+	expectedTools := map[string]string{
+		"Files_read": "Files.read",
+		"Files_fail": "Files.fail",
+		"Clock_now":  "Clock.now",
+	}
+	if !reflect.DeepEqual(proxy.Tools(), expectedTools) {
+		t.Errorf("expected tools %v, got %v", expectedTools, proxy.Tools())
+	}
+
+	registry := localTools.GetMethodRegistry()
+	read, ok := registry["Legacy.Files_read"]
+	if !ok {
+		t.Fatalf("expected Legacy.Files_read to be registered, got %v", registry)
+	}
+	if read.Description != "Reads a file" {
+		t.Errorf("unexpected description %q", read.Description)
+	}
+	if read.Parameters["type"] != "object" {
+		t.Errorf("expected input schema as parameters, got %v", read.Parameters)
+	}
+}
+
+func TestProxy_ExecuteMethodContext(t *testing.T) {
+	client := newRemoteClient(t)
+	localTools := tools.NewToolService()
+	localExecutor := tools.NewJSONRPCMethodExecutor(jsonrpc.NewServer())
+
+	proxy := NewProxy(client, "Legacy")
+	if err := proxy.Register(context.Background(), localTools, localExecutor); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		params        map[string]interface{}
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name:     "structured_result",
+			method:   "Files_read",
+			params:   map[string]interface{}{"path": "/etc/motd"},
+			expected: map[string]interface{}{"content": "contents of /etc/motd"},
+		},
+		{
+			name:     "text_result_is_decoded",
+			method:   "Clock_now",
+			params:   map[string]interface{}{},
+			expected: "noon",
+		},
+		{
+			name:          "tool_error",
+			method:        "Files_fail",
+			params:        map[string]interface{}{},
+			expectedError: "permission denied",
+		},
+		{
+			name:          "remote_validation_error",
+			method:        "Files_read",
+			params:        map[string]interface{}{},
+			expectedError: "path: is required",
+		},
+		{
+			name:          "unknown_method",
+			method:        "Files_write",
+			params:        map[string]interface{}{},
+			expectedError: "method 'Files_write' not found in service 'Legacy'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := localExecutor.ExecuteMethodContext(context.Background(), "Legacy", tt.method, tt.params)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestProxy_RegisterInvalidNamespace(t *testing.T) {
+	proxy := NewProxy(newRemoteClient(t), "Legacy.Tools")
+
+	err := proxy.Register(context.Background(), tools.NewToolService(), tools.NewJSONRPCMethodExecutor(jsonrpc.NewServer()))
+	if err == nil {
+		t.Fatal("expected error for namespace containing '.'")
+	}
+}
+
+// staticLister publishes a fixed set of tools
+type staticLister map[string]tools.ToolInfo
+
+func (l staticLister) GetMethodRegistry() map[string]tools.ToolInfo {
+	return l
+}
+
+func TestProxy_RegisterInvalidTool(t *testing.T) {
+	remote := staticLister{
+		"Files.read": {Name: "Files.read", Parameters: map[string]interface{}{"path": "string"}},
+		"Files.stat": {Name: "Files.stat", Parameters: map[string]interface{}{"path": "unsigned int"}},
+	}
+	client := NewInProcessClient(NewMCPTransport(remote, routingExecutor{}))
+	t.Cleanup(func() { client.Close() })
+	localTools := tools.NewToolService()
+	localExecutor := tools.NewJSONRPCMethodExecutor(jsonrpc.NewServer())
+
+	proxy := NewProxy(client, "Legacy")
+	err := proxy.Register(context.Background(), localTools, localExecutor)
+	if err == nil || !strings.Contains(err.Error(), "Files.stat") {
+		t.Fatalf("expected error naming the invalid tool, got %v", err)
+	}
+
+	// Nothing is registered, not even the valid tool
+	if registry := localTools.GetMethodRegistry(); len(registry) != 0 {
+		t.Errorf("expected no registered tools, got %v", registry)
+	}
+	if len(proxy.Tools()) != 0 {
+		t.Errorf("expected no proxied tools, got %v", proxy.Tools())
+	}
+	if _, err := localExecutor.ExecuteMethod("Legacy", "Files_read", map[string]interface{}{"path": "a"}); !errors.Is(err, server.ErrServiceNotFound) {
+		t.Errorf("expected no handler for the namespace, got %v", err)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	client := newRemoteClient(t)
+	if _, err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize returned error: %v", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if _, err := client.ListTools(context.Background()); err != ErrClientClosed {
+		t.Errorf("expected ErrClientClosed after Close, got %v", err)
+	}
+}
+
+func TestClient_CallToolPropagatesTraceContext(t *testing.T) {
+	remoteTools := tools.NewToolService()
+	remoteTools.RegisterMethod("Files", "read", "Reads a file", nil)
+
+	got := ""
+	interceptors := server.NewInterceptors()
+	interceptors.Use(func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		if sc, ok := tracing.SpanContextFromContext(ctx); ok {
+			got = sc.Traceparent() + " " + sc.TraceState
+		}
+		return next(ctx, call)
+	})
+	executor := routingExecutor{
+		"Files.read": func(params map[string]interface{}) (interface{}, error) { return "contents", nil },
+	}
+	client := NewInProcessClient(NewMCPTransport(remoteTools, executor, WithInterceptors(interceptors)))
+	defer client.Close()
+
+	sc, _ := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	sc.TraceState = "rojo=00f067aa0ba902b7"
+	ctx := tracing.ContextWithSpanContext(context.Background(), sc)
+	if _, err := client.CallTool(ctx, "Files.read", nil); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 rojo=00f067aa0ba902b7"
+	if got != expected {
+		t.Errorf("expected trace context %q on the server, got %q", expected, got)
+	}
+}
@@ -0,0 +1,476 @@
+// Package mcp exposes registered tools as a Model Context Protocol (MCP) server.
+// Tool definitions come from the same registry that backs /tools and calls are run by the
+// same MethodExecutor that backs /execute, so services only need to be registered once.
+// Both the stdio and the streamable HTTP transports of MCP are supported.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/tools"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
+)
+
+// maxMessageSize bounds a single stdio message
+const maxMessageSize = 16 << 20
+
+// ToolLister provides the tools published over MCP, e.g. a *tools.ToolService.
+// If it also implements tools.SchemaProvider, call arguments are validated before dispatch.
+type ToolLister interface {
+	GetMethodRegistry() map[string]tools.ToolInfo
+}
+
+// AuthorizedLister is implemented by tool listers that restrict tools to certain callers,
+// e.g. a *tools.ToolService with policies. tools/list then only returns the tools the
+// caller may use.
+type AuthorizedLister interface {
+	AuthorizedTools(principal *server.Principal) map[string]tools.ToolInfo
+}
+
+// MCPTransportOpts defines options for configuring the MCP transport
+type MCPTransportOpts func(*MCPTransport)
+
+// MCPTransport serves a tool registry and method executor as an MCP server.
+// It implements the server.Transport interface with the streamable HTTP transport.
+type MCPTransport struct {
+	tools          ToolLister
+	executor       server.MethodExecutor
+	info           Implementation
+	instructions   string
+	path           string
+	allowedOrigins map[string]bool
+	interceptors   *server.Interceptors
+
+	mutex   sync.Mutex
+	httpSrv *http.Server
+}
+
+// NewMCPTransport creates a new MCP transport and applies the given options
+func NewMCPTransport(toolLister ToolLister, executor server.MethodExecutor, opts ...MCPTransportOpts) *MCPTransport {
+	t := &MCPTransport{
+		tools:    toolLister,
+		executor: executor,
+		info:     Implementation{Name: "agent-sdk", Version: "0.1.0"},
+		path:     "/mcp",
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// WithServerInfo sets the name and version reported to clients during initialization
+func WithServerInfo(name, version string) MCPTransportOpts {
+	return func(t *MCPTransport) {
+		t.info = Implementation{Name: name, Version: version}
+	}
+}
+
+// WithInstructions sets the usage instructions returned to clients during initialization
+func WithInstructions(instructions string) MCPTransportOpts {
+	return func(t *MCPTransport) {
+		t.instructions = instructions
+	}
+}
+
+// WithPath sets the path of the streamable HTTP endpoint. The default is "/mcp".
+func WithPath(path string) MCPTransportOpts {
+	return func(t *MCPTransport) {
+		t.path = path
+	}
+}
+
+// WithAllowedOrigins sets the browser origins allowed to call the HTTP endpoint.
+// By default only requests without an Origin header or from the server's own host are accepted,
+// which protects local servers against DNS rebinding.
+func WithAllowedOrigins(origins ...string) MCPTransportOpts {
+	return func(t *MCPTransport) {
+		t.allowedOrigins = make(map[string]bool, len(origins))
+		for _, origin := range origins {
+			t.allowedOrigins[origin] = true
+		}
+	}
+}
+
+// WithInterceptors runs tool calls through the given interceptor chain,
+// e.g. the one returned by (*server.Server).GetInterceptors
+func WithInterceptors(interceptors *server.Interceptors) MCPTransportOpts {
+	return func(t *MCPTransport) {
+		t.interceptors = interceptors
+	}
+}
+
+// ListenAndServe serves the streamable HTTP transport on addr
+func (t *MCPTransport) ListenAndServe(addr string) error {
+	if addr == "" {
+		addr = ":http"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return t.Serve(listener)
+}
+
+// Serve serves the streamable HTTP transport on listener until Shutdown is called.
+// After Shutdown it returns http.ErrServerClosed.
+func (t *MCPTransport) Serve(listener net.Listener) error {
+	return t.server().Serve(listener)
+}
+
+// Shutdown stops the HTTP server, waiting for in-flight tool calls until ctx is done
+func (t *MCPTransport) Shutdown(ctx context.Context) error {
+	return t.server().Shutdown(ctx)
+}
+
+// server returns the underlying http.Server, creating it on first use
+func (t *MCPTransport) server() *http.Server {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.httpSrv == nil {
+		t.httpSrv = &http.Server{Handler: t.HTTPHandler()}
+	}
+	return t.httpSrv
+}
+
+// HTTPHandler returns a handler that serves the streamable HTTP endpoint at the configured path
+func (t *MCPTransport) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(t.path, t)
+	return mux
+}
+
+// ServeHTTP handles one message of the streamable HTTP transport.
+// Requests are answered with a single JSON response; notifications are acknowledged
+// with 202 Accepted. The server keeps no sessions and does not offer an SSE stream.
+func (t *MCPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !t.allowedOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if version := r.Header.Get("MCP-Protocol-Version"); version != "" && !isSupportedVersion(version) {
+		http.Error(w, fmt.Sprintf("unsupported protocol version %q", version), http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	caller := server.Caller{Transport: server.TransportMCP, RemoteAddr: r.RemoteAddr}
+	caller.Principal, _ = server.PrincipalFromContext(r.Context())
+	resp := t.handleMessage(tracing.Extract(r.Context(), r.Header), caller, body)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	status := http.StatusOK
+	if resp.Error != nil && resp.Error.Code == int(jsonrpc.ErrorCodeParseError) {
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// allowedOrigin reports whether the request's Origin header may access the endpoint
+func (t *MCPTransport) allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if t.allowedOrigins != nil {
+		return t.allowedOrigins[origin]
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+var _ server.Transport = (*MCPTransport)(nil)
+
+// ServeStdio serves the stdio transport on the process's standard input and output
+func (t *MCPTransport) ServeStdio(ctx context.Context) error {
+	return t.ServeStream(ctx, os.Stdin, os.Stdout)
+}
+
+// ServeStream reads newline-delimited JSON-RPC messages from r and writes one response per line to w.
+// Requests are handled concurrently, so responses may arrive out of order. ServeStream returns once r
+// is exhausted and all in-flight requests have been answered; ctx is passed to every call.
+func (t *MCPTransport) ServeStream(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	var (
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+		writeErr error
+	)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		data := append([]byte(nil), line...)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := t.handleMessage(ctx, server.Caller{Transport: server.TransportMCP}, data)
+			if resp == nil {
+				return
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err := encoder.Encode(resp); err != nil && writeErr == nil {
+				writeErr = fmt.Errorf("failed to write response: %w", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+	return writeErr
+}
+
+// handleMessage processes one JSON-RPC message and returns the response to send, or nil
+// for notifications and for responses sent by the client
+func (t *MCPTransport) handleMessage(ctx context.Context, caller server.Caller, data []byte) *response {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return errorResponse(nullID, jsonrpc.NewError(jsonrpc.ErrorCodeParseError, jsonrpc.ErrorCodeParseError.Message(), err.Error()))
+	}
+
+	id := msg.ID
+	if id == nil {
+		id = nullID
+	}
+
+	if msg.Method == "" {
+		// This server never sends requests, so responses from the client need no answer
+		if msg.ID != nil && (msg.Result != nil || msg.Error != nil) {
+			return nil
+		}
+		return errorResponse(id, jsonrpc.NewError(jsonrpc.ErrorCodeInvalidRequest, jsonrpc.ErrorCodeInvalidRequest.Message(), "method is required"))
+	}
+	if msg.JSONRPC != "2.0" {
+		return errorResponse(id, jsonrpc.NewError(jsonrpc.ErrorCodeInvalidRequest, jsonrpc.ErrorCodeInvalidRequest.Message(), "jsonrpc field must be '2.0'"))
+	}
+
+	result, rpcErr := t.dispatch(ctx, caller, msg.Method, msg.Params)
+	if msg.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return errorResponse(id, rpcErr)
+	}
+	return &response{JSONRPC: "2.0", ID: id, Result: result}
+}
+
+// dispatch runs an MCP method
+func (t *MCPTransport) dispatch(ctx context.Context, caller server.Caller, method string, params json.RawMessage) (any, *jsonrpc.Error) {
+	switch method {
+	case "initialize":
+		var p InitializeParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return InitializeResult{
+			ProtocolVersion: negotiateVersion(p.ProtocolVersion),
+			Capabilities: map[string]any{
+				"tools": map[string]any{"listChanged": false},
+			},
+			ServerInfo:   t.info,
+			Instructions: t.instructions,
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return ListToolsResult{Tools: t.listTools(caller.Principal)}, nil
+	case "tools/call":
+		var p CallToolParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return t.callTool(ctx, caller, p)
+	}
+
+	if strings.HasPrefix(method, "notifications/") {
+		return nil, nil
+	}
+	return nil, jsonrpc.NewError(jsonrpc.ErrorCodeMethodNotFound, jsonrpc.ErrorCodeMethodNotFound.Message(), method)
+}
+
+// listTools converts the registry into MCP tool definitions, sorted by name.
+// Listers that implement AuthorizedLister only return the tools principal may call.
+func (t *MCPTransport) listTools(principal *server.Principal) []Tool {
+	var registry map[string]tools.ToolInfo
+	if authorized, ok := t.tools.(AuthorizedLister); ok {
+		registry = authorized.AuthorizedTools(principal)
+	} else {
+		registry = t.tools.GetMethodRegistry()
+	}
+
+	list := make([]Tool, 0, len(registry))
+	for _, info := range registry {
+		list = append(list, toolFromInfo(info))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// toolFromInfo maps a registered tool to an MCP tool definition.
+// Property maps become object schemas; LLM-described tools accept any object.
+func toolFromInfo(info tools.ToolInfo) Tool {
+	description := info.Description
+	if info.Returns != "" {
+		description += "\n\nReturns: " + info.Returns
+	}
+
+	inputSchema := map[string]interface{}{"type": "object"}
+	if info.Parameters != nil {
+		inputSchema = tools.ParametersSchema(info.Parameters)
+	}
+
+	tool := Tool{
+		Name:        info.Name,
+		Description: description,
+		InputSchema: inputSchema,
+	}
+	// MCP output schemas must describe objects
+	if info.ReturnsSchema["type"] == "object" {
+		tool.OutputSchema = info.ReturnsSchema
+	}
+	return tool
+}
+
+// callTool executes a tools/call request through the interceptors.
+// Unknown tools and panics are protocol errors; invalid arguments and failed calls are tool errors.
+// A trace context in the request's _meta takes precedence over the traceparent header.
+func (t *MCPTransport) callTool(ctx context.Context, caller server.Caller, p CallToolParams) (any, *jsonrpc.Error) {
+	ctx = contextWithMetaTrace(ctx, p.Meta)
+
+	_, ok := t.tools.GetMethodRegistry()[p.Name]
+	serviceName, methodName, found := strings.Cut(p.Name, ".")
+	if !ok || !found {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorCodeInvalidParams, "Unknown tool: "+p.Name, nil)
+	}
+
+	arguments := p.Arguments
+	if arguments == nil {
+		arguments = make(map[string]interface{})
+	}
+
+	call := &server.ToolCall{
+		ServiceName: serviceName,
+		MethodName:  methodName,
+		Params:      arguments,
+		Caller:      caller,
+	}
+	result, err := t.interceptors.Invoke(ctx, call, t.execute)
+	var panicErr *server.PanicError
+	if errors.As(err, &panicErr) {
+		// The panic value and stack are not shown to the client
+		return nil, jsonrpc.NewError(jsonrpc.ErrorCodeInternalError, jsonrpc.ErrorCodeInternalError.Message(), nil)
+	}
+	if err != nil {
+		return toolError(err), nil
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return toolError(fmt.Errorf("failed to encode result: %w", err)), nil
+	}
+
+	callResult := CallToolResult{
+		Content: []Content{{Type: "text", Text: string(encoded)}},
+	}
+	var structured any
+	if json.Unmarshal(encoded, &structured) == nil {
+		if object, ok := structured.(map[string]any); ok {
+			callResult.StructuredContent = object
+		}
+	}
+	return callResult, nil
+}
+
+// execute validates the arguments of a call and runs it on the executor
+func (t *MCPTransport) execute(ctx context.Context, call *server.ToolCall) (result any, err error) {
+	if schemas, ok := t.tools.(tools.SchemaProvider); ok {
+		if schema, ok := schemas.ParameterSchema(call.ServiceName, call.MethodName); ok {
+			if err := tools.ValidateParams(schema, call.Params); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// JSONRPCMethodExecutor recovers panics itself, other executors may not
+	defer func() {
+		if r := recover(); r != nil {
+			err = server.NewPanicError(r)
+		}
+	}()
+	return server.Execute(ctx, t.executor, call.ServiceName, call.MethodName, call.Params)
+}
+
+// toolError reports a failed call as a tool result so the model can see and react to it
+func toolError(err error) CallToolResult {
+	text := err.Error()
+	if rpcErr, ok := jsonrpc.AsError(err); ok {
+		text = rpcErr.Message
+		if data, ok := rpcErr.Data.(string); ok && data != "" {
+			text += ": " + data
+		}
+	}
+	return CallToolResult{
+		Content: []Content{{Type: "text", Text: text}},
+		IsError: true,
+	}
+}
+
+// unmarshalParams decodes request params, treating missing params as empty
+func unmarshalParams(params json.RawMessage, v any) *jsonrpc.Error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return jsonrpc.NewError(jsonrpc.ErrorCodeInvalidParams, jsonrpc.ErrorCodeInvalidParams.Message(), err.Error())
+	}
+	return nil
+}
+
+// errorResponse builds a JSON-RPC error response
+func errorResponse(id json.RawMessage, err *jsonrpc.Error) *response {
+	return &response{JSONRPC: "2.0", ID: id, Error: err}
+}
@@ -0,0 +1,374 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/tools"
+)
+
+// stubExecutor records calls and answers with a fixed result or error, or panics
+type stubExecutor struct {
+	result     interface{}
+	err        error
+	panicValue interface{}
+	lastMethod string
+	lastParams map[string]interface{}
+}
+
+func (s *stubExecutor) ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	s.lastMethod = serviceName + "." + methodName
+	s.lastParams = params
+	if s.panicValue != nil {
+		panic(s.panicValue)
+	}
+	return s.result, s.err
+}
+
+type greetResponse struct {
+	Message string `json:"message"`
+}
+
+func newTestTransport(executor *stubExecutor, opts ...MCPTransportOpts) *MCPTransport {
+	toolService := tools.NewToolService()
+	toolService.RegisterMethod("HelloService", "Hello", "Greets a person", map[string]interface{}{
+		"name": map[string]interface{}{"type": "string", "required": true},
+	})
+	toolService.RegisterMethodLLM("NoteService.Add", "Adds a note", "The stored note")
+	return NewMCPTransport(toolService, executor, opts...)
+}
+
+func TestMCPTransport_handleMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		executor *stubExecutor
+		expected string
+	}{
+		{
+			name:     "initialize_echoes_supported_version",
+			message:  `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+			expected: `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-03-26","capabilities":{"tools":{"listChanged":false}},"serverInfo":{"name":"agent-sdk","version":"0.1.0"}}}`,
+		},
+		{
+			name:     "initialize_falls_back_to_latest_version",
+			message:  `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+			expected: `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","capabilities":{"tools":{"listChanged":false}},"serverInfo":{"name":"agent-sdk","version":"0.1.0"}}}`,
+		},
+		{
+			name:     "ping",
+			message:  `{"jsonrpc":"2.0","id":"a","method":"ping"}`,
+			expected: `{"jsonrpc":"2.0","id":"a","result":{}}`,
+		},
+		{
+			name:     "tools_list",
+			message:  `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+			expected: `{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"HelloService.Hello","description":"Greets a person","inputSchema":{"properties":{"name":{"type":"string"}},"required":["name"],"type":"object"}},{"name":"NoteService.Add","description":"Adds a note\n\nReturns: The stored note","inputSchema":{"type":"object"}}]}}`,
+		},
+		{
+			name:     "tools_call_success",
+			message:  `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"HelloService.Hello","arguments":{"name":"Ada"}}}`,
+			executor: &stubExecutor{result: greetResponse{Message: "Hello, Ada"}},
+			expected: `{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"{\"message\":\"Hello, Ada\"}"}],"structuredContent":{"message":"Hello, Ada"}}}`,
+		},
+		{
+			name:     "tools_call_execution_error",
+			message:  `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"NoteService.Add","arguments":{}}}`,
+			executor: &stubExecutor{err: fmt.Errorf("disk full")},
+			expected: `{"jsonrpc":"2.0","id":4,"result":{"content":[{"type":"text","text":"disk full"}],"isError":true}}`,
+		},
+		{
+			name:     "tools_call_panic",
+			message:  `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"NoteService.Add","arguments":{}}}`,
+			executor: &stubExecutor{panicValue: "secret state"},
+			expected: `{"jsonrpc":"2.0","id":4,"error":{"code":-32603,"message":"Internal error"}}`,
+		},
+		{
+			name:     "tools_call_recovered_panic",
+			message:  `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"NoteService.Add","arguments":{}}}`,
+			executor: &stubExecutor{err: server.NewPanicError("secret state")},
+			expected: `{"jsonrpc":"2.0","id":4,"error":{"code":-32603,"message":"Internal error"}}`,
+		},
+		{
+			name:     "tools_call_invalid_arguments",
+			message:  `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"HelloService.Hello","arguments":{}}}`,
+			expected: `{"jsonrpc":"2.0","id":5,"result":{"content":[{"type":"text","text":"invalid params: name: is required"}],"isError":true}}`,
+		},
+		{
+			name:     "tools_call_unknown_tool",
+			message:  `{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"Missing.Tool"}}`,
+			expected: `{"jsonrpc":"2.0","id":6,"error":{"code":-32602,"message":"Unknown tool: Missing.Tool"}}`,
+		},
+		{
+			name:     "unknown_method",
+			message:  `{"jsonrpc":"2.0","id":7,"method":"resources/list"}`,
+			expected: `{"jsonrpc":"2.0","id":7,"error":{"code":-32601,"message":"Method not found","data":"resources/list"}}`,
+		},
+		{
+			name:     "parse_error",
+			message:  `{"jsonrpc":`,
+			expected: `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error","data":"unexpected end of JSON input"}}`,
+		},
+		{
+			name:     "invalid_version",
+			message:  `{"jsonrpc":"1.0","id":8,"method":"ping"}`,
+			expected: `{"jsonrpc":"2.0","id":8,"error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc field must be '2.0'"}}`,
+		},
+		{
+			name:    "notification_has_no_response",
+			message: `{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		},
+		{
+			name:    "client_response_has_no_response",
+			message: `{"jsonrpc":"2.0","id":9,"result":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := tt.executor
+			if executor == nil {
+				executor = &stubExecutor{}
+			}
+			transport := newTestTransport(executor)
+
+			resp := transport.handleMessage(context.Background(), server.Caller{Transport: server.TransportMCP}, []byte(tt.message))
+			if tt.expected == "" {
+				if resp != nil {
+					t.Fatalf("expected no response, got %+v", resp)
+				}
+				return
+			}
+
+			got, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("failed to marshal response: %v", err)
+			}
+			assertJSONEqual(t, tt.expected, string(got))
+		})
+	}
+}
+
+func TestMCPTransport_callToolPassesArguments(t *testing.T) {
+	executor := &stubExecutor{result: greetResponse{}}
+	transport := newTestTransport(executor)
+
+	transport.handleMessage(context.Background(), server.Caller{Transport: server.TransportMCP}, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"HelloService.Hello","arguments":{"name":"Ada"}}}`))
+
+	if executor.lastMethod != "HelloService.Hello" {
+		t.Errorf("expected HelloService.Hello to be executed, got %q", executor.lastMethod)
+	}
+	if !reflect.DeepEqual(executor.lastParams, map[string]interface{}{"name": "Ada"}) {
+		t.Errorf("unexpected params %v", executor.lastParams)
+	}
+}
+
+func TestMCPTransport_callToolInterceptors(t *testing.T) {
+	executor := &stubExecutor{result: greetResponse{Message: "Hello"}}
+	interceptors := server.NewInterceptors()
+
+	var caller server.Caller
+	interceptors.UseMethod("HelloService", "Hello", func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		caller = call.Caller
+		call.Params["name"] = "Grace"
+		return next(ctx, call)
+	})
+	transport := newTestTransport(executor, WithInterceptors(interceptors))
+
+	// The interceptor supplies the required argument before validation runs
+	resp := transport.handleMessage(context.Background(), server.Caller{Transport: server.TransportMCP}, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"HelloService.Hello"}}`))
+
+	got, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("failed to marshal response: %v", err)
+	}
+	assertJSONEqual(t, `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"{\"message\":\"Hello\"}"}],"structuredContent":{"message":"Hello"}}}`, string(got))
+	if !reflect.DeepEqual(executor.lastParams, map[string]interface{}{"name": "Grace"}) {
+		t.Errorf("unexpected params %v", executor.lastParams)
+	}
+	if caller.Transport != server.TransportMCP {
+		t.Errorf("expected transport %q, got %q", server.TransportMCP, caller.Transport)
+	}
+}
+
+func TestMCPTransport_listToolsPolicies(t *testing.T) {
+	toolService := tools.NewToolService(tools.WithPolicy("NoteService", tools.Policy{Scopes: []string{"notes:write"}}))
+	toolService.RegisterMethod("HelloService", "Hello", "Greets a person", nil)
+	toolService.RegisterMethodLLM("NoteService.Add", "Adds a note")
+	transport := NewMCPTransport(toolService, &stubExecutor{})
+
+	tests := []struct {
+		name      string
+		principal *server.Principal
+		expected  []string
+	}{
+		{
+			name:     "anonymous",
+			expected: []string{"HelloService.Hello"},
+		},
+		{
+			name:      "writer",
+			principal: &server.Principal{Subject: "ada", Scopes: []string{"notes:write"}},
+			expected:  []string{"HelloService.Hello", "NoteService.Add"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, tool := range transport.listTools(tt.principal) {
+				names = append(names, tool.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestMCPTransport_ServeStream(t *testing.T) {
+	transport := newTestTransport(&stubExecutor{})
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+		``,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+	}, "\n")
+	var output bytes.Buffer
+
+	if err := transport.ServeStream(context.Background(), strings.NewReader(input), &output); err != nil {
+		t.Fatalf("ServeStream returned error: %v", err)
+	}
+
+	ids := map[string]bool{}
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		var resp message
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response line %q: %v", scanner.Text(), err)
+		}
+		ids[string(resp.ID)] = true
+	}
+	if !reflect.DeepEqual(ids, map[string]bool{"1": true, "2": true}) {
+		t.Errorf("expected responses for ids 1 and 2, got %v", ids)
+	}
+}
+
+func TestMCPTransport_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		headers        map[string]string
+		opts           []MCPTransportOpts
+		expectedStatus int
+	}{
+		{
+			name:           "request_returns_json",
+			method:         http.MethodPost,
+			body:           `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "notification_is_accepted",
+			method:         http.MethodPost,
+			body:           `{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "parse_error_is_bad_request",
+			method:         http.MethodPost,
+			body:           `not json`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "get_is_not_allowed",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "unsupported_protocol_version",
+			method:         http.MethodPost,
+			body:           `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			headers:        map[string]string{"MCP-Protocol-Version": "1999-01-01"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "foreign_origin_is_forbidden",
+			method:         http.MethodPost,
+			body:           `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			headers:        map[string]string{"Origin": "http://evil.example"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "same_origin_is_allowed",
+			method:         http.MethodPost,
+			body:           `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			headers:        map[string]string{"Origin": "http://example.com"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "configured_origin_is_allowed",
+			method:         http.MethodPost,
+			body:           `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			headers:        map[string]string{"Origin": "http://app.example"},
+			opts:           []MCPTransportOpts{WithAllowedOrigins("http://app.example")},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newTestTransport(&stubExecutor{}, tt.opts...)
+
+			req := httptest.NewRequest(tt.method, "http://example.com/mcp", strings.NewReader(tt.body))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			transport.HTTPHandler().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWithPath(t *testing.T) {
+	transport := newTestTransport(&stubExecutor{}, WithPath("/custom"))
+
+	req := httptest.NewRequest(http.MethodPost, "/custom", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	w := httptest.NewRecorder()
+	transport.HTTPHandler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 at custom path, got %d", w.Code)
+	}
+}
+
+// assertJSONEqual compares two JSON documents independent of key order
+func assertJSONEqual(t *testing.T, expected, actual string) {
+	t.Helper()
+	var want, got interface{}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		t.Fatalf("invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(actual), &got); err != nil {
+		t.Fatalf("invalid actual JSON: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contentType is the media type of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler returns an HTTP handler serving the metrics in the Prometheus text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			m.WriteTo(w)
+		}
+	})
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mutex.Lock()
+	keys := make([]seriesKey, 0, len(m.series))
+	snapshot := make(map[seriesKey]toolSeries, len(m.series))
+	for key, series := range m.series {
+		keys = append(keys, key)
+		copied := *series
+		copied.errors = make(map[int]uint64, len(series.errors))
+		for code, n := range series.errors {
+			copied.errors[code] = n
+		}
+		copied.duration.counts = append([]uint64(nil), series.duration.counts...)
+		copied.requests.counts = append([]uint64(nil), series.requests.counts...)
+		copied.responses.counts = append([]uint64(nil), series.responses.counts...)
+		snapshot[key] = copied
+	}
+	m.mutex.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].tool != keys[j].tool {
+			return keys[i].tool < keys[j].tool
+		}
+		return keys[i].transport < keys[j].transport
+	})
+
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.header("agentsdk_tool_calls_total", "counter", "Tool calls, by tool and transport.")
+	for _, key := range keys {
+		e.sample("agentsdk_tool_calls_total", labels(key), strconv.FormatUint(snapshot[key].calls, 10))
+	}
+
+	e.header("agentsdk_tool_errors_total", "counter", "Failed tool calls, by tool, transport and JSON-RPC error code.")
+	for _, key := range keys {
+		errs := snapshot[key].errors
+		codes := make([]int, 0, len(errs))
+		for code := range errs {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			e.sample("agentsdk_tool_errors_total", labels(key, "code", strconv.Itoa(code)), strconv.FormatUint(errs[code], 10))
+		}
+	}
+
+	e.header("agentsdk_tool_in_flight", "gauge", "Tool calls currently running, by tool and transport.")
+	for _, key := range keys {
+		e.sample("agentsdk_tool_in_flight", labels(key), strconv.FormatInt(snapshot[key].inFlight, 10))
+	}
+
+	e.header("agentsdk_tool_duration_seconds", "histogram", "Tool call latency in seconds, by tool and transport.")
+	for _, key := range keys {
+		series := snapshot[key]
+		e.histogram("agentsdk_tool_duration_seconds", key, m.durationBuckets, series.duration)
+	}
+
+	e.header("agentsdk_tool_request_bytes", "histogram", "Size of the JSON-encoded params of tool calls, by tool and transport.")
+	for _, key := range keys {
+		series := snapshot[key]
+		e.histogram("agentsdk_tool_request_bytes", key, m.sizeBuckets, series.requests)
+	}
+
+	e.header("agentsdk_tool_response_bytes", "histogram", "Size of the JSON-encoded results of successful tool calls, by tool and transport.")
+	for _, key := range keys {
+		series := snapshot[key]
+		e.histogram("agentsdk_tool_response_bytes", key, m.sizeBuckets, series.responses)
+	}
+
+	return e.n, e.flush()
+}
+
+// encoder writes the exposition format, keeping the first error
+type encoder struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// printf writes formatted text unless an earlier write failed
+func (e *encoder) printf(format string, args ...any) {
+	if e.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(e.w, format, args...)
+	e.n += int64(n)
+	e.err = err
+}
+
+// flush flushes the buffered output and returns the first error
+func (e *encoder) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// header writes the HELP and TYPE lines of a metric family
+func (e *encoder) header(name, kind, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one sample line
+func (e *encoder) sample(name, labels, value string) {
+	e.printf("%s{%s} %s\n", name, labels, value)
+}
+
+// histogram writes the cumulative buckets, sum and count of a histogram
+func (e *encoder) histogram(name string, key seriesKey, bounds []float64, h histogram) {
+	var cumulative uint64
+	for i, bound := range bounds {
+		if i < len(h.counts) {
+			cumulative += h.counts[i]
+		}
+		e.sample(name+"_bucket", labels(key, "le", formatFloat(bound)), strconv.FormatUint(cumulative, 10))
+	}
+	e.sample(name+"_bucket", labels(key, "le", "+Inf"), strconv.FormatUint(h.count, 10))
+	e.sample(name+"_sum", labels(key), formatFloat(h.sum))
+	e.sample(name+"_count", labels(key), strconv.FormatUint(h.count, 10))
+}
+
+// labels formats the labels of a series followed by extra name/value pairs
+func labels(key seriesKey, extra ...string) string {
+	var b strings.Builder
+	b.WriteString(`tool="` + escapeLabel(key.tool) + `",transport="` + escapeLabel(key.transport) + `"`)
+	for i := 0; i+1 < len(extra); i += 2 {
+		b.WriteString("," + extra[i] + `="` + escapeLabel(extra[i+1]) + `"`)
+	}
+	return b.String()
+}
+
+// escapeLabel escapes a label value as required by the exposition format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatFloat formats a sample value or bucket bound
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
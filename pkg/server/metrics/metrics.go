@@ -0,0 +1,192 @@
+// Package metrics collects tool execution metrics and serves them in the Prometheus text
+// exposition format. It depends on the standard library only.
+//
+// Metrics recorded for every call, labelled by tool ("Service.Method") and transport:
+//
+//	agentsdk_tool_calls_total          counter of calls
+//	agentsdk_tool_errors_total         counter of failed calls, also labelled by JSON-RPC code
+//	agentsdk_tool_duration_seconds     histogram of call latency
+//	agentsdk_tool_in_flight            gauge of calls currently running
+//	agentsdk_tool_request_bytes        histogram of the size of the JSON-encoded params
+//	agentsdk_tool_response_bytes       histogram of the size of the JSON-encoded results
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// maxTools bounds the distinct tool labels; calls to further tools, e.g. to random
+// method names sent by a misbehaving client, are counted under otherTool
+const maxTools = 1000
+
+// otherTool labels the calls of tools beyond maxTools
+const otherTool = "other"
+
+// DefaultDurationBuckets are the upper bounds, in seconds, of the latency histogram
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// DefaultSizeBuckets are the upper bounds, in bytes, of the payload size histograms
+var DefaultSizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+// MetricsOpts defines options for configuring the metrics
+type MetricsOpts func(*Metrics)
+
+// Metrics collects per-tool metrics. Install its Interceptor and serve its Handler.
+type Metrics struct {
+	durationBuckets []float64
+	sizeBuckets     []float64
+
+	mutex  sync.Mutex
+	series map[seriesKey]*toolSeries
+	tools  map[string]bool // Tools with a label of their own
+}
+
+// seriesKey identifies the metrics of one tool on one transport
+type seriesKey struct {
+	tool      string
+	transport string
+}
+
+// toolSeries holds the metrics of one tool on one transport
+type toolSeries struct {
+	calls     uint64
+	errors    map[int]uint64 // Key: JSON-RPC error code
+	inFlight  int64
+	duration  histogram
+	requests  histogram
+	responses histogram
+}
+
+// histogram counts observations into cumulative buckets
+type histogram struct {
+	counts []uint64 // One per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// observe adds v to the histogram with the given bucket bounds
+func (h *histogram) observe(bounds []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds))
+	}
+	if i := sort.SearchFloat64s(bounds, v); i < len(bounds) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// NewMetrics creates an empty set of metrics
+func NewMetrics(opts ...MetricsOpts) *Metrics {
+	m := &Metrics{
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		series:          make(map[seriesKey]*toolSeries),
+		tools:           make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithDurationBuckets sets the upper bounds, in seconds, of the latency histogram
+func WithDurationBuckets(buckets ...float64) MetricsOpts {
+	return func(m *Metrics) {
+		m.durationBuckets = sortedBuckets(buckets)
+	}
+}
+
+// WithSizeBuckets sets the upper bounds, in bytes, of the payload size histograms
+func WithSizeBuckets(buckets ...float64) MetricsOpts {
+	return func(m *Metrics) {
+		m.sizeBuckets = sortedBuckets(buckets)
+	}
+}
+
+// sortedBuckets returns a sorted copy of buckets
+func sortedBuckets(buckets []float64) []float64 {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// Interceptor returns an interceptor that records every call passing through it.
+// Install it before other interceptors to count the calls they reject.
+func (m *Metrics) Interceptor() server.Interceptor {
+	return func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		key := m.key(call)
+		requestSize := encodedSize(call.Params)
+
+		m.mutex.Lock()
+		m.seriesFor(key).inFlight++
+		m.mutex.Unlock()
+
+		start := time.Now()
+		result, err := next(ctx, call)
+		elapsed := time.Since(start).Seconds()
+
+		responseSize := 0
+		if err == nil {
+			responseSize = encodedSize(result)
+		}
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		series := m.seriesFor(key)
+		series.inFlight--
+		series.calls++
+		series.duration.observe(m.durationBuckets, elapsed)
+		series.requests.observe(m.sizeBuckets, float64(requestSize))
+		if err != nil {
+			series.errors[int(server.ErrorCode(err))]++
+		} else {
+			series.responses.observe(m.sizeBuckets, float64(responseSize))
+		}
+		return result, err
+	}
+}
+
+// key returns the series key of a call, folding tools beyond maxTools into otherTool
+func (m *Metrics) key(call *server.ToolCall) seriesKey {
+	tool := call.ServiceName + "." + call.MethodName
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if !m.tools[tool] {
+		if len(m.tools) >= maxTools {
+			tool = otherTool
+		} else {
+			m.tools[tool] = true
+		}
+	}
+	return seriesKey{tool: tool, transport: call.Caller.Transport}
+}
+
+// seriesFor returns the series of key, creating it if needed; the caller must hold the mutex
+func (m *Metrics) seriesFor(key seriesKey) *toolSeries {
+	series, ok := m.series[key]
+	if !ok {
+		series = &toolSeries{errors: make(map[int]uint64)}
+		m.series[key] = series
+	}
+	return series
+}
+
+// encodedSize returns the length of the JSON encoding of v, or 0 if it cannot be encoded
+func encodedSize(v any) int {
+	if v == nil {
+		return 0
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// call runs a call through the metrics interceptor with the given outcome
+func call(m *Metrics, tool, transport string, params map[string]interface{}, result any, err error) {
+	serviceName, methodName, _ := strings.Cut(tool, ".")
+	toolCall := &server.ToolCall{
+		ServiceName: serviceName,
+		MethodName:  methodName,
+		Params:      params,
+		Caller:      server.Caller{Transport: transport},
+	}
+	m.Interceptor()(context.Background(), toolCall, func(ctx context.Context, call *server.ToolCall) (any, error) {
+		return result, err
+	})
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := NewMetrics(WithDurationBuckets(10, 1), WithSizeBuckets(16, 4))
+	call(m, "NoteService.Add", "http", map[string]interface{}{"text": "hi"}, map[string]int{"id": 1}, nil)
+	call(m, "NoteService.Add", "http", map[string]interface{}{}, nil, jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, "Forbidden", nil))
+	call(m, "NoteService.Add", "http", map[string]interface{}{}, nil, context.DeadlineExceeded)
+	call(m, "NoteService.Add", "mcp", map[string]interface{}{}, nil, errors.New("disk full"))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("expected content type %q, got %q", contentType, got)
+	}
+
+	body := w.Body.String()
+	expected := []string{
+		"# TYPE agentsdk_tool_calls_total counter",
+		`agentsdk_tool_calls_total{tool="NoteService.Add",transport="http"} 3`,
+		`agentsdk_tool_calls_total{tool="NoteService.Add",transport="mcp"} 1`,
+		"# TYPE agentsdk_tool_errors_total counter",
+		`agentsdk_tool_errors_total{tool="NoteService.Add",transport="http",code="-32003"} 1`,
+		`agentsdk_tool_errors_total{tool="NoteService.Add",transport="http",code="-32001"} 1`,
+		`agentsdk_tool_errors_total{tool="NoteService.Add",transport="mcp",code="-32603"} 1`,
+		"# TYPE agentsdk_tool_in_flight gauge",
+		`agentsdk_tool_in_flight{tool="NoteService.Add",transport="http"} 0`,
+		"# TYPE agentsdk_tool_duration_seconds histogram",
+		`agentsdk_tool_duration_seconds_bucket{tool="NoteService.Add",transport="http",le="1"} 3`,
+		`agentsdk_tool_duration_seconds_bucket{tool="NoteService.Add",transport="http",le="+Inf"} 3`,
+		`agentsdk_tool_duration_seconds_count{tool="NoteService.Add",transport="http"} 3`,
+		// {"text":"hi"} is 13 bytes, {} is 2
+		`agentsdk_tool_request_bytes_bucket{tool="NoteService.Add",transport="http",le="4"} 2`,
+		`agentsdk_tool_request_bytes_bucket{tool="NoteService.Add",transport="http",le="16"} 3`,
+		`agentsdk_tool_request_bytes_sum{tool="NoteService.Add",transport="http"} 17`,
+		// Only successful calls have a response: {"id":1} is 8 bytes
+		`agentsdk_tool_response_bytes_bucket{tool="NoteService.Add",transport="http",le="16"} 1`,
+		`agentsdk_tool_response_bytes_count{tool="NoteService.Add",transport="http"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetrics_InFlight(t *testing.T) {
+	m := NewMetrics()
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		m.Interceptor()(context.Background(), &server.ToolCall{ServiceName: "Build", MethodName: "Run", Caller: server.Caller{Transport: "http"}},
+			func(ctx context.Context, call *server.ToolCall) (any, error) {
+				<-release
+				return nil, nil
+			})
+	}()
+
+	expected := `agentsdk_tool_in_flight{tool="Build.Run",transport="http"} 1`
+	for i := 0; i < 100; i++ {
+		var b strings.Builder
+		m.WriteTo(&b)
+		if strings.Contains(b.String(), expected) {
+			break
+		}
+		if i == 99 {
+			t.Errorf("expected %q in:\n%s", expected, b.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	<-done
+}
+
+func TestMetrics_ToolLabelsAreBounded(t *testing.T) {
+	m := NewMetrics()
+	for i := 0; i < maxTools+5; i++ {
+		call(m, fmt.Sprintf("Random.Method%d", i), "http", nil, nil, nil)
+	}
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	expected := `agentsdk_tool_calls_total{tool="other",transport="http"} 5`
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("expected %q", expected)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "plain", value: "NoteService.Add", expected: "NoteService.Add"},
+		{name: "quote", value: `a"b`, expected: `a\"b`},
+		{name: "backslash", value: `a\b`, expected: `a\\b`},
+		{name: "newline", value: "a\nb", expected: `a\nb`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeLabel(tt.value); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import "context"
+
+// Principal is the authenticated identity behind a call
+type Principal struct {
+	Subject string         // Token owner, key id or JWT "sub" claim
+	Scheme  string         // Authentication scheme that established the identity, e.g. "bearer"
+	Scopes  []string       // Granted scopes
+	Roles   []string       // Granted roles
+	Claims  map[string]any // Verified JWT claims, if any
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the principal was granted role
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// principalKey is the context key for the authenticated principal
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying principal
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal authenticated for the current call.
+// Service methods that take a context.Context as their first argument can use it
+// to see who is calling:
+//
+//	func (s *NoteService) Add(ctx context.Context, req AddRequest, reply *AddResponse) error {
+//	    principal, ok := server.PrincipalFromContext(ctx)
+//	    ...
+//	}
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -1,11 +1,22 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"sync"
 )
 
+// Transport serves the server's endpoints to clients
 type Transport interface {
 	ListenAndServe(addr string) error
+	// Serve accepts connections on listener until Shutdown is called
+	Serve(listener net.Listener) error
+	// Shutdown stops accepting connections and waits for in-flight calls to
+	// complete, or for ctx to be done, whichever happens first
+	Shutdown(ctx context.Context) error
 }
 
 // ToolRegistry defines the interface for tool registration
@@ -20,10 +31,30 @@ type MethodExecutor interface {
 	ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error)
 }
 
+// ContextMethodExecutor is implemented by executors that honor cancellation and deadlines
+type ContextMethodExecutor interface {
+	MethodExecutor
+	ExecuteMethodContext(ctx context.Context, serviceName, methodName string, params map[string]interface{}) (interface{}, error)
+}
+
+// Execute runs a method on executor, passing ctx through when the executor supports it
+func Execute(ctx context.Context, executor MethodExecutor, serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	if ctxExecutor, ok := executor.(ContextMethodExecutor); ok {
+		return ctxExecutor.ExecuteMethodContext(ctx, serviceName, methodName, params)
+	}
+	return executor.ExecuteMethod(serviceName, methodName, params)
+}
+
 type Server struct {
 	transport      Transport
 	toolRegistry   ToolRegistry
 	methodExecutor MethodExecutor
+	interceptors   *Interceptors
+	logger         *Logger
+
+	hooksMutex sync.Mutex
+	startHooks []func() error
+	stopHooks  []func(ctx context.Context) error
 }
 
 type ServerOpts func(*Server)
@@ -51,19 +82,82 @@ func WithMethodExecutor(executor MethodExecutor) ServerOpts {
 	}
 }
 
+// WithInterceptors sets the interceptor chain of the server. Pass the same chain to the
+// transports' handlers so that interceptors registered on the server apply to them.
+func WithInterceptors(interceptors *Interceptors) ServerOpts {
+	return func(s *Server) {
+		s.interceptors = interceptors
+	}
+}
+
+// WithLogger sets the logger of the server, see SetLogger
+func WithLogger(logger *slog.Logger, opts ...LoggerOpts) ServerOpts {
+	return func(s *Server) {
+		s.logger = NewLogger(logger, opts...)
+	}
+}
+
 func NewServer(opts ...ServerOpts) *Server {
 	s := &Server{}
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.interceptors == nil {
+		s.interceptors = NewInterceptors()
+	}
+	if s.logger != nil {
+		s.useLogger(s.logger)
+	}
 	return s
 }
 
+// SetLogger makes the server log its events to logger, configured by opts, and passes the
+// logger on to the transport, tool registry and method executor, if they accept one. It must
+// be called before the server starts serving.
+func (s *Server) SetLogger(logger *slog.Logger, opts ...LoggerOpts) {
+	s.useLogger(NewLogger(logger, opts...))
+}
+
+// useLogger sets the logger of the server and its components
+func (s *Server) useLogger(logger *Logger) {
+	s.logger = logger
+	for _, component := range []any{s.transport, s.toolRegistry, s.methodExecutor} {
+		if setter, ok := component.(loggerSetter); ok {
+			setter.SetLogger(logger)
+		}
+	}
+}
+
 // GetTransport returns the underlying transport
 func (s *Server) GetTransport() Transport {
 	return s.transport
 }
 
+// GetInterceptors returns the interceptor chain
+func (s *Server) GetInterceptors() *Interceptors {
+	return s.interceptors
+}
+
+// Use adds interceptors that run around every call
+func (s *Server) Use(interceptors ...Interceptor) {
+	s.interceptors.Use(interceptors...)
+}
+
+// UseFirst adds interceptors that run around every call, outside all interceptors added so far
+func (s *Server) UseFirst(interceptors ...Interceptor) {
+	s.interceptors.UseFirst(interceptors...)
+}
+
+// UseService adds interceptors that run around every call to a service
+func (s *Server) UseService(serviceName string, interceptors ...Interceptor) {
+	s.interceptors.UseService(serviceName, interceptors...)
+}
+
+// UseMethod adds interceptors that run around every call to a single method
+func (s *Server) UseMethod(serviceName, methodName string, interceptors ...Interceptor) {
+	s.interceptors.UseMethod(serviceName, methodName, interceptors...)
+}
+
 // GetToolRegistry returns the tool registry
 func (s *Server) GetToolRegistry() ToolRegistry {
 	return s.toolRegistry
@@ -107,10 +201,23 @@ func (s *Server) RegisterMethodLLM(methodName, description string, returns ...st
 
 // ExecuteMethod executes a method through the method executor
 func (s *Server) ExecuteMethod(serviceName, methodName string, params map[string]any) (any, error) {
-	if s.methodExecutor != nil {
-		return s.methodExecutor.ExecuteMethod(serviceName, methodName, params)
+	return s.ExecuteMethodContext(context.Background(), serviceName, methodName, params)
+}
+
+// ExecuteMethodContext executes a method through the interceptors and the method executor,
+// honoring ctx if the executor implements ContextMethodExecutor
+func (s *Server) ExecuteMethodContext(ctx context.Context, serviceName, methodName string, params map[string]any) (any, error) {
+	if s.methodExecutor == nil {
+		return nil, fmt.Errorf("no method executor configured")
+	}
+	call := &ToolCall{
+		ServiceName: serviceName,
+		MethodName:  methodName,
+		Params:      params,
+		Caller:      Caller{Transport: TransportDirect},
 	}
-	return nil, fmt.Errorf("no method executor configured")
+	call.Caller.Principal, _ = PrincipalFromContext(ctx)
+	return s.interceptors.Execute(ctx, s.methodExecutor, call)
 }
 
 // HTTPHandler returns the HTTP handler if the transport supports it
@@ -121,6 +228,89 @@ func (s *Server) HTTPHandler() any {
 	return nil
 }
 
+// OnStart registers fn to run before the server starts serving.
+// Hooks run in registration order; if one fails, the server does not start.
+func (s *Server) OnStart(fn func() error) {
+	s.hooksMutex.Lock()
+	defer s.hooksMutex.Unlock()
+	s.startHooks = append(s.startHooks, fn)
+}
+
+// OnStop registers fn to run during Shutdown, after the transport has drained.
+// Hooks run in reverse registration order and receive the shutdown context.
+func (s *Server) OnStop(fn func(ctx context.Context) error) {
+	s.hooksMutex.Lock()
+	defer s.hooksMutex.Unlock()
+	s.stopHooks = append(s.stopHooks, fn)
+}
+
+// ListenAndServe runs the start hooks and serves the transport on addr
 func (s *Server) ListenAndServe(addr string) error {
+	if s.transport == nil {
+		return fmt.Errorf("no transport configured")
+	}
+	if err := s.runStartHooks(); err != nil {
+		return err
+	}
+	s.logger.Event(context.Background(), "server starting", slog.String("addr", addr))
 	return s.transport.ListenAndServe(addr)
 }
+
+// Serve runs the start hooks and serves the transport on listener
+func (s *Server) Serve(listener net.Listener) error {
+	if s.transport == nil {
+		return fmt.Errorf("no transport configured")
+	}
+	if err := s.runStartHooks(); err != nil {
+		return err
+	}
+	if listener != nil {
+		s.logger.Event(context.Background(), "server starting", slog.String("addr", listener.Addr().String()))
+	}
+	return s.transport.Serve(listener)
+}
+
+// Shutdown gracefully stops the transport, waiting for in-flight calls until ctx is done,
+// then runs the stop hooks. Stop hooks run even if the transport did not drain in time;
+// all errors are returned joined.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+	if s.transport != nil {
+		if err := s.transport.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shut down transport: %w", err))
+		}
+	}
+
+	s.hooksMutex.Lock()
+	hooks := append([]func(context.Context) error(nil), s.stopHooks...)
+	s.hooksMutex.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop hook failed: %w", err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		s.logger.Warn(ctx, "server shutdown incomplete", slog.Any("error", err))
+	} else {
+		s.logger.Event(ctx, "server stopped")
+	}
+	return err
+}
+
+// runStartHooks runs the start hooks in registration order, stopping at the first error
+func (s *Server) runStartHooks() error {
+	s.hooksMutex.Lock()
+	hooks := append([]func() error(nil), s.startHooks...)
+	s.hooksMutex.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(); err != nil {
+			s.logger.Error(context.Background(), "start hook failed", slog.Any("error", err))
+			return fmt.Errorf("start hook failed: %w", err)
+		}
+	}
+	return nil
+}
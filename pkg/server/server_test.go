@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// recordingTransport is a Transport that records lifecycle calls in a shared log
+type recordingTransport struct {
+	log         *[]string
+	shutdownErr error
+}
+
+func (r *recordingTransport) ListenAndServe(addr string) error {
+	*r.log = append(*r.log, "listen")
+	return nil
+}
+
+func (r *recordingTransport) Serve(listener net.Listener) error {
+	*r.log = append(*r.log, "serve")
+	return nil
+}
+
+func (r *recordingTransport) Shutdown(ctx context.Context) error {
+	*r.log = append(*r.log, "shutdown")
+	return r.shutdownErr
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	tests := []struct {
+		name          string
+		startErr      error
+		shutdownErr   error
+		expectedLog   []string
+		expectedServe error
+	}{
+		{
+			name:        "hooks_run_in_order",
+			expectedLog: []string{"start 1", "start 2", "serve", "shutdown", "stop 2", "stop 1"},
+		},
+		{
+			name:          "failed_start_hook_prevents_serving",
+			startErr:      errors.New("database unavailable"),
+			expectedLog:   []string{"start 1", "shutdown", "stop 2", "stop 1"},
+			expectedServe: errors.New("database unavailable"),
+		},
+		{
+			name:        "stop_hooks_run_after_failed_shutdown",
+			shutdownErr: context.DeadlineExceeded,
+			expectedLog: []string{"start 1", "start 2", "serve", "shutdown", "stop 2", "stop 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			s := NewServer(WithTransport(&recordingTransport{log: &log, shutdownErr: tt.shutdownErr}))
+
+			s.OnStart(func() error {
+				log = append(log, "start 1")
+				return tt.startErr
+			})
+			s.OnStart(func() error {
+				log = append(log, "start 2")
+				return nil
+			})
+			s.OnStop(func(ctx context.Context) error {
+				log = append(log, "stop 1")
+				return nil
+			})
+			s.OnStop(func(ctx context.Context) error {
+				log = append(log, "stop 2")
+				return nil
+			})
+
+			err := s.Serve(nil)
+			if (err == nil) != (tt.expectedServe == nil) {
+				t.Errorf("Serve error = %v, want %v", err, tt.expectedServe)
+			}
+			if err != nil && !errors.Is(err, tt.startErr) {
+				t.Errorf("Serve error = %v, want it to wrap %v", err, tt.startErr)
+			}
+
+			err = s.Shutdown(context.Background())
+			if !errors.Is(err, tt.shutdownErr) || (err == nil) != (tt.shutdownErr == nil) {
+				t.Errorf("Shutdown error = %v, want %v", err, tt.shutdownErr)
+			}
+
+			if !reflect.DeepEqual(log, tt.expectedLog) {
+				t.Errorf("log = %v, want %v", log, tt.expectedLog)
+			}
+		})
+	}
+}
+
+func TestServer_ShutdownJoinsStopHookErrors(t *testing.T) {
+	var log []string
+	s := NewServer(WithTransport(&recordingTransport{log: &log}))
+
+	first := errors.New("flush failed")
+	second := errors.New("close failed")
+	s.OnStop(func(ctx context.Context) error { return first })
+	s.OnStop(func(ctx context.Context) error { return second })
+
+	err := s.Shutdown(context.Background())
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("Shutdown error = %v, want both hook errors", err)
+	}
+}
+
+func TestServer_NoTransport(t *testing.T) {
+	s := NewServer()
+	if err := s.ListenAndServe(":0"); err == nil {
+		t.Error("expected error without transport")
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown without transport error = %v", err)
+	}
+}
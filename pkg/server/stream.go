@@ -0,0 +1,45 @@
+package server
+
+import "context"
+
+// Stream receives the intermediate output of a long-running call.
+// Service methods opt into streaming by taking a Stream argument between the request
+// and the response pointer:
+//
+//	func (s *BuildService) Build(ctx context.Context, req BuildRequest, stream server.Stream, reply *BuildResponse) error {
+//	    stream.Progress(1, 3, "compiling")
+//	    stream.Send(map[string]any{"log": "..."})
+//	    ...
+//	}
+//
+// When the call was not made through a streaming transport the stream discards everything.
+// Sends fail once the client has gone away.
+type Stream interface {
+	// Send pushes a partial result to the client
+	Send(partial any) error
+	// Progress reports how much of the work is done; total is 0 when unknown
+	Progress(progress, total float64, message string) error
+}
+
+// streamKey is the context key for the stream of the current call
+type streamKey struct{}
+
+// ContextWithStream returns a copy of ctx carrying stream, used by streaming transports
+func ContextWithStream(ctx context.Context, stream Stream) context.Context {
+	return context.WithValue(ctx, streamKey{}, stream)
+}
+
+// StreamFromContext returns the stream of the current call, or a stream that discards
+// everything if the call is not streamed
+func StreamFromContext(ctx context.Context) Stream {
+	if stream, ok := ctx.Value(streamKey{}).(Stream); ok && stream != nil {
+		return stream
+	}
+	return discardStream{}
+}
+
+// discardStream is the Stream of calls made without a streaming transport
+type discardStream struct{}
+
+func (discardStream) Send(partial any) error                                 { return nil }
+func (discardStream) Progress(progress, total float64, message string) error { return nil }
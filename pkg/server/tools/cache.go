@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// maxCachedResults bounds the results kept by an executor's result cache
+const maxCachedResults = 4096
+
+// WithPureMethod marks a method as pure: its result depends on its params alone and calling
+// it has no side effects. Successful results of pure methods are cached for ttl, keyed on
+// the method and its canonicalized params, and repeated calls are answered from the cache
+// without running the method. Errors are not cached. Methods that read the caller's
+// principal or other context values are not pure.
+func WithPureMethod(methodName string, ttl time.Duration) ServiceOpts {
+	return func(c *serviceConfig) {
+		c.cacheTTLs[methodName] = ttl
+	}
+}
+
+// canonicalParams returns a key identifying a method and its params. Object keys are
+// sorted by encoding/json, so params that differ only in key order share a key.
+func canonicalParams(serviceName, methodName string, params map[string]interface{}) (string, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(serviceName+"."+methodName+"\x00"), encoded...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// resultCache holds the results of pure methods until they expire
+type resultCache struct {
+	mutex   sync.Mutex
+	now     func() time.Time
+	entries map[string]cachedResult // Key: canonicalParams
+}
+
+// cachedResult is a result and the time it expires
+type cachedResult struct {
+	result  interface{}
+	expires time.Time
+}
+
+// newResultCache creates an empty result cache
+func newResultCache() *resultCache {
+	return &resultCache{now: time.Now, entries: make(map[string]cachedResult)}
+}
+
+// get returns the cached result for key, if it has not expired
+func (c *resultCache) get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// put caches result for ttl. When the cache is full, expired results are dropped first,
+// then those closest to expiry.
+func (c *resultCache) put(key string, result interface{}, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxCachedResults {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	for len(c.entries) >= maxCachedResults {
+		oldest := ""
+		for k, entry := range c.entries {
+			if oldest == "" || entry.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cachedResult{result: result, expires: now.Add(ttl)}
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// PureService counts how often its methods run
+type PureService struct {
+	calls int
+}
+
+func (s *PureService) Add(req AddRequest, resp *AddResponse) error {
+	s.calls++
+	if req.A < 0 {
+		return fmt.Errorf("negative operand")
+	}
+	resp.Result = req.A + req.B
+	return nil
+}
+
+func (s *PureService) Sum(req AddRequest, resp *AddResponse) error {
+	s.calls++
+	resp.Result = req.A + req.B
+	return nil
+}
+
+func TestWithPureMethod(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		calls         []map[string]interface{}
+		advance       time.Duration
+		expectedCalls int
+	}{
+		{
+			name:          "same_params_in_any_order_are_cached",
+			method:        "Add",
+			calls:         []map[string]interface{}{{"a": 1, "b": 2}, {"b": 2, "a": 1}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "different_params_run_again",
+			method:        "Add",
+			calls:         []map[string]interface{}{{"a": 1, "b": 2}, {"a": 1, "b": 3}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "errors_are_not_cached",
+			method:        "Add",
+			calls:         []map[string]interface{}{{"a": -1, "b": 2}, {"a": -1, "b": 2}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "expired_results_run_again",
+			method:        "Add",
+			calls:         []map[string]interface{}{{"a": 1, "b": 2}, {"a": 1, "b": 2}},
+			advance:       time.Minute,
+			expectedCalls: 2,
+		},
+		{
+			name:          "methods_not_marked_pure_are_not_cached",
+			method:        "Sum",
+			calls:         []map[string]interface{}{{"a": 1, "b": 2}, {"a": 1, "b": 2}},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1700000000, 0)
+			executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+			executor.cache.now = func() time.Time { return now }
+			service := &PureService{}
+			if err := executor.RegisterService(service, WithPureMethod("Add", time.Minute)); err != nil {
+				t.Fatalf("failed to register service: %v", err)
+			}
+
+			for _, params := range tt.calls {
+				result, err := executor.ExecuteMethod("PureService", tt.method, params)
+				if err == nil && result.(AddResponse).Result != params["a"].(int)+params["b"].(int) {
+					t.Errorf("unexpected result %v for %v", result, params)
+				}
+				now = now.Add(tt.advance)
+			}
+
+			if service.calls != tt.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tt.expectedCalls, service.calls)
+			}
+		})
+	}
+}
+
+func TestResultCache_Eviction(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache := newResultCache()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < maxCachedResults; i++ {
+		cache.put(fmt.Sprint(i), i, time.Hour+time.Duration(i)*time.Second)
+	}
+	cache.put("new", "new", time.Hour)
+
+	if len(cache.entries) != maxCachedResults {
+		t.Errorf("expected %d entries, got %d", maxCachedResults, len(cache.entries))
+	}
+	if _, ok := cache.get("0"); ok {
+		t.Error("expected the result closest to expiry to be evicted")
+	}
+	if result, ok := cache.get("new"); !ok || result != "new" {
+		t.Errorf("expected new result to be cached, got %v", result)
+	}
+}
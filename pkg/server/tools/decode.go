@@ -0,0 +1,491 @@
+package tools
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError reports a parameter value that could not be decoded into the
+// method's request type. Path is the location of the offending value using
+// JSON names, e.g. "items[3].address.zip".
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// fieldInfo describes a struct field as seen by the JSON encoding rules
+type fieldInfo struct {
+	Name      string // JSON name
+	Index     []int  // index sequence for reflect.Value.FieldByIndex
+	Type      reflect.Type
+	OmitEmpty bool
+	Tag       reflect.StructTag
+}
+
+// parseJSONTag splits a json struct tag into its name and omitempty option
+func parseJSONTag(tag string) (string, bool) {
+	name, opts, _ := strings.Cut(tag, ",")
+	omitEmpty := false
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
+
+// structFields returns the fields of a struct type following encoding/json rules:
+// "-" fields are skipped, unexported fields are ignored and the fields of untagged
+// embedded structs are promoted. Shallower fields win over deeper ones with the same name;
+// of several fields with the same name at the same depth, a single tagged one wins, and
+// otherwise they are all ignored.
+func structFields(t reflect.Type) []fieldInfo {
+	var fields []fieldInfo
+	seen := make(map[string]bool)
+
+	type level struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []level{{typ: t}}
+	visited := map[reflect.Type]bool{}
+
+	for len(current) > 0 {
+		var next []level
+		candidates := make(map[string][]candidate) // Key: JSON name
+
+		for _, l := range current {
+			// A struct embedded twice at this depth yields each of its fields twice, so
+			// they conflict with each other and are ignored
+			if visited[l.typ] {
+				continue
+			}
+
+			for i := 0; i < l.typ.NumField(); i++ {
+				sf := l.typ.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, omitEmpty := parseJSONTag(tag)
+
+				index := make([]int, len(l.index)+1)
+				copy(index, l.index)
+				index[len(l.index)] = i
+
+				if sf.Anonymous && name == "" {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						next = append(next, level{typ: ft, index: index})
+						continue
+					}
+				}
+				if !sf.IsExported() {
+					continue
+				}
+
+				field := fieldInfo{
+					Name:      name,
+					Index:     index,
+					Type:      sf.Type,
+					OmitEmpty: omitEmpty,
+					Tag:       sf.Tag,
+				}
+				if name == "" {
+					field.Name = sf.Name
+				}
+				if seen[field.Name] {
+					continue
+				}
+				candidates[field.Name] = append(candidates[field.Name], candidate{field: field, tagged: name != ""})
+			}
+		}
+
+		for _, l := range current {
+			visited[l.typ] = true
+		}
+		for name, named := range candidates {
+			seen[name] = true
+			if field, ok := dominantField(named); ok {
+				fields = append(fields, field)
+			}
+		}
+		current = next
+	}
+
+	// Report fields in declaration order, with promoted fields in place of their embedded struct
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].Index, fields[j].Index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	return fields
+}
+
+// candidate is a field competing for a JSON name at one embedding depth
+type candidate struct {
+	field  fieldInfo
+	tagged bool // Whether the name comes from a json tag
+}
+
+// dominantField picks the field a JSON name refers to among the candidates at the shallowest
+// depth: the only one, or the only tagged one. Otherwise the name is ambiguous and
+// encoding/json ignores all of them.
+func dominantField(candidates []candidate) (fieldInfo, bool) {
+	if len(candidates) == 1 {
+		return candidates[0].field, true
+	}
+	var dominant []fieldInfo
+	for _, c := range candidates {
+		if c.tagged {
+			dominant = append(dominant, c.field)
+		}
+	}
+	if len(dominant) == 1 {
+		return dominant[0], true
+	}
+	return fieldInfo{}, false
+}
+
+// joinPath appends a field name to a parameter path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// indexPath appends a slice index to a parameter path
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// fieldByIndex returns the nested field for index, allocating nil embedded pointers on the way.
+// As with encoding/json, a nil pointer to an unexported embedded struct cannot be allocated
+// and is reported as an error.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct: %v", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// decodeValue decodes a generic JSON-like value (as produced by encoding/json or
+// built by hand in Go) into v, recursing through structs, slices, maps and pointers.
+func decodeValue(v reflect.Value, value any, path string) error {
+	if value == nil {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(v.Elem(), value, path)
+	}
+
+	if v.CanAddr() {
+		ptr := v.Addr()
+		if ptr.Type().Implements(jsonUnmarshalerType) {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return &FieldError{Path: path, Err: err}
+			}
+			if err := ptr.Interface().(json.Unmarshaler).UnmarshalJSON(data); err != nil {
+				return &FieldError{Path: path, Err: err}
+			}
+			return nil
+		}
+		if ptr.Type().Implements(textUnmarshalerType) {
+			if s, ok := value.(string); ok {
+				if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+					return &FieldError{Path: path, Err: err}
+				}
+				return nil
+			}
+		}
+	}
+
+	rv := reflect.ValueOf(value)
+	if v.Kind() != reflect.Interface && rv.Type().AssignableTo(v.Type()) {
+		v.Set(rv)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot decode into non-empty interface %v", v.Type())}
+		}
+		v.Set(rv)
+	case reflect.String:
+		if rv.Kind() != reflect.String {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to string", value)}
+		}
+		v.SetString(rv.String())
+	case reflect.Bool:
+		if rv.Kind() != reflect.Bool {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to bool", value)}
+		}
+		v.SetBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt(rv)
+		if err != nil || v.OverflowInt(n) {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to %v", value, v.Type())}
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := toUint(rv)
+		if err != nil || v.OverflowUint(n) {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to %v", value, v.Type())}
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(rv)
+		if err != nil || v.OverflowFloat(f) {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to %v", value, v.Type())}
+		}
+		v.SetFloat(f)
+	case reflect.Struct:
+		return decodeStruct(v, rv, path)
+	case reflect.Map:
+		return decodeMap(v, rv, path)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && rv.Kind() == reflect.String {
+			b, err := base64.StdEncoding.DecodeString(rv.String())
+			if err != nil {
+				return &FieldError{Path: path, Err: fmt.Errorf("invalid base64 data: %w", err)}
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to %v", value, v.Type())}
+		}
+		slice := reflect.MakeSlice(v.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if err := decodeValue(slice.Index(i), rv.Index(i).Interface(), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to %v", value, v.Type())}
+		}
+		if rv.Len() > v.Len() {
+			return &FieldError{Path: path, Err: fmt.Errorf("too many elements for %v: got %d", v.Type(), rv.Len())}
+		}
+		v.Set(reflect.Zero(v.Type()))
+		for i := 0; i < rv.Len(); i++ {
+			if err := decodeValue(v.Index(i), rv.Index(i).Interface(), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return &FieldError{Path: path, Err: fmt.Errorf("unsupported field type: %v", v.Kind())}
+	}
+
+	return nil
+}
+
+// decodeStruct decodes an object with string keys into the struct v
+func decodeStruct(v reflect.Value, rv reflect.Value, path string) error {
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to %v", rv.Interface(), v.Type())}
+	}
+
+	fields := structFields(v.Type())
+	for _, key := range rv.MapKeys() {
+		name := key.String()
+		field, ok := lookupField(fields, name)
+		if !ok {
+			// Unknown fields are ignored, as with encoding/json
+			continue
+		}
+		fieldPath := joinPath(path, field.Name)
+		fv, err := fieldByIndex(v, field.Index)
+		if err != nil {
+			return &FieldError{Path: fieldPath, Err: err}
+		}
+		if err := decodeValue(fv, rv.MapIndex(key).Interface(), fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupField finds a field by exact name, falling back to a case-insensitive match
+func lookupField(fields []fieldInfo, name string) (fieldInfo, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return fieldInfo{}, false
+}
+
+// decodeMap decodes an object into the map v, converting keys to the map's key type
+func decodeMap(v reflect.Value, rv reflect.Value, path string) error {
+	if rv.Kind() != reflect.Map {
+		return &FieldError{Path: path, Err: fmt.Errorf("cannot convert %v to %v", rv.Interface(), v.Type())}
+	}
+
+	mapType := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(mapType, rv.Len()))
+	}
+
+	for _, key := range rv.MapKeys() {
+		keyString := fmt.Sprint(key.Interface())
+		keyPath := joinPath(path, keyString)
+
+		mapKey := reflect.New(mapType.Key()).Elem()
+		if err := decodeMapKey(mapKey, keyString); err != nil {
+			return &FieldError{Path: keyPath, Err: err}
+		}
+
+		elem := reflect.New(mapType.Elem()).Elem()
+		if err := decodeValue(elem, rv.MapIndex(key).Interface(), keyPath); err != nil {
+			return err
+		}
+		v.SetMapIndex(mapKey, elem)
+	}
+
+	return nil
+}
+
+// decodeMapKey converts an object key into a map key of the target type
+func decodeMapKey(k reflect.Value, s string) error {
+	if reflect.PointerTo(k.Type()).Implements(textUnmarshalerType) {
+		return k.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch k.Kind() {
+	case reflect.String:
+		k.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || k.OverflowInt(n) {
+			return fmt.Errorf("invalid map key %q for %v", s, k.Type())
+		}
+		k.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || k.OverflowUint(n) {
+			return fmt.Errorf("invalid map key %q for %v", s, k.Type())
+		}
+		k.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported map key type: %v", k.Type())
+	}
+
+	return nil
+}
+
+// toInt converts a numeric value to int64, rejecting fractional floats
+func toInt(rv reflect.Value) (int64, error) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value out of range")
+		}
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+			return 0, fmt.Errorf("not an integer")
+		}
+		return int64(f), nil
+	case reflect.String:
+		if n, ok := rv.Interface().(json.Number); ok {
+			return n.Int64()
+		}
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+// toUint converts a numeric value to uint64, rejecting negative and fractional values
+func toUint(rv reflect.Value) (uint64, error) {
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	}
+	n, err := toInt(rv)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative value")
+	}
+	return uint64(n), nil
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(rv reflect.Value) (float64, error) {
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.String:
+		if n, ok := rv.Interface().(json.Number); ok {
+			return n.Float64()
+		}
+	}
+	return 0, fmt.Errorf("not a number")
+}
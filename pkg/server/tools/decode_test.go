@@ -0,0 +1,507 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeStruct(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         map[string]interface{}
+		target        reflect.Value
+		expectedError bool
+	}{
+		{
+			name: "valid_string_field",
+			input: map[string]interface{}{
+				"name": "test",
+			},
+			target:        reflect.ValueOf(HelloRequest{}),
+			expectedError: false,
+		},
+		{
+			name: "valid_int_field",
+			input: map[string]interface{}{
+				"a": 5,
+				"b": 3,
+			},
+			target:        reflect.ValueOf(AddRequest{}),
+			expectedError: false,
+		},
+		{
+			name: "valid_float_to_int_conversion",
+			input: map[string]interface{}{
+				"a": 5.0,
+				"b": 3.0,
+			},
+			target:        reflect.ValueOf(AddRequest{}),
+			expectedError: false,
+		},
+		{
+			name: "invalid_field_type",
+			input: map[string]interface{}{
+				"name": 123, // int instead of string
+			},
+			target:        reflect.ValueOf(HelloRequest{}),
+			expectedError: true,
+		},
+		{
+			name: "missing_field",
+			input: map[string]interface{}{
+				"other": "value",
+			},
+			target:        reflect.ValueOf(HelloRequest{}),
+			expectedError: false, // Missing fields are ignored
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a new instance of the target type
+			targetValue := reflect.New(tt.target.Type()).Elem()
+
+			err := decodeStruct(targetValue, reflect.ValueOf(tt.input), "")
+
+			if tt.expectedError && err == nil {
+				t.Error("expected error but got none")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			// For successful cases, verify the field was set correctly
+			if !tt.expectedError && err == nil {
+				if tt.name == "valid_string_field" {
+					field := targetValue.FieldByName("Name")
+					if field.String() != "test" {
+						t.Errorf("expected field value 'test', got '%s'", field.String())
+					}
+				} else if tt.name == "valid_int_field" || tt.name == "valid_float_to_int_conversion" {
+					fieldA := targetValue.FieldByName("A")
+					fieldB := targetValue.FieldByName("B")
+					if fieldA.Int() != 5 || fieldB.Int() != 3 {
+						t.Errorf("expected field values 5 and 3, got %d and %d", fieldA.Int(), fieldB.Int())
+					}
+				}
+			}
+		})
+	}
+}
+
+// Nested request types used to exercise recursive decoding
+type decodeAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip"`
+}
+
+type decodeItem struct {
+	SKU      string         `json:"sku"`
+	Quantity uint           `json:"quantity"`
+	Address  *decodeAddress `json:"address,omitempty"`
+}
+
+type decodeAudit struct {
+	CreatedBy string `json:"createdBy"`
+}
+
+type decodeLevel int
+
+func (l *decodeLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+type decodeOrder struct {
+	decodeAudit
+	ID       string            `json:"id"`
+	Items    []decodeItem      `json:"items"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Counts   map[int]float64   `json:"counts"`
+	Due      time.Time         `json:"due"`
+	Level    decodeLevel       `json:"level"`
+	Internal string            `json:"-"`
+	Extra    any               `json:"extra"`
+}
+
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		expectedError bool
+		errorPath     string
+		check         func(t *testing.T, order decodeOrder)
+	}{
+		{
+			name: "nested_structs_slices_and_maps",
+			params: map[string]interface{}{
+				"id":        "order-1",
+				"createdBy": "agent",
+				"items": []interface{}{
+					map[string]interface{}{
+						"sku":      "A1",
+						"quantity": float64(2),
+						"address":  map[string]interface{}{"street": "Main", "zip": "12345"},
+					},
+				},
+				"tags":   map[string]interface{}{"priority": "high"},
+				"counts": map[string]interface{}{"1": 1.5},
+				"due":    "2024-01-02T03:04:05Z",
+				"level":  "high",
+				"extra":  map[string]interface{}{"free": "form"},
+			},
+			check: func(t *testing.T, order decodeOrder) {
+				if order.ID != "order-1" || order.CreatedBy != "agent" {
+					t.Errorf("unexpected top-level fields: %+v", order)
+				}
+				if len(order.Items) != 1 || order.Items[0].Quantity != 2 || order.Items[0].Address == nil || order.Items[0].Address.Zip != "12345" {
+					t.Errorf("unexpected items: %+v", order.Items)
+				}
+				if order.Tags["priority"] != "high" {
+					t.Errorf("expected tag priority=high, got %v", order.Tags)
+				}
+				if order.Counts[1] != 1.5 {
+					t.Errorf("expected counts[1]=1.5, got %v", order.Counts)
+				}
+				if !order.Due.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+					t.Errorf("unexpected due time: %v", order.Due)
+				}
+				if order.Level != 2 {
+					t.Errorf("expected level 2, got %d", order.Level)
+				}
+				if _, ok := order.Extra.(map[string]interface{}); !ok {
+					t.Errorf("expected extra to be a map, got %T", order.Extra)
+				}
+			},
+		},
+		{
+			name: "ignored_dash_field",
+			params: map[string]interface{}{
+				"Internal": "secret",
+				"-":        "secret",
+			},
+			check: func(t *testing.T, order decodeOrder) {
+				if order.Internal != "" {
+					t.Errorf("expected Internal to be ignored, got %q", order.Internal)
+				}
+			},
+		},
+		{
+			name: "nested_error_path",
+			params: map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"sku": "A1"},
+					map[string]interface{}{"sku": "B2", "address": map[string]interface{}{"zip": 12345}},
+				},
+			},
+			expectedError: true,
+			errorPath:     "items[1].address.zip",
+		},
+		{
+			name: "text_unmarshaler_error_path",
+			params: map[string]interface{}{
+				"level": "medium",
+			},
+			expectedError: true,
+			errorPath:     "level",
+		},
+		{
+			name: "json_unmarshaler_error_path",
+			params: map[string]interface{}{
+				"due": "not a time",
+			},
+			expectedError: true,
+			errorPath:     "due",
+		},
+		{
+			name: "invalid_map_key",
+			params: map[string]interface{}{
+				"counts": map[string]interface{}{"one": 1.0},
+			},
+			expectedError: true,
+			errorPath:     "counts.one",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order decodeOrder
+			err := decodeValue(reflect.ValueOf(&order).Elem(), tt.params, "")
+
+			if tt.expectedError && err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if !tt.expectedError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectedError {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) {
+					t.Fatalf("expected *FieldError, got %T", err)
+				}
+				if fieldErr.Path != tt.errorPath {
+					t.Errorf("expected error path %q, got %q", tt.errorPath, fieldErr.Path)
+				}
+				return
+			}
+
+			if tt.check != nil {
+				tt.check(t, order)
+			}
+		})
+	}
+}
+
+func TestDecodeValue_Conversions(t *testing.T) {
+	tests := []struct {
+		name          string
+		fieldType     reflect.Type
+		value         interface{}
+		expectedError bool
+	}{
+		{
+			name:          "set_string_field",
+			fieldType:     reflect.TypeOf(""),
+			value:         "test",
+			expectedError: false,
+		},
+		{
+			name:          "set_int_field",
+			fieldType:     reflect.TypeOf(0),
+			value:         42,
+			expectedError: false,
+		},
+		{
+			name:          "set_float_field",
+			fieldType:     reflect.TypeOf(0.0),
+			value:         3.14,
+			expectedError: false,
+		},
+		{
+			name:          "set_bool_field",
+			fieldType:     reflect.TypeOf(false),
+			value:         true,
+			expectedError: false,
+		},
+		{
+			name:          "set_int_from_float",
+			fieldType:     reflect.TypeOf(0),
+			value:         42.0,
+			expectedError: false,
+		},
+		{
+			name:          "set_string_from_int_error",
+			fieldType:     reflect.TypeOf(""),
+			value:         42,
+			expectedError: true,
+		},
+		{
+			name:          "set_int_from_string_error",
+			fieldType:     reflect.TypeOf(0),
+			value:         "not_a_number",
+			expectedError: true,
+		},
+		{
+			name:          "set_slice_field",
+			fieldType:     reflect.TypeOf([]string{}),
+			value:         []interface{}{"test"},
+			expectedError: false,
+		},
+		{
+			name:          "set_uint_from_negative_error",
+			fieldType:     reflect.TypeOf(uint(0)),
+			value:         -1.0,
+			expectedError: true,
+		},
+		{
+			name:          "set_int_from_fractional_float_error",
+			fieldType:     reflect.TypeOf(0),
+			value:         4.5,
+			expectedError: true,
+		},
+		{
+			name:          "set_unsupported_type",
+			fieldType:     reflect.TypeOf(make(chan int)),
+			value:         "test",
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a field of the specified type
+			fieldValue := reflect.New(tt.fieldType).Elem()
+
+			err := decodeValue(fieldValue, tt.value, "")
+
+			if tt.expectedError && err == nil {
+				t.Error("expected error but got none")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			// For successful cases, verify the value was set correctly
+			if !tt.expectedError && err == nil {
+				switch tt.fieldType.Kind() {
+				case reflect.String:
+					if fieldValue.String() != tt.value.(string) {
+						t.Errorf("expected string value %s, got %s", tt.value.(string), fieldValue.String())
+					}
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					var expected int64
+					switch v := tt.value.(type) {
+					case int:
+						expected = int64(v)
+					case float64:
+						expected = int64(v)
+					default:
+						t.Errorf("unexpected value type: %T", tt.value)
+						return
+					}
+					if fieldValue.Int() != expected {
+						t.Errorf("expected int value %d, got %d", expected, fieldValue.Int())
+					}
+				case reflect.Float32, reflect.Float64:
+					if fieldValue.Float() != tt.value.(float64) {
+						t.Errorf("expected float value %f, got %f", tt.value.(float64), fieldValue.Float())
+					}
+				case reflect.Bool:
+					if fieldValue.Bool() != tt.value.(bool) {
+						t.Errorf("expected bool value %t, got %t", tt.value.(bool), fieldValue.Bool())
+					}
+				}
+			}
+		})
+	}
+}
+
+type decodeInner struct {
+	Note string `json:"note"`
+}
+
+type decodeEmbeddingRequest struct {
+	*decodeInner
+	ID int `json:"id"`
+}
+
+func TestDecodeStruct_UnexportedEmbeddedPointer(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         map[string]interface{}
+		expectedError bool
+	}{
+		{
+			name:          "promoted_field_returns_field_error",
+			input:         map[string]interface{}{"id": 1, "note": "hello"},
+			expectedError: true,
+		},
+		{
+			name:          "own_field_decodes",
+			input:         map[string]interface{}{"id": 1},
+			expectedError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req decodeEmbeddingRequest
+			err := decodeStruct(reflect.ValueOf(&req).Elem(), reflect.ValueOf(tt.input), "")
+
+			if !tt.expectedError {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if req.ID != 1 {
+					t.Errorf("expected id 1, got %d", req.ID)
+				}
+				return
+			}
+
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("expected a *FieldError, got %v", err)
+			}
+			if fieldErr.Path != "note" {
+				t.Errorf("expected path note, got %q", fieldErr.Path)
+			}
+		})
+	}
+}
+
+// Embedded structs whose fields compete for the same JSON name
+type (
+	conflictFirst struct {
+		Name string
+		Note string
+	}
+	conflictSecond struct {
+		Name string
+	}
+	conflictTagged struct {
+		Title string `json:"Name"`
+	}
+	conflictBothUntagged struct {
+		conflictFirst
+		conflictSecond
+	}
+	conflictOneTagged struct {
+		conflictSecond
+		conflictTagged
+	}
+	conflictShallowerWins struct {
+		conflictBothUntagged
+		Name string
+	}
+	conflictWrapperA      struct{ conflictSecond }
+	conflictWrapperB      struct{ conflictSecond }
+	conflictSameTypeTwice struct {
+		conflictWrapperA
+		conflictWrapperB
+	}
+)
+
+func TestDecodeStruct_FieldNameConflicts(t *testing.T) {
+	input := map[string]interface{}{"Name": "ada", "Note": "hi"}
+	encoded, _ := json.Marshal(input)
+
+	tests := []struct {
+		name   string
+		target any
+	}{
+		{name: "untagged_fields_at_same_depth_are_ignored", target: &conflictBothUntagged{}},
+		{name: "single_tagged_field_wins", target: &conflictOneTagged{}},
+		{name: "shallower_field_wins", target: &conflictShallowerWins{}},
+		{name: "struct_embedded_twice_at_same_depth", target: &conflictSameTypeTwice{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// encoding/json is the reference for which field a name refers to
+			expected := reflect.New(reflect.TypeOf(tt.target).Elem())
+			if err := json.Unmarshal(encoded, expected.Interface()); err != nil {
+				t.Fatalf("encoding/json failed: %v", err)
+			}
+
+			got := reflect.ValueOf(tt.target).Elem()
+			if err := decodeStruct(got, reflect.ValueOf(input), ""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got.Interface(), expected.Elem().Interface()) {
+				t.Errorf("expected %+v as decoded by encoding/json, got %+v", expected.Elem().Interface(), got.Interface())
+			}
+		})
+	}
+}
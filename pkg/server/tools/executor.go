@@ -1,9 +1,22 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	streamType  = reflect.TypeOf((*server.Stream)(nil)).Elem()
 )
 
 // ServiceRegistry defines the interface for service registration
@@ -11,44 +24,207 @@ type ServiceRegistry interface {
 	Register(service any) error
 }
 
+// ServiceHandler executes the methods of a service that is not backed by Go methods,
+// such as tools forwarded to a remote server
+type ServiceHandler interface {
+	ExecuteMethodContext(ctx context.Context, methodName string, params map[string]interface{}) (interface{}, error)
+}
+
+// ServiceOpts defines options applied when a service is registered
+type ServiceOpts func(*serviceConfig)
+
+// serviceConfig holds per-service execution and description settings
+type serviceConfig struct {
+	timeout        time.Duration            // default for every method of the service
+	methodTimeouts map[string]time.Duration // Key: method name
+	cacheTTLs      map[string]time.Duration // Key: method name of a pure method
+	descriptions   map[string]string        // Key: method name
+}
+
+// newServiceConfig applies opts to an empty service configuration
+func newServiceConfig(opts ...ServiceOpts) serviceConfig {
+	config := serviceConfig{
+		methodTimeouts: make(map[string]time.Duration),
+		cacheTTLs:      make(map[string]time.Duration),
+		descriptions:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// WithTimeout sets the maximum execution time for every method of the service
+func WithTimeout(d time.Duration) ServiceOpts {
+	return func(c *serviceConfig) {
+		c.timeout = d
+	}
+}
+
+// WithMethodTimeout sets the maximum execution time for a single method of the service,
+// overriding any service-wide timeout
+func WithMethodTimeout(methodName string, d time.Duration) ServiceOpts {
+	return func(c *serviceConfig) {
+		c.methodTimeouts[methodName] = d
+	}
+}
+
+// WithMethodDescription sets the tool description published for a method when the
+// service is described with ToolService.DescribeService
+func WithMethodDescription(methodName, description string) ServiceOpts {
+	return func(c *serviceConfig) {
+		c.descriptions[methodName] = description
+	}
+}
+
 // JSONRPCMethodExecutor implements MethodExecutor using a service registry
 type JSONRPCMethodExecutor struct {
 	registry ServiceRegistry
 	services map[string]any
+	handlers map[string]ServiceHandler // Key: service name
+	configs  map[string]serviceConfig  // Key: service name
+	cache    *resultCache
+	logger   *server.Logger
+	mutex    sync.RWMutex
 }
 
+// JSONRPCMethodExecutorOpts defines options for configuring the method executor
+type JSONRPCMethodExecutorOpts func(*JSONRPCMethodExecutor)
+
 // NewJSONRPCMethodExecutor creates a new JSON-RPC method executor
-func NewJSONRPCMethodExecutor(registry ServiceRegistry) *JSONRPCMethodExecutor {
-	return &JSONRPCMethodExecutor{
+func NewJSONRPCMethodExecutor(registry ServiceRegistry, opts ...JSONRPCMethodExecutorOpts) *JSONRPCMethodExecutor {
+	e := &JSONRPCMethodExecutor{
 		registry: registry,
 		services: make(map[string]any),
+		handlers: make(map[string]ServiceHandler),
+		configs:  make(map[string]serviceConfig),
+		cache:    newResultCache(),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
 }
 
-// RegisterService registers a service with the registry
-func (e *JSONRPCMethodExecutor) RegisterService(service any) error {
+// RegisterService registers a service with the registry.
+// Methods may take a context.Context as their first argument, in which case they
+// receive the caller's context, and a server.Stream between the request and the
+// response pointer, in which case they can stream partial results. Because net/rpc
+// cannot call such methods, a service made up only of them is not registered with
+// the registry and is reachable through the executor alone.
+func (e *JSONRPCMethodExecutor) RegisterService(service any, opts ...ServiceOpts) error {
 	// Register with registry for validation
-	if err := e.registry.Register(service); err != nil {
-		return err
+	if !hasOnlyExecutorMethods(service) {
+		if err := e.registry.Register(service); err != nil {
+			return err
+		}
 	}
 
+	config := newServiceConfig(opts...)
+
 	// Also store locally for direct access
 	serviceType := reflect.TypeOf(service)
 	if serviceType.Kind() == reflect.Ptr {
 		serviceType = serviceType.Elem()
 	}
 	serviceName := serviceType.Name()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
 	e.services[serviceName] = service
+	delete(e.handlers, serviceName)
+	e.configs[serviceName] = config
+	e.logger.Event(context.Background(), "service registered", slog.String("service", serviceName))
+
+	return nil
+}
+
+// RegisterServiceHandler registers handler to execute every method of serviceName.
+// Handler services are not registered with the registry, so they are reachable
+// through the executor alone. Timeouts from opts apply as for RegisterService.
+func (e *JSONRPCMethodExecutor) RegisterServiceHandler(serviceName string, handler ServiceHandler, opts ...ServiceOpts) error {
+	if serviceName == "" || strings.Contains(serviceName, ".") {
+		return fmt.Errorf("invalid service name '%s'", serviceName)
+	}
+	if handler == nil {
+		return fmt.Errorf("cannot register nil handler for service '%s'", serviceName)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if _, exists := e.services[serviceName]; exists {
+		return fmt.Errorf("service '%s' is already registered", serviceName)
+	}
+	e.handlers[serviceName] = handler
+	e.configs[serviceName] = newServiceConfig(opts...)
+	e.logger.Event(context.Background(), "service registered", slog.String("service", serviceName), slog.Bool("handler", true))
 
 	return nil
 }
 
 // ExecuteMethod executes a method by directly calling the registered service
 func (e *JSONRPCMethodExecutor) ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	return e.ExecuteMethodContext(context.Background(), serviceName, methodName, params)
+}
+
+// ExecuteMethodContext executes a method by directly calling the registered service.
+// Context-aware methods receive ctx, bounded by any timeout configured at registration,
+// and streaming methods receive the stream carried by ctx (see server.StreamFromContext).
+// If ctx is done before the method returns, the call is abandoned and ctx's error is returned.
+// Results of pure methods are served from the cache while they are fresh, see WithPureMethod.
+// Failures are classified as server.ErrServiceNotFound, server.ErrMethodNotFound,
+// server.ErrInvalidParams or, for errors returned by the method, server.ErrToolFailed.
+// A panic of the method is recovered and returned as a *server.PanicError.
+func (e *JSONRPCMethodExecutor) ExecuteMethodContext(ctx context.Context, serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	start := time.Now()
+	e.logCallStart(ctx, serviceName, methodName, params)
+	result, cached, err := e.executeCached(ctx, serviceName, methodName, params)
+	e.logCallEnd(ctx, serviceName, methodName, start, result, cached, err)
+	return result, err
+}
+
+// executeCached runs a method, answering calls of pure methods from the cache when possible.
+// cached reports whether the result came from the cache.
+func (e *JSONRPCMethodExecutor) executeCached(ctx context.Context, serviceName, methodName string, params map[string]interface{}) (result interface{}, cached bool, err error) {
+	e.mutex.RLock()
+	config := e.configs[serviceName]
+	e.mutex.RUnlock()
+
+	ttl := config.cacheTTLs[methodName]
+	if ttl <= 0 {
+		result, err = e.executeMethod(ctx, config, serviceName, methodName, params)
+		return result, false, err
+	}
+
+	key, err := canonicalParams(serviceName, methodName, params)
+	if err != nil {
+		result, err = e.executeMethod(ctx, config, serviceName, methodName, params)
+		return result, false, err
+	}
+	if result, ok := e.cache.get(key); ok {
+		return result, true, nil
+	}
+	result, err = e.executeMethod(ctx, config, serviceName, methodName, params)
+	if err == nil {
+		e.cache.put(key, result, ttl)
+	}
+	return result, false, err
+}
+
+// executeMethod runs a method of a registered service or handler
+func (e *JSONRPCMethodExecutor) executeMethod(ctx context.Context, config serviceConfig, serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
 	// Get the service
+	e.mutex.RLock()
 	service, exists := e.services[serviceName]
+	handler, isHandler := e.handlers[serviceName]
+	e.mutex.RUnlock()
+	if isHandler {
+		return e.executeHandler(ctx, handler, config, serviceName, methodName, params)
+	}
 	if !exists {
-		return nil, fmt.Errorf("service '%s' not found", serviceName)
+		return nil, server.ClassifyError(server.ErrServiceNotFound, fmt.Errorf("service '%s' not found", serviceName))
 	}
 
 	// Get the service value
@@ -57,111 +233,199 @@ func (e *JSONRPCMethodExecutor) ExecuteMethod(serviceName, methodName string, pa
 	// Find the method
 	method := serviceValue.MethodByName(methodName)
 	if !method.IsValid() {
-		return nil, fmt.Errorf("method '%s' not found in service '%s'", methodName, serviceName)
+		return nil, server.ClassifyError(server.ErrMethodNotFound, fmt.Errorf("method '%s' not found in service '%s'", methodName, serviceName))
 	}
 
 	// Get method type
 	methodType := method.Type()
 
-	// Check if method has the correct signature (should have 2 parameters: request and response pointer)
-	if methodType.NumIn() != 2 {
+	// Check if method has the correct signature: request and response pointer,
+	// optionally preceded by a context.Context and separated by a server.Stream
+	signature, ok := parseSignature(methodType)
+	if !ok {
 		return nil, fmt.Errorf("method '%s' must have exactly 2 parameters (request and response pointer)", methodName)
 	}
 
 	// Create request parameter
-	requestType := methodType.In(0)
-	requestValue := reflect.New(requestType).Elem()
+	requestValue := reflect.New(signature.request).Elem()
 
-	// Convert params map to request struct
-	if err := e.mapToStruct(params, requestValue); err != nil {
-		return nil, fmt.Errorf("failed to convert parameters to request: %w", err)
+	// Decode params into the request type
+	if err := decodeValue(requestValue, params, ""); err != nil {
+		e.logger.Warn(ctx, "params decode failed",
+			slog.String("tool", serviceName+"."+methodName),
+			slog.Any("error", err),
+		)
+		return nil, server.ClassifyError(server.ErrInvalidParams, fmt.Errorf("failed to convert parameters to request: %w", err))
 	}
 
 	// Create response parameter
-	responseType := methodType.In(1)
-	responseValue := reflect.New(responseType.Elem())
+	responseValue := reflect.New(signature.response.Elem())
+
+	// Apply the configured timeout, if any
+	if timeout := config.timeoutFor(methodName); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	args := []reflect.Value{requestValue}
+	if signature.takesContext {
+		args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+	if signature.takesStream {
+		args = append(args, reflect.ValueOf(server.StreamFromContext(ctx)))
+	}
+	args = append(args, responseValue)
 
-	// Call the method
-	results := method.Call([]reflect.Value{requestValue, responseValue})
+	// Call the method; a panic is only read once the call has completed
+	var panicErr error
+	results, err := callWithContext(ctx, func() []reflect.Value {
+		defer e.recoverPanic(ctx, serviceName, methodName, &panicErr)
+		return method.Call(args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("method '%s' in service '%s' did not complete: %w", methodName, serviceName, err)
+	}
+	if panicErr != nil {
+		return nil, panicErr
+	}
 
 	// Check for errors
 	if len(results) > 0 && !results[0].IsNil() {
-		return nil, results[0].Interface().(error)
+		return nil, server.ClassifyError(server.ErrToolFailed, results[0].Interface().(error))
 	}
 
 	// Return the response
 	return responseValue.Elem().Interface(), nil
 }
 
-// mapToStruct converts a map to a struct using reflection
-func (e *JSONRPCMethodExecutor) mapToStruct(m map[string]interface{}, v reflect.Value) error {
-	if v.Kind() != reflect.Struct {
-		return fmt.Errorf("target must be a struct")
+// executeHandler runs a method of a handler service, bounded by the configured timeout
+func (e *JSONRPCMethodExecutor) executeHandler(ctx context.Context, handler ServiceHandler, config serviceConfig, serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	if timeout := config.timeoutFor(methodName); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	vType := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := vType.Field(i)
+	result, err := func() (result interface{}, err error) {
+		defer e.recoverPanic(ctx, serviceName, methodName, &err)
+		return handler.ExecuteMethodContext(ctx, methodName, params)
+	}()
+	if err == nil {
+		return result, nil
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("method '%s' in service '%s' did not complete: %w", methodName, serviceName, ctx.Err())
+	}
+	var panicErr *server.PanicError
+	if errors.As(err, &panicErr) {
+		return nil, err
+	}
+	return nil, server.ClassifyError(server.ErrToolFailed, err)
+}
 
-		// Get JSON tag name
-		jsonTag := fieldType.Tag.Get("json")
-		if jsonTag == "" {
-			jsonTag = fieldType.Name
-		}
-		// Remove comma and options from JSON tag
-		if commaIndex := strings.Index(jsonTag, ","); commaIndex != -1 {
-			jsonTag = jsonTag[:commaIndex]
-		}
+// callWithContext runs call, returning early with ctx's error if ctx is done first.
+// Methods that ignore their context keep running in the background until they return.
+func callWithContext(ctx context.Context, call func() []reflect.Value) ([]reflect.Value, error) {
+	if ctx.Done() == nil {
+		return call(), nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan []reflect.Value, 1)
+	go func() {
+		done <- call()
+	}()
+
+	select {
+	case results := <-done:
+		return results, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// timeoutFor returns the timeout for a method, preferring a method-specific one
+func (c serviceConfig) timeoutFor(methodName string) time.Duration {
+	if d, ok := c.methodTimeouts[methodName]; ok {
+		return d
+	}
+	return c.timeout
+}
 
-		// Get value from map
-		if value, exists := m[jsonTag]; exists {
-			// Convert and set the field
-			if err := e.setFieldValue(field, value); err != nil {
-				return fmt.Errorf("failed to set field '%s': %w", fieldType.Name, err)
-			}
+// takesStream reports whether a method type, including its receiver, has a server.Stream argument
+func takesStream(methodType reflect.Type) bool {
+	for i := 0; i < methodType.NumIn(); i++ {
+		if methodType.In(i) == streamType {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil
+// methodSignature describes the arguments of a service method of the form
+// func([context.Context,] Request, [server.Stream,] *Response)
+type methodSignature struct {
+	takesContext bool
+	takesStream  bool
+	request      reflect.Type
+	response     reflect.Type // Pointer to the response type
 }
 
-// setFieldValue sets a field value with type conversion
-func (e *JSONRPCMethodExecutor) setFieldValue(field reflect.Value, value interface{}) error {
-	fieldType := field.Type()
+// parseSignature matches the arguments of methodType, which must not include the receiver
+func parseSignature(methodType reflect.Type) (methodSignature, bool) {
+	var signature methodSignature
+	in := make([]reflect.Type, methodType.NumIn())
+	for i := range in {
+		in[i] = methodType.In(i)
+	}
 
-	// Handle different types
-	switch fieldType.Kind() {
-	case reflect.String:
-		if str, ok := value.(string); ok {
-			field.SetString(str)
-		} else {
-			return fmt.Errorf("cannot convert %v to string", value)
-		}
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch v := value.(type) {
-		case int:
-			field.SetInt(int64(v))
-		case float64:
-			field.SetInt(int64(v))
-		default:
-			return fmt.Errorf("cannot convert %v to int", value)
-		}
-	case reflect.Float32, reflect.Float64:
-		if f, ok := value.(float64); ok {
-			field.SetFloat(f)
-		} else {
-			return fmt.Errorf("cannot convert %v to float", value)
-		}
-	case reflect.Bool:
-		if b, ok := value.(bool); ok {
-			field.SetBool(b)
-		} else {
-			return fmt.Errorf("cannot convert %v to bool", value)
-		}
-	default:
-		return fmt.Errorf("unsupported field type: %v", fieldType.Kind())
+	if len(in) > 0 && in[0] == contextType {
+		signature.takesContext = true
+		in = in[1:]
+	}
+	if len(in) == 3 && in[1] == streamType {
+		signature.takesStream = true
+		in = []reflect.Type{in[0], in[2]}
+	}
+	if len(in) != 2 || in[1].Kind() != reflect.Ptr {
+		return signature, false
 	}
 
-	return nil
+	signature.request, signature.response = in[0], in[1]
+	return signature, true
+}
+
+// serviceMethodTypes returns the request and response types of a service method of the form
+// func([context.Context,] Request, [server.Stream,] *Response) error.
+// methodType must not include the receiver.
+func serviceMethodTypes(methodType reflect.Type) (reflect.Type, reflect.Type, bool) {
+	signature, ok := parseSignature(methodType)
+	if !ok || methodType.NumOut() != 1 || methodType.Out(0) != errorType {
+		return nil, nil, false
+	}
+	return signature.request, signature.response.Elem(), true
+}
+
+// hasOnlyExecutorMethods reports whether all of a service's exported methods take a
+// context.Context or a server.Stream, which net/rpc cannot call
+func hasOnlyExecutorMethods(service any) bool {
+	if service == nil {
+		return false
+	}
+
+	serviceType := reflect.TypeOf(service)
+	if serviceType.NumMethod() == 0 {
+		return false
+	}
+	for i := 0; i < serviceType.NumMethod(); i++ {
+		// Method types obtained from the reflect.Type include the receiver as the first argument
+		methodType := serviceType.Method(i).Type
+		if methodType.NumIn() < 2 || methodType.In(1) != contextType && !takesStream(methodType) {
+			return false
+		}
+	}
+	return true
 }
@@ -1,9 +1,16 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
 )
 
 // MockServiceRegistry implements ServiceRegistry for testing
@@ -368,195 +375,445 @@ func TestJSONRPCMethodExecutor_ExecuteMethodWithDifferentTypes(t *testing.T) {
 	}
 }
 
-func TestJSONRPCMethodExecutor_mapToStruct(t *testing.T) {
+// ContextService has only context-aware methods, which net/rpc cannot register
+type ContextService struct{}
+
+type SleepRequest struct {
+	Duration time.Duration `json:"duration"`
+}
+
+type SleepResponse struct {
+	Slept bool `json:"slept"`
+}
+
+func (s *ContextService) Sleep(ctx context.Context, req SleepRequest, resp *SleepResponse) error {
+	select {
+	case <-time.After(req.Duration):
+		resp.Slept = true
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ContextService) Deadline(ctx context.Context, req SleepRequest, resp *SleepResponse) error {
+	_, resp.Slept = ctx.Deadline()
+	return nil
+}
+
+// BlockingService ignores cancellation entirely
+type BlockingService struct{}
+
+func (s *BlockingService) Block(req SleepRequest, resp *SleepResponse) error {
+	time.Sleep(req.Duration)
+	resp.Slept = true
+	return nil
+}
+
+func TestJSONRPCMethodExecutor_ExecuteMethodContext(t *testing.T) {
 	tests := []struct {
-		name          string
-		input         map[string]interface{}
-		target        reflect.Value
-		expectedError bool
+		name             string
+		service          any
+		opts             []ServiceOpts
+		serviceName      string
+		methodName       string
+		params           map[string]interface{}
+		ctxTimeout       time.Duration
+		expectedDeadline bool
+		expectedSlept    bool
 	}{
 		{
-			name: "valid_string_field",
-			input: map[string]interface{}{
-				"name": "test",
-			},
-			target:        reflect.ValueOf(HelloRequest{}),
-			expectedError: false,
+			name:          "context_method_completes",
+			service:       &ContextService{},
+			serviceName:   "ContextService",
+			methodName:    "Sleep",
+			params:        map[string]interface{}{"duration": float64(time.Millisecond)},
+			expectedSlept: true,
 		},
 		{
-			name: "valid_int_field",
-			input: map[string]interface{}{
-				"a": 5,
-				"b": 3,
-			},
-			target:        reflect.ValueOf(AddRequest{}),
-			expectedError: false,
+			name:             "method_timeout_cancels_context_method",
+			service:          &ContextService{},
+			opts:             []ServiceOpts{WithMethodTimeout("Sleep", 10*time.Millisecond)},
+			serviceName:      "ContextService",
+			methodName:       "Sleep",
+			params:           map[string]interface{}{"duration": float64(time.Second)},
+			expectedDeadline: true,
 		},
 		{
-			name: "valid_float_to_int_conversion",
-			input: map[string]interface{}{
-				"a": 5.0,
-				"b": 3.0,
-			},
-			target:        reflect.ValueOf(AddRequest{}),
-			expectedError: false,
+			name:          "method_timeout_overrides_service_timeout",
+			service:       &ContextService{},
+			opts:          []ServiceOpts{WithTimeout(time.Millisecond), WithMethodTimeout("Sleep", time.Second)},
+			serviceName:   "ContextService",
+			methodName:    "Sleep",
+			params:        map[string]interface{}{"duration": float64(5 * time.Millisecond)},
+			expectedSlept: true,
 		},
 		{
-			name: "invalid_field_type",
-			input: map[string]interface{}{
-				"name": 123, // int instead of string
-			},
-			target:        reflect.ValueOf(HelloRequest{}),
-			expectedError: true,
+			name:          "service_timeout_visible_to_method",
+			service:       &ContextService{},
+			opts:          []ServiceOpts{WithTimeout(time.Second)},
+			serviceName:   "ContextService",
+			methodName:    "Deadline",
+			params:        map[string]interface{}{},
+			expectedSlept: true,
 		},
 		{
-			name: "missing_field",
-			input: map[string]interface{}{
-				"other": "value",
-			},
-			target:        reflect.ValueOf(HelloRequest{}),
-			expectedError: false, // Missing fields are ignored
+			name:             "caller_deadline_abandons_blocking_method",
+			service:          &BlockingService{},
+			serviceName:      "BlockingService",
+			methodName:       "Block",
+			params:           map[string]interface{}{"duration": float64(time.Second)},
+			ctxTimeout:       10 * time.Millisecond,
+			expectedDeadline: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			executor := NewJSONRPCMethodExecutor(nil)
+			executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+			if err := executor.RegisterService(tt.service, tt.opts...); err != nil {
+				t.Fatalf("failed to register service: %v", err)
+			}
 
-			// Create a new instance of the target type
-			targetValue := reflect.New(tt.target.Type()).Elem()
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
 
-			err := executor.mapToStruct(tt.input, targetValue)
+			start := time.Now()
+			result, err := executor.ExecuteMethodContext(ctx, tt.serviceName, tt.methodName, tt.params)
 
-			if tt.expectedError && err == nil {
-				t.Error("expected error but got none")
+			if tt.expectedDeadline {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected deadline exceeded, got %v", err)
+				}
+				if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+					t.Errorf("call was not abandoned promptly: %v", elapsed)
+				}
+				return
 			}
-			if !tt.expectedError && err != nil {
-				t.Errorf("unexpected error: %v", err)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response, ok := result.(SleepResponse); !ok || response.Slept != tt.expectedSlept {
+				t.Errorf("expected slept %t, got %v", tt.expectedSlept, result)
 			}
+		})
+	}
+}
 
-			// For successful cases, verify the field was set correctly
-			if !tt.expectedError && err == nil {
-				if tt.name == "valid_string_field" {
-					field := targetValue.FieldByName("Name")
-					if field.String() != "test" {
-						t.Errorf("expected field value 'test', got '%s'", field.String())
-					}
-				} else if tt.name == "valid_int_field" || tt.name == "valid_float_to_int_conversion" {
-					fieldA := targetValue.FieldByName("A")
-					fieldB := targetValue.FieldByName("B")
-					if fieldA.Int() != 5 || fieldB.Int() != 3 {
-						t.Errorf("expected field values 5 and 3, got %d and %d", fieldA.Int(), fieldB.Int())
-					}
+func TestJSONRPCMethodExecutor_RegisterContextOnlyService(t *testing.T) {
+	mockRegistry := NewMockServiceRegistry()
+	mockRegistry.registerError = fmt.Errorf("type ContextService has no exported methods of suitable type")
+
+	executor := NewJSONRPCMethodExecutor(mockRegistry)
+	if err := executor.RegisterService(&ContextService{}); err != nil {
+		t.Fatalf("context-only service should not require registry registration: %v", err)
+	}
+	if _, exists := executor.services["ContextService"]; !exists {
+		t.Error("service was not stored in executor")
+	}
+
+	// Services with net/rpc compatible methods still go through the registry
+	if err := executor.RegisterService(&TestService{}); err == nil {
+		t.Error("expected registry error for net/rpc compatible service")
+	}
+}
+
+// recordingHandler is a ServiceHandler that echoes the method name or waits for cancellation
+type recordingHandler struct {
+	block bool
+}
+
+func (r *recordingHandler) ExecuteMethodContext(ctx context.Context, methodName string, params map[string]interface{}) (interface{}, error) {
+	if r.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return map[string]interface{}{"method": methodName, "params": params}, nil
+}
+
+func TestJSONRPCMethodExecutor_RegisterServiceHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceName   string
+		handler       ServiceHandler
+		opts          []ServiceOpts
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name:        "forwards_method_and_params",
+			serviceName: "Remote",
+			handler:     &recordingHandler{},
+			expected: map[string]interface{}{
+				"method": "Search",
+				"params": map[string]interface{}{"q": "go"},
+			},
+		},
+		{
+			name:          "timeout_applies_to_handler",
+			serviceName:   "Remote",
+			handler:       &recordingHandler{block: true},
+			opts:          []ServiceOpts{WithTimeout(10 * time.Millisecond)},
+			expectedError: "did not complete: context deadline exceeded",
+		},
+		{
+			name:          "invalid_service_name",
+			serviceName:   "Remote.Tools",
+			handler:       &recordingHandler{},
+			expectedError: "invalid service name",
+		},
+		{
+			name:          "nil_handler",
+			serviceName:   "Remote",
+			expectedError: "cannot register nil handler",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+
+			err := executor.RegisterServiceHandler(tt.serviceName, tt.handler, tt.opts...)
+			if err == nil {
+				var result interface{}
+				result, err = executor.ExecuteMethodContext(context.Background(), tt.serviceName, "Search", map[string]interface{}{"q": "go"})
+				if err == nil && !reflect.DeepEqual(result, tt.expected) {
+					t.Errorf("expected %v, got %v", tt.expected, result)
+				}
+			}
+
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
 				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectedError, err)
 			}
 		})
 	}
 }
 
-func TestJSONRPCMethodExecutor_setFieldValue(t *testing.T) {
+// StreamService streams a count before returning the total
+type StreamService struct{}
+
+type CountRequest struct {
+	To int `json:"to"`
+}
+
+type CountResponse struct {
+	Total int `json:"total"`
+}
+
+func (s *StreamService) Count(req CountRequest, stream server.Stream, resp *CountResponse) error {
+	for i := 1; i <= req.To; i++ {
+		if err := stream.Progress(float64(i), float64(req.To), ""); err != nil {
+			return err
+		}
+		if err := stream.Send(map[string]int{"n": i}); err != nil {
+			return err
+		}
+		resp.Total += i
+	}
+	return nil
+}
+
+// recordingStream is a server.Stream that records what it receives
+type recordingStream struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (r *recordingStream) Send(partial any) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, fmt.Sprintf("partial %v", partial))
+	return nil
+}
+
+func (r *recordingStream) Progress(progress, total float64, message string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, fmt.Sprintf("progress %v/%v", progress, total))
+	return nil
+}
+
+func TestJSONRPCMethodExecutor_ExecuteStreamingMethod(t *testing.T) {
+	mockRegistry := NewMockServiceRegistry()
+	mockRegistry.registerError = fmt.Errorf("type StreamService has no exported methods of suitable type")
+
+	// Streaming services cannot be served by net/rpc and skip the registry
+	executor := NewJSONRPCMethodExecutor(mockRegistry)
+	if err := executor.RegisterService(&StreamService{}); err != nil {
+		t.Fatalf("streaming service should not require registry registration: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		stream         *recordingStream
+		expectedEvents []string
+	}{
+		{
+			name:           "stream_from_context",
+			stream:         &recordingStream{},
+			expectedEvents: []string{"progress 1/2", "partial map[n:1]", "progress 2/2", "partial map[n:2]"},
+		},
+		{
+			name: "without_stream_output_is_discarded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.stream != nil {
+				ctx = server.ContextWithStream(ctx, tt.stream)
+			}
+
+			result, err := executor.ExecuteMethodContext(ctx, "StreamService", "Count", map[string]interface{}{"to": 2})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != (CountResponse{Total: 3}) {
+				t.Errorf("expected total 3, got %v", result)
+			}
+			if tt.stream != nil && !reflect.DeepEqual(tt.stream.events, tt.expectedEvents) {
+				t.Errorf("expected events %v, got %v", tt.expectedEvents, tt.stream.events)
+			}
+		})
+	}
+}
+
+// panicHandler is a ServiceHandler whose methods panic
+type panicHandler struct{}
+
+func (panicHandler) ExecuteMethodContext(ctx context.Context, methodName string, params map[string]interface{}) (interface{}, error) {
+	panic("handler boom")
+}
+
+func TestJSONRPCMethodExecutor_ErrorClassification(t *testing.T) {
 	tests := []struct {
 		name          string
-		fieldType     reflect.Type
-		value         interface{}
-		expectedError bool
+		serviceName   string
+		methodName    string
+		params        map[string]interface{}
+		expectedClass error
+		expectedError string
 	}{
 		{
-			name:          "set_string_field",
-			fieldType:     reflect.TypeOf(""),
-			value:         "test",
-			expectedError: false,
+			name:          "service_not_found",
+			serviceName:   "Missing",
+			methodName:    "Hello",
+			expectedClass: server.ErrServiceNotFound,
+			expectedError: "service 'Missing' not found",
 		},
 		{
-			name:          "set_int_field",
-			fieldType:     reflect.TypeOf(0),
-			value:         42,
-			expectedError: false,
+			name:          "method_not_found",
+			serviceName:   "TestService",
+			methodName:    "Missing",
+			expectedClass: server.ErrMethodNotFound,
+			expectedError: "method 'Missing' not found in service 'TestService'",
 		},
 		{
-			name:          "set_float_field",
-			fieldType:     reflect.TypeOf(0.0),
-			value:         3.14,
-			expectedError: false,
+			name:          "invalid_params",
+			serviceName:   "TestService",
+			methodName:    "Hello",
+			params:        map[string]interface{}{"name": 42},
+			expectedClass: server.ErrInvalidParams,
+			expectedError: "failed to convert parameters to request: name: cannot convert 42 to string",
 		},
 		{
-			name:          "set_bool_field",
-			fieldType:     reflect.TypeOf(false),
-			value:         true,
-			expectedError: false,
+			name:          "tool_failed",
+			serviceName:   "TestService",
+			methodName:    "HelloWithError",
+			expectedClass: server.ErrToolFailed,
+			expectedError: "test error",
 		},
 		{
-			name:          "set_int_from_float",
-			fieldType:     reflect.TypeOf(0),
-			value:         42.0,
-			expectedError: false,
+			name:          "handler_failed",
+			serviceName:   "Remote",
+			methodName:    "Search",
+			expectedClass: server.ErrToolFailed,
+			expectedError: "context canceled",
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+			executor.RegisterService(&TestService{})
+			executor.RegisterServiceHandler("Remote", failingHandler{})
+
+			_, err := executor.ExecuteMethod(tt.serviceName, tt.methodName, tt.params)
+			if !errors.Is(err, tt.expectedClass) {
+				t.Errorf("expected error of class %v, got %v", tt.expectedClass, err)
+			}
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+// failingHandler is a ServiceHandler whose methods fail without their context being done
+type failingHandler struct{}
+
+func (failingHandler) ExecuteMethodContext(ctx context.Context, methodName string, params map[string]interface{}) (interface{}, error) {
+	return nil, context.Canceled
+}
+
+func TestJSONRPCMethodExecutor_RecoversPanics(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceName string
+		timeout     time.Duration // Non-zero timeouts run the method in its own goroutine
+		expected    any
+	}{
 		{
-			name:          "set_string_from_int_error",
-			fieldType:     reflect.TypeOf(""),
-			value:         42,
-			expectedError: true,
+			name:        "method_panics",
+			serviceName: "PanicService",
+			expected:    "boom",
 		},
 		{
-			name:          "set_int_from_string_error",
-			fieldType:     reflect.TypeOf(0),
-			value:         "not_a_number",
-			expectedError: true,
+			name:        "method_panics_with_timeout",
+			serviceName: "PanicService",
+			timeout:     time.Second,
+			expected:    "boom",
 		},
 		{
-			name:          "set_unsupported_type",
-			fieldType:     reflect.TypeOf([]string{}),
-			value:         []string{"test"},
-			expectedError: true,
+			name:        "handler_panics",
+			serviceName: "Remote",
+			expected:    "handler boom",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			executor := NewJSONRPCMethodExecutor(nil)
-
-			// Create a field of the specified type
-			fieldValue := reflect.New(tt.fieldType).Elem()
+			var opts []ServiceOpts
+			if tt.timeout > 0 {
+				opts = append(opts, WithTimeout(tt.timeout))
+			}
+			executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+			executor.RegisterService(&PanicService{}, opts...)
+			executor.RegisterServiceHandler("Remote", panicHandler{}, opts...)
 
-			err := executor.setFieldValue(fieldValue, tt.value)
+			_, err := executor.ExecuteMethod(tt.serviceName, "Explode", map[string]interface{}{})
 
-			if tt.expectedError && err == nil {
-				t.Error("expected error but got none")
+			var panicErr *server.PanicError
+			if !errors.As(err, &panicErr) {
+				t.Fatalf("expected a *server.PanicError, got %v", err)
 			}
-			if !tt.expectedError && err != nil {
-				t.Errorf("unexpected error: %v", err)
+			if panicErr.Value != tt.expected {
+				t.Errorf("expected panic value %v, got %v", tt.expected, panicErr.Value)
 			}
-
-			// For successful cases, verify the value was set correctly
-			if !tt.expectedError && err == nil {
-				switch tt.fieldType.Kind() {
-				case reflect.String:
-					if fieldValue.String() != tt.value.(string) {
-						t.Errorf("expected string value %s, got %s", tt.value.(string), fieldValue.String())
-					}
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					var expected int64
-					switch v := tt.value.(type) {
-					case int:
-						expected = int64(v)
-					case float64:
-						expected = int64(v)
-					default:
-						t.Errorf("unexpected value type: %T", tt.value)
-						return
-					}
-					if fieldValue.Int() != expected {
-						t.Errorf("expected int value %d, got %d", expected, fieldValue.Int())
-					}
-				case reflect.Float32, reflect.Float64:
-					if fieldValue.Float() != tt.value.(float64) {
-						t.Errorf("expected float value %f, got %f", tt.value.(float64), fieldValue.Float())
-					}
-				case reflect.Bool:
-					if fieldValue.Bool() != tt.value.(bool) {
-						t.Errorf("expected bool value %t, got %t", tt.value.(bool), fieldValue.Bool())
-					}
-				}
+			if len(panicErr.Stack) == 0 {
+				t.Error("expected the stack of the panic")
 			}
 		})
 	}
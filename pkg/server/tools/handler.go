@@ -1,33 +1,122 @@
 package tools
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
 	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/jobs"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
 )
 
+// MethodExecutionHandlerOpts defines options for configuring the method execution handler
+type MethodExecutionHandlerOpts func(*MethodExecutionHandler)
+
 // MethodExecutionHandler provides HTTP handlers for method execution
 type MethodExecutionHandler struct {
-	executor server.MethodExecutor
+	executor         server.MethodExecutor
+	batchConcurrency int
+	schemas          SchemaProvider
+	interceptors     *server.Interceptors
+	jobs             *jobs.Manager
+	idempotency      *idempotencyStore
+	debugErrors      bool
+	inFlight         callTracker
 }
 
 // NewMethodExecutionHandler creates a new method execution handler
-func NewMethodExecutionHandler(executor server.MethodExecutor) *MethodExecutionHandler {
-	return &MethodExecutionHandler{
-		executor: executor,
+func NewMethodExecutionHandler(executor server.MethodExecutor, opts ...MethodExecutionHandlerOpts) *MethodExecutionHandler {
+	h := &MethodExecutionHandler{
+		executor:         executor,
+		batchConcurrency: 1,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// WithBatchConcurrency sets how many requests of a JSON-RPC batch are executed in parallel.
+// The default of 1 executes batch entries sequentially, in order.
+func WithBatchConcurrency(n int) MethodExecutionHandlerOpts {
+	return func(h *MethodExecutionHandler) {
+		if n < 1 {
+			n = 1
+		}
+		h.batchConcurrency = n
+	}
+}
+
+// WithSchemaValidation validates params against the schema registered for each method
+// before dispatch. Requests that do not satisfy it are rejected with -32602 Invalid params
+// and a list of violations as the error data. Methods without a schema are not checked.
+func WithSchemaValidation(schemas SchemaProvider) MethodExecutionHandlerOpts {
+	return func(h *MethodExecutionHandler) {
+		h.schemas = schemas
+	}
+}
+
+// WithInterceptors runs every call through the given interceptor chain, e.g. the one
+// returned by (*server.Server).GetInterceptors. Interceptors run after the request has been
+// parsed and before schema validation, so they may adjust params that are then validated.
+func WithInterceptors(interceptors *server.Interceptors) MethodExecutionHandlerOpts {
+	return func(h *MethodExecutionHandler) {
+		h.interceptors = interceptors
+	}
+}
+
+// WithJobs lets clients execute calls asynchronously by adding "async": true to a request.
+// The call is submitted to manager and answered at once with the job's ID and status:
+//
+//	{"jsonrpc": "2.0", "result": {"jobId": "9f2c...", "status": "pending"}, "id": 1}
+//
+// Its outcome is collected from the job endpoints, see (*jobs.Manager).Handler.
+func WithJobs(manager *jobs.Manager) MethodExecutionHandlerOpts {
+	return func(h *MethodExecutionHandler) {
+		h.jobs = manager
 	}
 }
 
-// ServeHTTP handles method execution requests
+// WithDebugErrors adds the stack of a panicking method to the data of its -32603 error:
+//
+//	{"code": -32603, "message": "Internal error", "data": {"error": "panic: ...", "stack": "goroutine 7 [running]:..."}}
+//
+// Stacks reveal the server's internals, so enable this during development only.
+func WithDebugErrors() MethodExecutionHandlerOpts {
+	return func(h *MethodExecutionHandler) {
+		h.debugErrors = true
+	}
+}
+
+// ServeHTTP handles method execution requests.
+// The body may be a single JSON-RPC request object or a batch (an array of request objects).
+// Clients that accept text/event-stream get the response as Server-Sent Events, see StreamHandler.
+// The caller's trace context is read from the traceparent and tracestate headers, so that
+// spans of the call join the caller's trace.
 func (h *MethodExecutionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if wantsStream(r) {
+		h.serveStream(w, r)
+		return
+	}
+
+	h.inFlight.add()
+	defer h.inFlight.done()
 
 	// Read the request body
 	body, err := io.ReadAll(r.Body)
@@ -37,49 +126,257 @@ func (h *MethodExecutionHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	}
 	defer r.Body.Close()
 
+	caller := server.Caller{Transport: server.TransportHTTP, RemoteAddr: r.RemoteAddr}
+	caller.Principal, _ = server.PrincipalFromContext(r.Context())
+	ctx := tracing.Extract(r.Context(), r.Header)
+
+	// A leading '[' marks a batch request
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		h.serveBatch(ctx, caller, w, trimmed)
+		return
+	}
+
 	// Parse the JSON-RPC request
 	var request map[string]interface{}
 	if err := json.Unmarshal(body, &request); err != nil {
 		http.Error(w, "invalid JSON", http.StatusBadRequest)
 		return
 	}
+	applyIdempotencyHeader(r, request)
+
+	h.writeResponse(w, h.handleRequest(ctx, caller, request))
+}
+
+// InFlight returns the number of requests currently being executed
+func (h *MethodExecutionHandler) InFlight() int {
+	return h.inFlight.count()
+}
+
+// Wait blocks until no requests are being executed or ctx is done.
+// It is used to drain in-flight calls during a graceful shutdown.
+func (h *MethodExecutionHandler) Wait(ctx context.Context) error {
+	return h.inFlight.wait(ctx)
+}
 
+// serveBatch executes each request of a batch and writes the responses as an array.
+// Responses keep the order of the requests; notifications (requests without an id) get no entry.
+func (h *MethodExecutionHandler) serveBatch(ctx context.Context, caller server.Caller, w http.ResponseWriter, body []byte) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(batch) == 0 {
+		h.sendErrorResponse(w, nil, -32600, "Invalid Request", "batch must contain at least one request")
+		return
+	}
+
+	responses := make([]map[string]interface{}, len(batch))
+	sem := make(chan struct{}, h.batchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, raw := range batch {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, raw json.RawMessage) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			var request map[string]interface{}
+			if err := json.Unmarshal(raw, &request); err != nil || request == nil {
+				responses[i] = h.errorResponse(nil, -32600, "Invalid Request", "batch entry must be a request object")
+				return
+			}
+
+			// An invalid entry is not a notification, even without an id, and always gets an error
+			if err := h.validateRequest(request); err != nil {
+				responses[i] = h.errorResponse(request, -32600, "Invalid Request", err.Error())
+				return
+			}
+
+			response := h.handleRequest(ctx, caller, request)
+			if _, hasID := request["id"]; hasID {
+				responses[i] = response
+			}
+		}(i, raw)
+	}
+	wg.Wait()
+
+	results := make([]map[string]interface{}, 0, len(responses))
+	wait := 0
+	for _, response := range responses {
+		if response != nil {
+			results = append(results, response)
+			wait = max(wait, retryAfter(response))
+		}
+	}
+
+	// A batch made up only of notifications returns nothing at all
+	if len(results) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(wait))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleRequest validates and executes a single JSON-RPC request and returns its response object.
+// ctx is passed to the executor so that calls stop when the client goes away.
+func (h *MethodExecutionHandler) handleRequest(ctx context.Context, caller server.Caller, request map[string]interface{}) map[string]interface{} {
 	// Validate JSON-RPC 2.0 request
 	if err := h.validateRequest(request); err != nil {
-		h.sendErrorResponse(w, request, -32600, "Invalid Request", err.Error())
-		return
+		return h.errorResponse(request, -32600, "Invalid Request", err.Error())
 	}
 
 	// Extract method name
 	method, ok := request["method"].(string)
 	if !ok {
-		h.sendErrorResponse(w, request, -32600, "Invalid Request", "method field is required and must be a string")
-		return
+		return h.errorResponse(request, -32600, "Invalid Request", "method field is required and must be a string")
 	}
 
 	// Parse method name (format: "ServiceName.MethodName")
 	serviceName, methodName, err := h.parseMethodName(method)
 	if err != nil {
-		h.sendErrorResponse(w, request, -32601, "Method not found", err.Error())
-		return
+		return h.errorResponse(request, -32601, "Method not found", err.Error())
 	}
 
 	// Extract parameters
 	params, err := h.extractParams(request)
 	if err != nil {
-		h.sendErrorResponse(w, request, -32602, "Invalid params", err.Error())
-		return
+		return h.errorResponse(request, -32602, "Invalid params", err.Error())
+	}
+
+	// Execute the method through the interceptors
+	call := &server.ToolCall{
+		ServiceName: serviceName,
+		MethodName:  methodName,
+		Params:      params,
+		Caller:      caller,
 	}
+	if key, _ := request[idempotencyKeyField].(string); key != "" && h.idempotency != nil {
+		return h.idempotent(ctx, key, request, call, func(ctx context.Context) map[string]interface{} {
+			return h.invoke(ctx, request, call)
+		})
+	}
+	return h.invoke(ctx, request, call)
+}
 
-	// Execute the method
-	result, err := h.executor.ExecuteMethod(serviceName, methodName, params)
+// invoke runs a parsed call through the interceptors, or submits it as a job if the
+// request asks for asynchronous execution, and returns the response object
+func (h *MethodExecutionHandler) invoke(ctx context.Context, request map[string]interface{}, call *server.ToolCall) map[string]interface{} {
+	if async, _ := request["async"].(bool); async {
+		return h.submitJob(ctx, request, call)
+	}
+	result, err := h.interceptors.Invoke(ctx, call, h.execute)
 	if err != nil {
-		h.sendErrorResponse(w, request, -32603, "Internal error", err.Error())
-		return
+		rpcErr := h.callError(err)
+		return h.errorResponse(request, rpcErr.Code, rpcErr.Message, rpcErr.Data)
 	}
 
-	// Send success response
-	h.sendSuccessResponse(w, request, result)
+	return h.successResponse(request, result)
+}
+
+// submitJob runs a call in the background and returns a response holding its job ID.
+// Interceptors and validation run as part of the job, so their errors are reported by the job.
+func (h *MethodExecutionHandler) submitJob(ctx context.Context, request map[string]interface{}, call *server.ToolCall) map[string]interface{} {
+	if h.jobs == nil {
+		return h.errorResponse(request, -32600, "Invalid Request", "async execution is not enabled")
+	}
+
+	owner := ""
+	if call.Caller.Principal != nil {
+		owner = call.Caller.Principal.Subject
+	}
+
+	// The job outlives the request, so it must not write to the request's stream
+	ctx = server.ContextWithStream(ctx, nil)
+	job, err := h.jobs.Submit(ctx, owner, call.ServiceName+"."+call.MethodName, maps.Clone(call.Params), func(ctx context.Context) (any, error) {
+		result, err := h.interceptors.Invoke(ctx, call, h.execute)
+		if err != nil {
+			return nil, h.callError(err)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return h.errorResponse(request, -32603, "Internal error", err.Error())
+	}
+
+	return h.successResponse(request, map[string]interface{}{"jobId": job.ID, "status": job.Status})
+}
+
+// Dispatcher returns a jsonrpc.Dispatcher that runs TCP calls through the same interceptors,
+// schema validation and executor as /execute, so that a jsonrpc.Server can share them:
+//
+//	rpcServer := jsonrpc.NewServer()
+//	rpcServer.SetDispatcher(handler.Dispatcher())
+//
+// TCP requests carry no trace context, so traced calls over TCP start a new trace.
+func (h *MethodExecutionHandler) Dispatcher() jsonrpc.Dispatcher {
+	return func(ctx context.Context, c *jsonrpc.Call) (any, error) {
+		h.inFlight.add()
+		defer h.inFlight.done()
+
+		call := &server.ToolCall{
+			ServiceName: c.ServiceName,
+			MethodName:  c.MethodName,
+			Params:      c.Params,
+			Caller:      server.Caller{Transport: server.TransportTCP, RemoteAddr: c.RemoteAddr},
+		}
+		result, err := h.interceptors.Invoke(ctx, call, h.execute)
+		if err != nil {
+			return nil, h.callError(err)
+		}
+		return result, nil
+	}
+}
+
+// execute validates the params of a call and runs it on the executor.
+// It is the innermost invoker of the interceptor chain.
+func (h *MethodExecutionHandler) execute(ctx context.Context, call *server.ToolCall) (result any, err error) {
+	if err := h.validateParams(call.ServiceName, call.MethodName, call.Params); err != nil {
+		// Interceptors see the -32602 error the client receives
+		return nil, h.callError(err)
+	}
+
+	// JSONRPCMethodExecutor recovers panics itself, other executors may not
+	defer func() {
+		if r := recover(); r != nil {
+			err = server.NewPanicError(r)
+		}
+	}()
+	return server.Execute(ctx, h.executor, call.ServiceName, call.MethodName, call.Params)
+}
+
+// callError maps an error returned by the interceptor chain to a JSON-RPC error.
+// Validation failures carry their violations and typed errors pass through unchanged;
+// other errors get the code of their class, see server.ErrorCode, and their message as data.
+// The stack of a panic is only added when debug errors are enabled.
+func (h *MethodExecutionHandler) callError(err error) *jsonrpc.Error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return jsonrpc.NewError(jsonrpc.ErrorCodeInvalidParams, jsonrpc.ErrorCodeInvalidParams.Message(), validationErr.Violations)
+	}
+	if rpcErr, ok := jsonrpc.AsError(err); ok {
+		return rpcErr
+	}
+
+	code := server.ErrorCode(err)
+	var panicErr *server.PanicError
+	if h.debugErrors && errors.As(err, &panicErr) {
+		return jsonrpc.NewError(code, code.Message(), map[string]any{
+			"error": err.Error(),
+			"stack": string(panicErr.Stack),
+		})
+	}
+	return jsonrpc.NewError(code, code.Message(), err.Error())
 }
 
 // validateRequest validates a JSON-RPC 2.0 request
@@ -102,6 +399,18 @@ func (h *MethodExecutionHandler) validateRequest(request map[string]interface{})
 	return nil
 }
 
+// validateParams checks params against the method's schema when schema validation is enabled
+func (h *MethodExecutionHandler) validateParams(serviceName, methodName string, params map[string]interface{}) error {
+	if h.schemas == nil {
+		return nil
+	}
+	schema, ok := h.schemas.ParameterSchema(serviceName, methodName)
+	if !ok {
+		return nil
+	}
+	return ValidateParams(schema, params)
+}
+
 // parseMethodName parses a method name in the format "ServiceName.MethodName"
 func (h *MethodExecutionHandler) parseMethodName(method string) (string, string, error) {
 	parts := strings.Split(method, ".")
@@ -144,36 +453,113 @@ func (h *MethodExecutionHandler) extractParams(request map[string]interface{}) (
 	}
 }
 
-// sendSuccessResponse sends a JSON-RPC 2.0 success response
-func (h *MethodExecutionHandler) sendSuccessResponse(w http.ResponseWriter, request map[string]interface{}, result interface{}) {
-	response := map[string]interface{}{
+// successResponse builds a JSON-RPC 2.0 success response object
+func (h *MethodExecutionHandler) successResponse(request map[string]interface{}, result interface{}) map[string]interface{} {
+	return map[string]interface{}{
 		"jsonrpc": "2.0",
 		"result":  result,
 		"id":      request["id"],
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
 }
 
-// sendErrorResponse sends a JSON-RPC 2.0 error response
-func (h *MethodExecutionHandler) sendErrorResponse(w http.ResponseWriter, request map[string]interface{}, code int, message, data string) {
+// errorResponse builds a JSON-RPC 2.0 error response object.
+// data is omitted when it is nil or an empty string.
+func (h *MethodExecutionHandler) errorResponse(request map[string]interface{}, code int, message string, data interface{}) map[string]interface{} {
 	errorObj := map[string]interface{}{
 		"code":    code,
 		"message": message,
 	}
-	if data != "" {
+	if data != nil && data != "" {
 		errorObj["data"] = data
 	}
 
-	response := map[string]interface{}{
+	return map[string]interface{}{
 		"jsonrpc": "2.0",
 		"error":   errorObj,
 		"id":      request["id"],
 	}
+}
 
+// writeResponse writes a JSON-RPC 2.0 response object.
+// Rate limited calls also get a Retry-After header.
+func (h *MethodExecutionHandler) writeResponse(w http.ResponseWriter, response map[string]interface{}) {
+	if wait := retryAfter(response); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(wait))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK) // JSON-RPC 2.0 always returns 200 OK
 	json.NewEncoder(w).Encode(response)
 }
+
+// sendSuccessResponse sends a JSON-RPC 2.0 success response
+func (h *MethodExecutionHandler) sendSuccessResponse(w http.ResponseWriter, request map[string]interface{}, result interface{}) {
+	h.writeResponse(w, h.successResponse(request, result))
+}
+
+// sendErrorResponse sends a JSON-RPC 2.0 error response
+func (h *MethodExecutionHandler) sendErrorResponse(w http.ResponseWriter, request map[string]interface{}, code int, message string, data interface{}) {
+	h.writeResponse(w, h.errorResponse(request, code, message, data))
+}
+
+// retryAfter returns the retryAfter hint, in seconds, of a rate limited response, or 0
+func retryAfter(response map[string]interface{}) int {
+	errorObj, ok := response["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != int(jsonrpc.ErrorCodeRateLimited) {
+		return 0
+	}
+	data, _ := errorObj["data"].(map[string]any)
+	wait, _ := data["retryAfter"].(int)
+	return wait
+}
+
+// callTracker counts in-flight calls. The zero value is ready to use.
+type callTracker struct {
+	mutex  sync.Mutex
+	active int
+	idle   chan struct{} // Closed when active drops to zero
+}
+
+// add records the start of a call
+func (c *callTracker) add() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.active == 0 {
+		c.idle = make(chan struct{})
+	}
+	c.active++
+}
+
+// done records the end of a call
+func (c *callTracker) done() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.active--
+	if c.active == 0 {
+		close(c.idle)
+		c.idle = nil
+	}
+}
+
+// count returns the number of in-flight calls
+func (c *callTracker) count() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.active
+}
+
+// wait blocks until there are no in-flight calls or ctx is done
+func (c *callTracker) wait(ctx context.Context) error {
+	c.mutex.Lock()
+	idle := c.idle
+	c.mutex.Unlock()
+	if idle == nil {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -2,13 +2,22 @@ package tools
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
 	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/jobs"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
 )
 
 // MockMethodExecutor implements server.MethodExecutor for testing
@@ -896,3 +905,850 @@ func TestMethodExecutionHandler_sendErrorResponse(t *testing.T) {
 		})
 	}
 }
+
+// echoMethodExecutor returns the params it receives and records peak concurrency.
+// It is safe for concurrent use, unlike MockMethodExecutor.
+type echoMethodExecutor struct {
+	delay    time.Duration
+	mutex    sync.Mutex
+	inFlight int
+	peak     int
+	calls    int
+}
+
+func (e *echoMethodExecutor) ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	e.mutex.Lock()
+	e.calls++
+	e.inFlight++
+	if e.inFlight > e.peak {
+		e.peak = e.inFlight
+	}
+	e.mutex.Unlock()
+
+	time.Sleep(e.delay)
+
+	e.mutex.Lock()
+	e.inFlight--
+	e.mutex.Unlock()
+
+	if methodName == "Fail" {
+		return nil, fmt.Errorf("failed")
+	}
+	return params, nil
+}
+
+func TestMethodExecutionHandler_ServeHTTPBatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedIDs    []interface{} // ids of the response entries, in order
+		expectedCodes  []float64     // error code per entry, 0 for success
+		expectedCalls  int
+	}{
+		{
+			name: "mixed_batch_in_order",
+			body: `[
+				{"jsonrpc":"2.0","method":"Echo.Say","params":{"n":1},"id":1},
+				{"jsonrpc":"2.0","method":"Echo.Fail","params":{},"id":"two"},
+				{"jsonrpc":"2.0","method":"Echo.Say","params":{"n":3},"id":3}
+			]`,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []interface{}{float64(1), "two", float64(3)},
+			expectedCodes:  []float64{0, -32603, 0},
+			expectedCalls:  3,
+		},
+		{
+			name: "notifications_get_no_entry",
+			body: `[
+				{"jsonrpc":"2.0","method":"Echo.Say","params":{"n":1}},
+				{"jsonrpc":"2.0","method":"Echo.Say","params":{"n":2},"id":2}
+			]`,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []interface{}{float64(2)},
+			expectedCodes:  []float64{0},
+			expectedCalls:  2,
+		},
+		{
+			name:           "only_notifications",
+			body:           `[{"jsonrpc":"2.0","method":"Echo.Say"},{"jsonrpc":"2.0","method":"Echo.Say"}]`,
+			expectedStatus: http.StatusNoContent,
+			expectedCalls:  2,
+		},
+		{
+			name:           "invalid_entries",
+			body:           `[1, {"jsonrpc":"1.0","method":"Echo.Say","id":2}]`,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []interface{}{nil, float64(2)},
+			expectedCodes:  []float64{-32600, -32600},
+			expectedCalls:  0,
+		},
+		{
+			name:           "invalid_entries_without_id",
+			body:           `[{"foo":"bar"}, {"jsonrpc":"2.0","method":42}, {"jsonrpc":"2.0","method":"Echo.Say"}]`,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []interface{}{nil, nil},
+			expectedCodes:  []float64{-32600, -32600},
+			expectedCalls:  1,
+		},
+		{
+			name:           "malformed_batch",
+			body:           `[{"jsonrpc":"2.0"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &echoMethodExecutor{}
+			handler := NewMethodExecutionHandler(executor, WithBatchConcurrency(2))
+
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if executor.calls != tt.expectedCalls {
+				t.Errorf("expected %d executor calls, got %d", tt.expectedCalls, executor.calls)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var responses []map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &responses); err != nil {
+				t.Fatalf("failed to unmarshal batch response: %v", err)
+			}
+			if len(responses) != len(tt.expectedIDs) {
+				t.Fatalf("expected %d responses, got %d", len(tt.expectedIDs), len(responses))
+			}
+
+			for i, response := range responses {
+				if response["id"] != tt.expectedIDs[i] {
+					t.Errorf("response %d: expected id %v, got %v", i, tt.expectedIDs[i], response["id"])
+				}
+				if tt.expectedCodes[i] == 0 {
+					if _, exists := response["result"]; !exists {
+						t.Errorf("response %d: expected result, got %v", i, response)
+					}
+					continue
+				}
+				errorObj, ok := response["error"].(map[string]interface{})
+				if !ok {
+					t.Errorf("response %d: expected error, got %v", i, response)
+					continue
+				}
+				if errorObj["code"] != tt.expectedCodes[i] {
+					t.Errorf("response %d: expected code %v, got %v", i, tt.expectedCodes[i], errorObj["code"])
+				}
+			}
+		})
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPEmptyBatch(t *testing.T) {
+	handler := NewMethodExecutionHandler(&echoMethodExecutor{})
+
+	req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(`[]`)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("expected a single error object, got %s", w.Body.String())
+	}
+	errorObj, ok := response["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32600) {
+		t.Errorf("expected Invalid Request error, got %v", response)
+	}
+}
+
+func TestWithBatchConcurrency(t *testing.T) {
+	tests := []struct {
+		name         string
+		concurrency  int
+		expectedPeak int
+	}{
+		{
+			name:         "sequential_by_default",
+			concurrency:  0,
+			expectedPeak: 1,
+		},
+		{
+			name:         "bounded_parallelism",
+			concurrency:  3,
+			expectedPeak: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &echoMethodExecutor{delay: 20 * time.Millisecond}
+			handler := NewMethodExecutionHandler(executor, WithBatchConcurrency(tt.concurrency))
+
+			batch := make([]map[string]interface{}, 6)
+			for i := range batch {
+				batch[i] = map[string]interface{}{"jsonrpc": "2.0", "method": "Echo.Say", "params": map[string]interface{}{"n": i}, "id": i}
+			}
+			body, err := json.Marshal(batch)
+			if err != nil {
+				t.Fatalf("failed to marshal batch: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var responses []map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &responses); err != nil {
+				t.Fatalf("failed to unmarshal batch response: %v", err)
+			}
+			for i, response := range responses {
+				result := response["result"].(map[string]interface{})
+				if result["n"] != float64(i) {
+					t.Errorf("response %d out of order: %v", i, result)
+				}
+			}
+
+			if executor.peak > tt.expectedPeak {
+				t.Errorf("expected at most %d concurrent calls, got %d", tt.expectedPeak, executor.peak)
+			}
+			if tt.expectedPeak > 1 && executor.peak < 2 {
+				t.Errorf("expected parallel execution, peak was %d", executor.peak)
+			}
+		})
+	}
+}
+
+// contextMethodExecutor records the context it is called with and fails with its error
+type contextMethodExecutor struct {
+	MockMethodExecutor
+	lastCtx context.Context
+}
+
+func (c *contextMethodExecutor) ExecuteMethodContext(ctx context.Context, serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	c.lastCtx = ctx
+	<-ctx.Done()
+	return nil, fmt.Errorf("method '%s' did not complete: %w", methodName, ctx.Err())
+}
+
+func TestMethodExecutionHandler_ServeHTTPContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		cancel       bool
+		timeout      time.Duration
+		expectedCode float64
+	}{
+		{
+			name:         "deadline_maps_to_timeout_code",
+			timeout:      10 * time.Millisecond,
+			expectedCode: -32001,
+		},
+		{
+			name:         "cancellation_maps_to_internal_error",
+			cancel:       true,
+			expectedCode: -32603,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &contextMethodExecutor{}
+			handler := NewMethodExecutionHandler(executor)
+
+			body := []byte(`{"jsonrpc":"2.0","method":"Slow.Run","params":{},"id":1}`)
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(body))
+
+			ctx, cancel := context.WithCancel(req.Context())
+			defer cancel()
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			if tt.cancel {
+				cancel()
+			}
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if executor.lastCtx == nil || executor.lastCtx.Done() == nil {
+				t.Fatal("request context was not passed to the executor")
+			}
+			if executor.executeCalled {
+				t.Error("expected ExecuteMethodContext to be used instead of ExecuteMethod")
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			errorObj, ok := response["error"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected error response, got %v", response)
+			}
+			if errorObj["code"] != tt.expectedCode {
+				t.Errorf("expected code %v, got %v", tt.expectedCode, errorObj["code"])
+			}
+		})
+	}
+}
+
+// staticSchemaProvider serves a fixed set of parameter schemas keyed by "Service.Method"
+type staticSchemaProvider map[string]map[string]interface{}
+
+func (s staticSchemaProvider) ParameterSchema(serviceName, methodName string) (map[string]interface{}, bool) {
+	schema, ok := s[serviceName+"."+methodName]
+	return schema, ok
+}
+
+func TestMethodExecutionHandler_ServeHTTPSchemaValidation(t *testing.T) {
+	schemas := staticSchemaProvider{
+		"Greeter.Hello": {
+			"name": map[string]interface{}{"type": "string", "required": true},
+			"age":  map[string]interface{}{"type": "number"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedCalled bool
+		expectedData   []interface{}
+	}{
+		{
+			name:           "valid_params_are_dispatched",
+			body:           `{"jsonrpc":"2.0","method":"Greeter.Hello","params":{"name":"Ada"},"id":1}`,
+			expectedCalled: true,
+		},
+		{
+			name:           "method_without_schema_is_not_checked",
+			body:           `{"jsonrpc":"2.0","method":"Greeter.Bye","params":{"name":1},"id":1}`,
+			expectedCalled: true,
+		},
+		{
+			name: "violations_are_listed",
+			body: `{"jsonrpc":"2.0","method":"Greeter.Hello","params":{"age":"old"},"id":1}`,
+			expectedData: []interface{}{
+				map[string]interface{}{"path": "name", "rule": "required", "message": "is required"},
+				map[string]interface{}{"path": "age", "rule": "type", "message": "expected number, got string"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewMockMethodExecutor()
+			handler := NewMethodExecutionHandler(executor, WithSchemaValidation(schemas))
+
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if executor.executeCalled != tt.expectedCalled {
+				t.Errorf("expected executeCalled %v, got %v", tt.expectedCalled, executor.executeCalled)
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if tt.expectedData == nil {
+				if response["error"] != nil {
+					t.Errorf("expected success, got error %v", response["error"])
+				}
+				return
+			}
+
+			errorObj, ok := response["error"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected error response, got %v", response)
+			}
+			if errorObj["code"] != float64(-32602) {
+				t.Errorf("expected code -32602, got %v", errorObj["code"])
+			}
+			if !reflect.DeepEqual(errorObj["data"], tt.expectedData) {
+				t.Errorf("expected data %v, got %v", tt.expectedData, errorObj["data"])
+			}
+		})
+	}
+}
+
+// blockingMethodExecutor blocks every call until released
+type blockingMethodExecutor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingMethodExecutor) ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return "done", nil
+}
+
+func TestMethodExecutionHandler_Wait(t *testing.T) {
+	executor := &blockingMethodExecutor{started: make(chan struct{}), release: make(chan struct{})}
+	handler := NewMethodExecutionHandler(executor)
+
+	if err := handler.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait on idle handler returned %v", err)
+	}
+
+	go func() {
+		body := []byte(`{"jsonrpc":"2.0","method":"Slow.Run","params":{},"id":1}`)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(body)))
+	}()
+	<-executor.started
+
+	if got := handler.InFlight(); got != 1 {
+		t.Errorf("expected 1 call in flight, got %d", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := handler.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded while call is in flight, got %v", err)
+	}
+
+	close(executor.release)
+	if err := handler.Wait(context.Background()); err != nil {
+		t.Errorf("Wait after completion returned %v", err)
+	}
+	if got := handler.InFlight(); got != 0 {
+		t.Errorf("expected no calls in flight, got %d", got)
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPInterceptors(t *testing.T) {
+	schemas := staticSchemaProvider{
+		"Notes.Add": {
+			"text":   map[string]interface{}{"type": "string", "required": true},
+			"tenant": map[string]interface{}{"type": "string", "required": true},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		interceptor    server.Interceptor
+		expectedCalled bool
+		expected       string
+	}{
+		{
+			name: "interceptor_params_are_validated_and_dispatched",
+			body: `{"jsonrpc":"2.0","method":"Notes.Add","params":{"text":"hi"},"id":1}`,
+			interceptor: func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+				if call.Caller.Transport != server.TransportHTTP || call.Caller.RemoteAddr == "" {
+					return nil, fmt.Errorf("unexpected caller %+v", call.Caller)
+				}
+				call.Params["tenant"] = "acme"
+				return next(ctx, call)
+			},
+			expectedCalled: true,
+			expected:       `{"jsonrpc":"2.0","result":{"result":"success"},"id":1}`,
+		},
+		{
+			name: "interceptor_sees_validation_errors",
+			body: `{"jsonrpc":"2.0","method":"Notes.Add","params":{"text":"hi"},"id":2}`,
+			interceptor: func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+				return next(ctx, call)
+			},
+			expected: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":[{"path":"tenant","rule":"required","message":"is required"}]},"id":2}`,
+		},
+		{
+			name: "typed_errors_pass_through",
+			body: `{"jsonrpc":"2.0","method":"Notes.Add","params":{"text":"hi","tenant":"acme"},"id":3}`,
+			interceptor: func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+				return nil, jsonrpc.NewError(-32003, "Forbidden", call.ServiceName+"."+call.MethodName)
+			},
+			expected: `{"jsonrpc":"2.0","error":{"code":-32003,"message":"Forbidden","data":"Notes.Add"},"id":3}`,
+		},
+		{
+			name: "interceptor_can_replace_result",
+			body: `{"jsonrpc":"2.0","method":"Notes.Add","params":{"text":"hi","tenant":"acme"},"id":4}`,
+			interceptor: func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+				result, err := next(ctx, call)
+				return map[string]interface{}{"wrapped": result}, err
+			},
+			expectedCalled: true,
+			expected:       `{"jsonrpc":"2.0","result":{"wrapped":{"result":"success"}},"id":4}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewMockMethodExecutor()
+			interceptors := server.NewInterceptors()
+			interceptors.UseService("Notes", tt.interceptor)
+			handler := NewMethodExecutionHandler(executor, WithSchemaValidation(schemas), WithInterceptors(interceptors))
+
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if executor.executeCalled != tt.expectedCalled {
+				t.Errorf("expected executeCalled %v, got %v", tt.expectedCalled, executor.executeCalled)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &want); err != nil {
+				t.Fatalf("invalid expected JSON: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMethodExecutionHandler_Dispatcher(t *testing.T) {
+	executor := NewMockMethodExecutor()
+	interceptors := server.NewInterceptors()
+
+	var callers []server.Caller
+	interceptors.Use(func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		callers = append(callers, call.Caller)
+		return next(ctx, call)
+	})
+	handler := NewMethodExecutionHandler(executor, WithInterceptors(interceptors))
+
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.SetDispatcher(handler.Dispatcher())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go rpcServer.Serve(listener)
+
+	client, err := jsonrpc.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	var reply map[string]interface{}
+	if err := client.Call("Notes.Add", map[string]interface{}{"text": "hi"}, &reply); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(reply, map[string]interface{}{"result": "success"}) {
+		t.Errorf("unexpected reply %v", reply)
+	}
+	if executor.lastServiceName != "Notes" || executor.lastMethodName != "Add" {
+		t.Errorf("expected Notes.Add to be executed, got %s.%s", executor.lastServiceName, executor.lastMethodName)
+	}
+	if len(callers) != 1 || callers[0].Transport != server.TransportTCP || callers[0].RemoteAddr == "" {
+		t.Errorf("unexpected callers %+v", callers)
+	}
+
+	// Executor failures keep the mapping used by /execute
+	executor.executeError = fmt.Errorf("disk full")
+	err = client.Call("Notes.Add", map[string]interface{}{}, &reply)
+	rpcErr, ok := jsonrpc.AsError(err)
+	if !ok || rpcErr.Code != int(jsonrpc.ErrorCodeInternalError) || rpcErr.Data != "disk full" {
+		t.Errorf("expected internal error, got %v", err)
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPPrincipal(t *testing.T) {
+	interceptors := server.NewInterceptors()
+	var caller server.Caller
+	interceptors.Use(func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		caller = call.Caller
+		return next(ctx, call)
+	})
+	handler := NewMethodExecutionHandler(NewMockMethodExecutor(), WithInterceptors(interceptors))
+
+	principal := &server.Principal{Subject: "ada", Scheme: "bearer"}
+	body := []byte(`{"jsonrpc":"2.0","method":"Notes.Add","params":{},"id":1}`)
+	req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(body))
+	req = req.WithContext(server.ContextWithPrincipal(req.Context(), principal))
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if caller.Principal != principal {
+		t.Errorf("expected principal %+v, got %+v", principal, caller.Principal)
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPRateLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		expected   string
+		retryAfter string
+	}{
+		{
+			name:       "single_request",
+			body:       `{"jsonrpc":"2.0","method":"Search.Query","params":{},"id":2}`,
+			expected:   `{"jsonrpc":"2.0","error":{"code":-32004,"message":"Rate limit exceeded: tool Search.Query","data":{"retryAfter":10}},"id":2}`,
+			retryAfter: "10",
+		},
+		{
+			name:       "batch",
+			body:       `[{"jsonrpc":"2.0","method":"Search.Query","params":{},"id":2},{"jsonrpc":"2.0","method":"Search.Other","params":{},"id":3}]`,
+			expected:   `[{"jsonrpc":"2.0","error":{"code":-32004,"message":"Rate limit exceeded: tool Search.Query","data":{"retryAfter":10}},"id":2},{"jsonrpc":"2.0","result":{"result":"success"},"id":3}]`,
+			retryAfter: "10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := server.NewLimiter(server.WithToolLimit("Search", "Query", server.Limit{Rate: 0.1}))
+			interceptors := server.NewInterceptors()
+			interceptors.Use(limiter.Interceptor())
+			handler := NewMethodExecutionHandler(NewMockMethodExecutor(), WithInterceptors(interceptors))
+
+			// The first call uses up the bucket
+			first := []byte(`{"jsonrpc":"2.0","method":"Search.Query","params":{},"id":1}`)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(first)))
+			if w.Header().Get("Retry-After") != "" {
+				t.Fatalf("expected no Retry-After on admitted call, got %q", w.Header().Get("Retry-After"))
+			}
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(tt.body))))
+
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("expected Retry-After %q, got %q", tt.retryAfter, got)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			json.Unmarshal([]byte(tt.expected), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPAsync(t *testing.T) {
+	tests := []struct {
+		name          string
+		withJobs      bool
+		expectedError string
+	}{
+		{
+			name:     "job_is_submitted",
+			withJobs: true,
+		},
+		{
+			name:          "async_not_enabled",
+			expectedError: `{"code":-32600,"data":"async execution is not enabled","message":"Invalid Request"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := jobs.NewManager(jobs.NewMemoryStore())
+			var opts []MethodExecutionHandlerOpts
+			if tt.withJobs {
+				opts = append(opts, WithJobs(manager))
+			}
+			handler := NewMethodExecutionHandler(NewMockMethodExecutor(), opts...)
+
+			body := []byte(`{"jsonrpc":"2.0","method":"Notes.Add","params":{"text":"hi"},"async":true,"id":1}`)
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(body))
+			req = req.WithContext(server.ContextWithPrincipal(req.Context(), &server.Principal{Subject: "ada"}))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			var response struct {
+				Result struct {
+					JobID  string      `json:"jobId"`
+					Status jobs.Status `json:"status"`
+				} `json:"result"`
+				Error json.RawMessage `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if tt.expectedError != "" {
+				if string(response.Error) != tt.expectedError {
+					t.Errorf("expected error %s, got %s", tt.expectedError, response.Error)
+				}
+				return
+			}
+			if response.Result.JobID == "" || response.Result.Status != jobs.StatusPending {
+				t.Fatalf("expected pending job, got %s", w.Body.String())
+			}
+
+			// The job belongs to the caller and records the method's result
+			if err := manager.Shutdown(context.Background()); err != nil {
+				t.Fatalf("failed to wait for job: %v", err)
+			}
+			job, err := manager.Get(context.Background(), "ada", response.Result.JobID)
+			if err != nil {
+				t.Fatalf("failed to get job: %v", err)
+			}
+			if job.Status != jobs.StatusSucceeded || string(job.Result) != `{"result":"success"}` {
+				t.Errorf("expected succeeded job with result, got %+v", job)
+			}
+			if job.Method != "Notes.Add" || !reflect.DeepEqual(job.Params, map[string]interface{}{"text": "hi"}) {
+				t.Errorf("unexpected job call %s %v", job.Method, job.Params)
+			}
+		})
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPTraceContext(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name     string
+		body     string
+		header   http.Header
+		expected string
+	}{
+		{
+			name:     "single_request",
+			body:     `{"jsonrpc":"2.0","method":"Notes.Add","params":{},"id":1}`,
+			header:   http.Header{"Traceparent": {traceparent}, "Tracestate": {"rojo=00f067aa0ba902b7"}},
+			expected: traceparent,
+		},
+		{
+			name:     "batch_request",
+			body:     `[{"jsonrpc":"2.0","method":"Notes.Add","params":{},"id":1}]`,
+			header:   http.Header{"Traceparent": {traceparent}},
+			expected: traceparent,
+		},
+		{
+			name:     "streamed_request",
+			body:     `{"jsonrpc":"2.0","method":"Notes.Add","params":{},"id":1}`,
+			header:   http.Header{"Traceparent": {traceparent}, "Accept": {"text/event-stream"}},
+			expected: traceparent,
+		},
+		{
+			name:     "invalid_traceparent",
+			body:     `{"jsonrpc":"2.0","method":"Notes.Add","params":{},"id":1}`,
+			header:   http.Header{"Traceparent": {"00-not-a-trace"}},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptors := server.NewInterceptors()
+			got := ""
+			interceptors.Use(func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+				if sc, ok := tracing.SpanContextFromContext(ctx); ok {
+					got = sc.Traceparent()
+				}
+				return next(ctx, call)
+			})
+			handler := NewMethodExecutionHandler(NewMockMethodExecutor(), WithInterceptors(interceptors))
+
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(tt.body)))
+			req.Header = tt.header
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expected {
+				t.Errorf("expected traceparent %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPErrorCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		params       string
+		opts         []MethodExecutionHandlerOpts
+		expectedCode int
+		expectedData interface{}
+		expectStack  bool
+	}{
+		{
+			name:         "service_not_found",
+			method:       "Missing.Hello",
+			params:       `{}`,
+			expectedCode: -32601,
+			expectedData: "service 'Missing' not found",
+		},
+		{
+			name:         "method_not_found",
+			method:       "TestService.Missing",
+			params:       `{}`,
+			expectedCode: -32601,
+			expectedData: "method 'Missing' not found in service 'TestService'",
+		},
+		{
+			name:         "invalid_params",
+			method:       "TestService.Hello",
+			params:       `{"name":42}`,
+			expectedCode: -32602,
+			expectedData: "failed to convert parameters to request: name: cannot convert 42 to string",
+		},
+		{
+			name:         "tool_failed",
+			method:       "TestService.HelloWithError",
+			params:       `{}`,
+			expectedCode: -32000,
+			expectedData: "test error",
+		},
+		{
+			name:         "panic",
+			method:       "PanicService.Explode",
+			params:       `{}`,
+			expectedCode: -32603,
+			expectedData: "panic: boom",
+		},
+		{
+			name:         "panic_with_debug_errors",
+			method:       "PanicService.Explode",
+			params:       `{}`,
+			opts:         []MethodExecutionHandlerOpts{WithDebugErrors()},
+			expectedCode: -32603,
+			expectStack:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+			executor.RegisterService(&TestService{})
+			executor.RegisterService(&PanicService{})
+			handler := NewMethodExecutionHandler(executor, tt.opts...)
+
+			body := `{"jsonrpc":"2.0","method":"` + tt.method + `","params":` + tt.params + `,"id":1}`
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(body)))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var response struct {
+				Error *jsonrpc.Error `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error == nil {
+				t.Fatalf("expected an error response, got %s", w.Body.String())
+			}
+			if response.Error.Code != tt.expectedCode {
+				t.Errorf("expected code %d, got %d", tt.expectedCode, response.Error.Code)
+			}
+			if tt.expectStack {
+				data, _ := response.Error.Data.(map[string]interface{})
+				if data["error"] != "panic: boom" || !strings.Contains(fmt.Sprint(data["stack"]), "PanicService") {
+					t.Errorf("expected the panic and its stack as data, got %v", response.Error.Data)
+				}
+				return
+			}
+			if !reflect.DeepEqual(response.Error.Data, tt.expectedData) {
+				t.Errorf("expected data %v, got %v", tt.expectedData, response.Error.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// IdempotencyKeyHeader carries the idempotency key of a single request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyKeyField is the JSON-RPC extension member carrying an idempotency key
+const idempotencyKeyField = "idempotencyKey"
+
+// maxIdempotencyKeys bounds the keys tracked by an idempotency store
+const maxIdempotencyKeys = 4096
+
+// WithIdempotency makes requests that carry an idempotency key safe to retry. The key is
+// sent in the Idempotency-Key header or, e.g. for batch entries, as an "idempotencyKey"
+// member of the request object. The first request with a key runs and its response is kept
+// for ttl; repeats with the same key replay that response instead of running the method
+// again, and repeats that arrive while it is still running wait for it. Keys are scoped to
+// the caller's principal, and reusing a key for a different method or params is rejected.
+//
+// Keyed calls are not cancelled when the client disconnects, so that a retry after a client
+// timeout receives the outcome of the first attempt. Failures that may pass when retried are
+// not kept, so a repeat runs the call again: rate limits (-32004), timeouts (-32001) and
+// internal errors (-32603).
+//
+// Replays do not run the interceptors. A repeat receives the response the interceptors
+// produced for the first request, so authorization is not checked again: a principal whose
+// roles were revoked since still gets the kept response until it expires.
+// At most 4096 keys are tracked; when they are all taken, the responses closest to expiry
+// are dropped first, and calls made while every key is still running are not deduplicated.
+func WithIdempotency(ttl time.Duration) MethodExecutionHandlerOpts {
+	return func(h *MethodExecutionHandler) {
+		h.idempotency = newIdempotencyStore(ttl)
+	}
+}
+
+// applyIdempotencyHeader copies the Idempotency-Key header into a single request
+// that does not carry its own key
+func applyIdempotencyHeader(r *http.Request, request map[string]interface{}) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if _, hasKey := request[idempotencyKeyField]; key != "" && !hasKey && request != nil {
+		request[idempotencyKeyField] = key
+	}
+}
+
+// idempotencyStore keeps the responses of keyed requests until they expire
+type idempotencyStore struct {
+	mutex   sync.Mutex
+	now     func() time.Time
+	ttl     time.Duration
+	pruned  time.Time
+	entries map[string]*idempotencyEntry // Key: principal subject and idempotency key
+}
+
+// idempotencyEntry is the response to a keyed request, once done is closed
+type idempotencyEntry struct {
+	fingerprint string
+	done        chan struct{}
+	response    map[string]interface{} // nil if the response was not kept
+	expires     time.Time
+}
+
+// newIdempotencyStore creates an empty store keeping responses for ttl, by default a day
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &idempotencyStore{now: time.Now, ttl: ttl, entries: make(map[string]*idempotencyEntry)}
+}
+
+// do returns the kept response for key, or runs the request and keeps its response.
+// fingerprint identifies the call made with key.
+func (s *idempotencyStore) do(ctx context.Context, key, fingerprint string, run func() map[string]interface{}) (map[string]interface{}, *jsonrpc.Error) {
+	for {
+		s.mutex.Lock()
+		s.pruneLocked()
+		entry, exists := s.entries[key]
+		if exists && s.expiredLocked(entry) {
+			exists = false
+		}
+		if !exists {
+			if !s.makeRoomLocked() {
+				s.mutex.Unlock()
+				return run(), nil
+			}
+			entry = &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{})}
+			s.entries[key] = entry
+			s.mutex.Unlock()
+			return s.run(key, entry, run), nil
+		}
+		s.mutex.Unlock()
+
+		if entry.fingerprint != fingerprint {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorCodeInvalidRequest, jsonrpc.ErrorCodeInvalidRequest.Message(),
+				"idempotency key was already used for a different call")
+		}
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, jsonrpc.NewError(jsonrpc.ErrorCodeInternalError, jsonrpc.ErrorCodeInternalError.Message(), ctx.Err().Error())
+		}
+		if entry.response != nil {
+			return entry.response, nil
+		}
+		// The first attempt was not kept, so this one runs the call itself
+	}
+}
+
+// run executes the first request with a key and keeps its response
+func (s *idempotencyStore) run(key string, entry *idempotencyEntry, fn func() map[string]interface{}) map[string]interface{} {
+	response := fn()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if transientFailure(response) {
+		delete(s.entries, key)
+	} else {
+		entry.response = response
+		entry.expires = s.now().Add(s.ttl)
+	}
+	close(entry.done)
+	return response
+}
+
+// transientCodes are the error codes of failures that may pass when the call is retried
+var transientCodes = map[int]bool{
+	int(jsonrpc.ErrorCodeRateLimited):   true,
+	int(jsonrpc.ErrorCodeTimeout):       true,
+	int(jsonrpc.ErrorCodeInternalError): true,
+}
+
+// transientFailure reports whether response is an error that may pass when retried
+func transientFailure(response map[string]interface{}) bool {
+	errorObj, ok := response["error"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	code, _ := errorObj["code"].(int)
+	return transientCodes[code]
+}
+
+// pruneLocked drops expired responses, at most once a minute; the caller must hold the mutex
+func (s *idempotencyStore) pruneLocked() {
+	now := s.now()
+	if now.Sub(s.pruned) < time.Minute {
+		return
+	}
+	s.pruned = now
+	for key, entry := range s.entries {
+		if s.expiredLocked(entry) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// makeRoomLocked drops kept responses until another key fits, expired ones first, then
+// those closest to expiry. It reports false if every key belongs to a running call.
+// The caller must hold the mutex.
+func (s *idempotencyStore) makeRoomLocked() bool {
+	if len(s.entries) < maxIdempotencyKeys {
+		return true
+	}
+	for key, entry := range s.entries {
+		if s.expiredLocked(entry) {
+			delete(s.entries, key)
+		}
+	}
+	for len(s.entries) >= maxIdempotencyKeys {
+		oldest := ""
+		for key, entry := range s.entries {
+			if isClosed(entry.done) && (oldest == "" || entry.expires.Before(s.entries[oldest].expires)) {
+				oldest = key
+			}
+		}
+		if oldest == "" {
+			return false
+		}
+		delete(s.entries, oldest)
+	}
+	return true
+}
+
+// expiredLocked reports whether a kept response has expired; the caller must hold the mutex
+func (s *idempotencyStore) expiredLocked(entry *idempotencyEntry) bool {
+	return isClosed(entry.done) && !s.now().Before(entry.expires)
+}
+
+// isClosed reports whether ch is closed
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotent runs a keyed request through the idempotency store. The call is detached from
+// the client's cancellation, and a replayed response gets the id of the current request.
+func (h *MethodExecutionHandler) idempotent(ctx context.Context, key string, request map[string]interface{}, call *server.ToolCall, run func(ctx context.Context) map[string]interface{}) map[string]interface{} {
+	// Subjects are only unique within an authentication scheme: a JWT "sub" may equal an HMAC key ID
+	scope := ""
+	if principal := call.Caller.Principal; principal != nil {
+		scope = principal.Scheme + "\x00" + principal.Subject
+	}
+	fingerprint, err := canonicalParams(call.ServiceName, call.MethodName, call.Params)
+	if err != nil {
+		return h.errorResponse(request, -32602, "Invalid params", err.Error())
+	}
+
+	response, rpcErr := h.idempotency.do(ctx, scope+"\x00"+key, fingerprint, func() map[string]interface{} {
+		return run(context.WithoutCancel(ctx))
+	})
+	if rpcErr != nil {
+		return h.errorResponse(request, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+	}
+
+	replay := maps.Clone(response)
+	replay["id"] = request["id"]
+	return replay
+}
@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// countingMethodExecutor returns how often it has been called
+type countingMethodExecutor struct {
+	mutex   sync.Mutex
+	calls   int
+	release chan struct{} // If set, calls block until it is closed
+	ctxErr  error
+	failing error // If set, the first call returns it
+}
+
+func (c *countingMethodExecutor) ExecuteMethod(serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	return c.ExecuteMethodContext(context.Background(), serviceName, methodName, params)
+}
+
+func (c *countingMethodExecutor) ExecuteMethodContext(ctx context.Context, serviceName, methodName string, params map[string]interface{}) (interface{}, error) {
+	if c.release != nil {
+		<-c.release
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls++
+	c.ctxErr = ctx.Err()
+	if c.failing != nil && c.calls == 1 {
+		return nil, c.failing
+	}
+	return map[string]interface{}{"call": c.calls}, nil
+}
+
+// idempotentRequest is a request to send to the handler
+type idempotentRequest struct {
+	body      string
+	header    string
+	principal string
+	scheme    string // Authentication scheme of the principal
+}
+
+// send posts a request and returns the decoded response
+func send(t *testing.T, handler http.Handler, ctx context.Context, req idempotentRequest) map[string]interface{} {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader([]byte(req.body)))
+	if req.header != "" {
+		r.Header.Set(IdempotencyKeyHeader, req.header)
+	}
+	if req.principal != "" {
+		ctx = server.ContextWithPrincipal(ctx, &server.Principal{Subject: req.principal, Scheme: req.scheme})
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r.WithContext(ctx))
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response %q: %v", w.Body.String(), err)
+	}
+	return response
+}
+
+func TestWithIdempotency(t *testing.T) {
+	tests := []struct {
+		name          string
+		failing       error
+		requests      []idempotentRequest
+		expected      []string
+		expectedCalls int
+	}{
+		{
+			name: "header_key_replays_response",
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`, header: "k1"},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":2}`, header: "k1"},
+			},
+			expected: []string{
+				`{"id":1,"jsonrpc":"2.0","result":{"call":1}}`,
+				`{"id":2,"jsonrpc":"2.0","result":{"call":1}}`,
+			},
+			expectedCalls: 1,
+		},
+		{
+			name: "field_key_replays_response",
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"idempotencyKey":"k1","id":1}`},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"idempotencyKey":"k1","id":2}`},
+			},
+			expected: []string{
+				`{"id":1,"jsonrpc":"2.0","result":{"call":1}}`,
+				`{"id":2,"jsonrpc":"2.0","result":{"call":1}}`,
+			},
+			expectedCalls: 1,
+		},
+		{
+			name: "key_reused_for_other_params",
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`, header: "k1"},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":7},"id":2}`, header: "k1"},
+			},
+			expected: []string{
+				`{"id":1,"jsonrpc":"2.0","result":{"call":1}}`,
+				`{"error":{"code":-32600,"data":"idempotency key was already used for a different call","message":"Invalid Request"},"id":2,"jsonrpc":"2.0"}`,
+			},
+			expectedCalls: 1,
+		},
+		{
+			name: "keys_are_scoped_to_the_principal",
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`, header: "k1", principal: "ada"},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":2}`, header: "k1", principal: "bob"},
+			},
+			expected: []string{
+				`{"id":1,"jsonrpc":"2.0","result":{"call":1}}`,
+				`{"id":2,"jsonrpc":"2.0","result":{"call":2}}`,
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "keys_are_scoped_to_the_authentication_scheme",
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`, header: "k1", principal: "ada", scheme: "jwt"},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":2}`, header: "k1", principal: "ada", scheme: "hmac"},
+			},
+			expected: []string{
+				`{"id":1,"jsonrpc":"2.0","result":{"call":1}}`,
+				`{"id":2,"jsonrpc":"2.0","result":{"call":2}}`,
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "requests_without_key_run_every_time",
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":2}`},
+			},
+			expected: []string{
+				`{"id":1,"jsonrpc":"2.0","result":{"call":1}}`,
+				`{"id":2,"jsonrpc":"2.0","result":{"call":2}}`,
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:    "internal_errors_are_not_kept",
+			failing: errors.New("database is locked"),
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`, header: "k1"},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":2}`, header: "k1"},
+			},
+			expected: []string{
+				`{"error":{"code":-32603,"data":"database is locked","message":"Internal error"},"id":1,"jsonrpc":"2.0"}`,
+				`{"id":2,"jsonrpc":"2.0","result":{"call":2}}`,
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:    "timeouts_are_not_kept",
+			failing: context.DeadlineExceeded,
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`, header: "k1"},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":2}`, header: "k1"},
+			},
+			expected: []string{
+				`{"error":{"code":-32001,"data":"context deadline exceeded","message":"Request timeout"},"id":1,"jsonrpc":"2.0"}`,
+				`{"id":2,"jsonrpc":"2.0","result":{"call":2}}`,
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:    "tool_failures_are_kept",
+			failing: fmt.Errorf("%w: card declined", server.ErrToolFailed),
+			requests: []idempotentRequest{
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":1}`, header: "k1"},
+				{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{"amount":5},"id":2}`, header: "k1"},
+			},
+			expected: []string{
+				`{"error":{"code":-32000,"data":"tool failed: card declined","message":"Server error"},"id":1,"jsonrpc":"2.0"}`,
+				`{"error":{"code":-32000,"data":"tool failed: card declined","message":"Server error"},"id":2,"jsonrpc":"2.0"}`,
+			},
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &countingMethodExecutor{failing: tt.failing}
+			handler := NewMethodExecutionHandler(executor, WithIdempotency(time.Hour))
+
+			for i, req := range tt.requests {
+				response := send(t, handler, context.Background(), req)
+				if encoded, _ := json.Marshal(response); string(encoded) != tt.expected[i] {
+					t.Errorf("request %d: expected %s, got %s", i, tt.expected[i], encoded)
+				}
+			}
+			if executor.calls != tt.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tt.expectedCalls, executor.calls)
+			}
+		})
+	}
+}
+
+func TestWithIdempotency_ConcurrentRetryWaits(t *testing.T) {
+	executor := &countingMethodExecutor{release: make(chan struct{})}
+	handler := NewMethodExecutionHandler(executor, WithIdempotency(time.Hour))
+	req := idempotentRequest{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{},"id":1}`, header: "k1"}
+
+	responses := make([]map[string]interface{}, 2)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = send(t, handler, context.Background(), req)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(executor.release)
+	wg.Wait()
+
+	if executor.calls != 1 {
+		t.Errorf("expected 1 call, got %d", executor.calls)
+	}
+	for _, response := range responses {
+		if result, _ := response["result"].(map[string]interface{}); result["call"] != float64(1) {
+			t.Errorf("expected replay of the first call, got %v", response)
+		}
+	}
+}
+
+func TestWithIdempotency_IgnoresClientCancellation(t *testing.T) {
+	executor := &countingMethodExecutor{}
+	handler := NewMethodExecutionHandler(executor, WithIdempotency(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	send(t, handler, ctx, idempotentRequest{body: `{"jsonrpc":"2.0","method":"Billing.Charge","params":{},"id":1}`, header: "k1"})
+
+	if executor.ctxErr != nil {
+		t.Errorf("expected keyed call to be detached from the client, got %v", executor.ctxErr)
+	}
+}
+
+func TestIdempotencyStore_BoundsKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name            string
+		running         bool // Whether the tracked calls are still running
+		expectedTracked bool
+		expectedDropped string
+	}{
+		{
+			name:            "drops_response_closest_to_expiry",
+			expectedTracked: true,
+			expectedDropped: "key-0",
+		},
+		{
+			name:            "untracked_while_all_calls_run",
+			running:         true,
+			expectedTracked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newIdempotencyStore(time.Hour)
+			s.now = func() time.Time { return now }
+			s.pruned = now
+			for i := range maxIdempotencyKeys {
+				entry := &idempotencyEntry{fingerprint: "f", done: make(chan struct{}), expires: now.Add(time.Duration(i+1) * time.Minute)}
+				if !tt.running {
+					entry.response = map[string]interface{}{"result": i}
+					close(entry.done)
+				}
+				s.entries[fmt.Sprintf("key-%d", i)] = entry
+			}
+
+			response, rpcErr := s.do(context.Background(), "new", "f", func() map[string]interface{} {
+				return map[string]interface{}{"result": "new"}
+			})
+			if rpcErr != nil || response["result"] != "new" {
+				t.Fatalf("expected the call to run, got %v, %v", response, rpcErr)
+			}
+			if len(s.entries) > maxIdempotencyKeys {
+				t.Errorf("expected at most %d keys, got %d", maxIdempotencyKeys, len(s.entries))
+			}
+			if _, tracked := s.entries["new"]; tracked != tt.expectedTracked {
+				t.Errorf("expected new key tracked %v, got %v", tt.expectedTracked, tracked)
+			}
+			if _, kept := s.entries[tt.expectedDropped]; tt.expectedDropped != "" && kept {
+				t.Errorf("expected %s to be dropped", tt.expectedDropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// WithLogger logs tool registrations and discovery requests, see (*ToolService).SetLogger
+func WithLogger(logger *slog.Logger, opts ...server.LoggerOpts) ToolServiceOpts {
+	return func(t *ToolService) {
+		t.logger = server.NewLogger(logger, opts...)
+	}
+}
+
+// SetLogger sets the logger of the tool service. It is called by server.Server for its
+// tool registry and must be called before tools are registered.
+func (t *ToolService) SetLogger(logger *server.Logger) {
+	t.logger = logger
+}
+
+// WithExecutorLogger logs service registrations, the start and end of every call, params
+// that cannot be decoded and panics of service methods, see (*JSONRPCMethodExecutor).SetLogger
+func WithExecutorLogger(logger *slog.Logger, opts ...server.LoggerOpts) JSONRPCMethodExecutorOpts {
+	return func(e *JSONRPCMethodExecutor) {
+		e.logger = server.NewLogger(logger, opts...)
+	}
+}
+
+// SetLogger sets the logger of the executor. It is called by server.Server for its method
+// executor and must be called before services are registered.
+func (e *JSONRPCMethodExecutor) SetLogger(logger *server.Logger) {
+	e.logger = logger
+}
+
+// logCallStart logs the start of a call, with its params if payloads are logged
+func (e *JSONRPCMethodExecutor) logCallStart(ctx context.Context, serviceName, methodName string, params map[string]interface{}) {
+	if e.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("tool", serviceName+"."+methodName)}
+	if e.logger.Payloads() {
+		attrs = append(attrs, slog.Any("params", params))
+	}
+	e.logger.Event(ctx, "call started", attrs...)
+}
+
+// logCallEnd logs the outcome of a call: failures as warnings, successes as routine
+// events with their result if payloads are logged
+func (e *JSONRPCMethodExecutor) logCallEnd(ctx context.Context, serviceName, methodName string, start time.Time, result interface{}, cached bool, err error) {
+	if e.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("tool", serviceName+"."+methodName),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	if err != nil {
+		e.logger.Warn(ctx, "call failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	if cached {
+		attrs = append(attrs, slog.Bool("cached", true))
+	}
+	if e.logger.Payloads() {
+		attrs = append(attrs, slog.Any("result", result))
+	}
+	e.logger.Event(ctx, "call finished", attrs...)
+}
+
+// recoverPanic turns a panic of a service method into a *server.PanicError stored in errp
+// and logs it with its stack. It must be deferred directly by the function calling the method.
+func (e *JSONRPCMethodExecutor) recoverPanic(ctx context.Context, serviceName, methodName string, errp *error) {
+	if r := recover(); r != nil {
+		panicErr := server.NewPanicError(r)
+		e.logger.Error(ctx, "call panicked",
+			slog.String("tool", serviceName+"."+methodName),
+			slog.Any("panic", r),
+			slog.String("stack", string(panicErr.Stack)),
+		)
+		*errp = panicErr
+	}
+}
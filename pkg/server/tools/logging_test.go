@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// PanicService has a method that panics
+type PanicService struct{}
+
+func (s *PanicService) Explode(req HelloRequest, resp *HelloResponse) error {
+	panic("boom")
+}
+
+// newTestLogger returns a logger writing JSON lines to the returned buffer
+func newTestLogger() (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(handler), &buf
+}
+
+// decodeEvents parses logged JSON lines, keeping the level, message and the given attributes
+func decodeEvents(t *testing.T, buf *bytes.Buffer, keep ...string) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var event map[string]any
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatalf("invalid log line: %v", err)
+		}
+		kept := map[string]any{"level": event["level"], "msg": event["msg"]}
+		for _, key := range keep {
+			if value, ok := event[key]; ok {
+				kept[key] = value
+			}
+		}
+		events = append(events, kept)
+	}
+	return events
+}
+
+func TestJSONRPCMethodExecutor_Logging(t *testing.T) {
+	ctx := server.ContextWithRequestID(context.Background(), "req-1")
+
+	tests := []struct {
+		name       string
+		opts       []server.LoggerOpts
+		methodName string
+		params     map[string]interface{}
+		expected   []map[string]any
+	}{
+		{
+			name:       "successful_call",
+			methodName: "Hello",
+			params:     map[string]interface{}{"name": "Ada"},
+			expected: []map[string]any{
+				{"level": "INFO", "msg": "call started", "tool": "TestService.Hello", "request_id": "req-1"},
+				{"level": "INFO", "msg": "call finished", "tool": "TestService.Hello", "request_id": "req-1"},
+			},
+		},
+		{
+			name:       "successful_call_with_payloads",
+			opts:       []server.LoggerOpts{server.WithPayloads(), server.WithLogLevel(slog.LevelDebug)},
+			methodName: "Hello",
+			params:     map[string]interface{}{"name": "Ada"},
+			expected: []map[string]any{
+				{"level": "DEBUG", "msg": "call started", "tool": "TestService.Hello", "request_id": "req-1", "params": map[string]any{"name": "Ada"}},
+				{"level": "DEBUG", "msg": "call finished", "tool": "TestService.Hello", "request_id": "req-1", "result": map[string]any{"message": "Hello, Ada!"}},
+			},
+		},
+		{
+			name:       "failed_call",
+			methodName: "HelloWithError",
+			params:     map[string]interface{}{"name": "Ada"},
+			expected: []map[string]any{
+				{"level": "INFO", "msg": "call started", "tool": "TestService.HelloWithError", "request_id": "req-1"},
+				{"level": "WARN", "msg": "call failed", "tool": "TestService.HelloWithError", "request_id": "req-1", "error": "test error"},
+			},
+		},
+		{
+			name:       "params_decode_failure",
+			methodName: "Hello",
+			params:     map[string]interface{}{"name": 42},
+			expected: []map[string]any{
+				{"level": "INFO", "msg": "call started", "tool": "TestService.Hello", "request_id": "req-1"},
+				{"level": "WARN", "msg": "params decode failed", "tool": "TestService.Hello", "request_id": "req-1", "error": "name: cannot convert 42 to string"},
+				{"level": "WARN", "msg": "call failed", "tool": "TestService.Hello", "request_id": "req-1", "error": "failed to convert parameters to request: name: cannot convert 42 to string"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger()
+			executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry(), WithExecutorLogger(logger, tt.opts...))
+			if err := executor.RegisterService(&TestService{}); err != nil {
+				t.Fatalf("RegisterService failed: %v", err)
+			}
+			buf.Reset()
+
+			executor.ExecuteMethodContext(ctx, "TestService", tt.methodName, tt.params)
+
+			got := decodeEvents(t, buf, "tool", "request_id", "params", "result", "error")
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected events %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJSONRPCMethodExecutor_LoggingPanic(t *testing.T) {
+	logger, buf := newTestLogger()
+	executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry(), WithExecutorLogger(logger))
+	executor.RegisterService(&PanicService{})
+	buf.Reset()
+
+	_, err := executor.ExecuteMethod("PanicService", "Explode", map[string]interface{}{})
+	var panicErr *server.PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("expected the panic to be returned as an error, got %v", err)
+	}
+
+	events := decodeEvents(t, buf, "tool", "panic")
+	expected := map[string]any{"level": "ERROR", "msg": "call panicked", "tool": "PanicService.Explode", "panic": "boom"}
+	if len(events) != 3 || !reflect.DeepEqual(events[1], expected) {
+		t.Errorf("expected a call panicked event, got %v", events)
+	}
+}
+
+func TestToolService_Logging(t *testing.T) {
+	logger, buf := newTestLogger()
+	toolService := NewToolService(WithLogger(logger))
+
+	toolService.RegisterMethod("TestService", "Hello", "Greets", nil)
+	toolService.RegisterMethodLLM("TestService2.Add", "Adds numbers")
+	req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+	req = req.WithContext(server.ContextWithPrincipal(server.ContextWithRequestID(req.Context(), "req-1"), &server.Principal{Subject: "alice"}))
+	toolService.ToolDiscoveryHandler().ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := []map[string]any{
+		{"level": "INFO", "msg": "tool registered", "tool": "TestService.Hello", "registration": "struct"},
+		{"level": "INFO", "msg": "tool registered", "tool": "TestService2.Add", "registration": "llm"},
+		{"level": "INFO", "msg": "tool discovery", "tools": float64(2), "request_id": "req-1", "subject": "alice"},
+	}
+	if got := decodeEvents(t, buf, "tool", "registration", "tools", "request_id", "subject"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected events %v, got %v", expected, got)
+	}
+}
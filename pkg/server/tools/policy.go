@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// Policy restricts who may call a tool. Every policy requires an authenticated principal;
+// the principal must also hold all of Scopes and, when Roles is not empty, at least one of Roles.
+type Policy struct {
+	Scopes []string
+	Roles  []string
+}
+
+// WithPolicy attaches a policy to a method or service when the tool service is created, see SetPolicy
+func WithPolicy(name string, policy Policy) ToolServiceOpts {
+	return func(t *ToolService) {
+		t.policies[name] = policy
+	}
+}
+
+// SetPolicy attaches a policy to a method ("ServiceName.MethodName") or to every method
+// of a service ("ServiceName"). Method policies take precedence over service policies.
+// Tools without a policy can be called by anyone.
+func (t *ToolService) SetPolicy(name string, policy Policy) error {
+	serviceName, methodName, isMethod := strings.Cut(name, ".")
+	if serviceName == "" || (isMethod && (methodName == "" || strings.Contains(methodName, "."))) {
+		return fmt.Errorf("policy name must be in format 'ServiceName' or 'ServiceName.MethodName'")
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.policies[name] = policy
+	return nil
+}
+
+// Policy returns the policy that applies to a method, if any
+func (t *ToolService) Policy(serviceName, methodName string) (Policy, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.policyLocked(serviceName, methodName)
+}
+
+// policyLocked returns the policy of a method; the caller must hold the mutex
+func (t *ToolService) policyLocked(serviceName, methodName string) (Policy, bool) {
+	if policy, ok := t.policies[serviceName+"."+methodName]; ok {
+		return policy, true
+	}
+	policy, ok := t.policies[serviceName]
+	return policy, ok
+}
+
+// Authorize reports whether principal may call a method. It returns a JSON-RPC
+// Unauthorized error when a policy applies and there is no principal, and a
+// Forbidden error when the principal does not satisfy the policy.
+func (t *ToolService) Authorize(principal *server.Principal, serviceName, methodName string) error {
+	policy, ok := t.Policy(serviceName, methodName)
+	if !ok {
+		return nil
+	}
+	return policy.check(principal)
+}
+
+// AuthorizedTools returns the registered tools that principal may call.
+// A nil principal sees only the tools without a policy.
+func (t *ToolService) AuthorizedTools(principal *server.Principal) map[string]ToolInfo {
+	tools := t.GetMethodRegistry()
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for name := range tools {
+		serviceName, methodName, _ := strings.Cut(name, ".")
+		if policy, ok := t.policyLocked(serviceName, methodName); ok && policy.check(principal) != nil {
+			delete(tools, name)
+		}
+	}
+	return tools
+}
+
+// AuthorizationInterceptor returns an interceptor that rejects calls whose caller does not
+// satisfy the method's policy. Direct calls without a principal come from the application
+// itself and are not checked.
+func (t *ToolService) AuthorizationInterceptor() server.Interceptor {
+	return func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		if call.Caller.Transport == server.TransportDirect && call.Caller.Principal == nil {
+			return next(ctx, call)
+		}
+		if err := t.Authorize(call.Caller.Principal, call.ServiceName, call.MethodName); err != nil {
+			return nil, err
+		}
+		return next(ctx, call)
+	}
+}
+
+// check returns an error if principal does not satisfy the policy
+func (p Policy) check(principal *server.Principal) error {
+	if principal == nil {
+		return jsonrpc.NewError(jsonrpc.ErrorCodeUnauthorized, jsonrpc.ErrorCodeUnauthorized.Message(), "authentication required")
+	}
+
+	var missing []string
+	for _, scope := range p.Scopes {
+		if !principal.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, jsonrpc.ErrorCodeForbidden.Message(),
+			fmt.Sprintf("missing scopes: %s", strings.Join(missing, ", ")))
+	}
+
+	if len(p.Roles) == 0 {
+		return nil
+	}
+	for _, role := range p.Roles {
+		if principal.HasRole(role) {
+			return nil
+		}
+	}
+	return jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, jsonrpc.ErrorCodeForbidden.Message(),
+		fmt.Sprintf("requires one of roles: %s", strings.Join(p.Roles, ", ")))
+}
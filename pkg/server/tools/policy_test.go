@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+func newPolicyToolService(t *testing.T) *ToolService {
+	t.Helper()
+	ts := NewToolService(WithPolicy("NoteService", Policy{Scopes: []string{"notes:read"}}))
+	ts.RegisterMethod("NoteService", "List", "Lists notes", nil)
+	ts.RegisterMethod("NoteService", "Delete", "Deletes a note", nil)
+	ts.RegisterMethod("HelloService", "Hello", "Greets a person", nil)
+	ts.RegisterMethodLLM("AdminService.Reset", "Resets everything")
+
+	if err := ts.SetPolicy("NoteService.Delete", Policy{Scopes: []string{"notes:read", "notes:write"}, Roles: []string{"admin", "owner"}}); err != nil {
+		t.Fatalf("failed to set policy: %v", err)
+	}
+	if err := ts.SetPolicy("AdminService", Policy{}); err != nil {
+		t.Fatalf("failed to set policy: %v", err)
+	}
+	return ts
+}
+
+func TestToolService_Authorize(t *testing.T) {
+	ts := newPolicyToolService(t)
+
+	reader := &server.Principal{Subject: "ada", Scopes: []string{"notes:read"}}
+	writer := &server.Principal{Subject: "bob", Scopes: []string{"notes:read", "notes:write"}}
+	owner := &server.Principal{Subject: "eve", Scopes: []string{"notes:read", "notes:write"}, Roles: []string{"owner"}}
+
+	tests := []struct {
+		name         string
+		principal    *server.Principal
+		method       string
+		expectedCode int
+		expectedData string
+	}{
+		{
+			name:   "tool_without_policy_is_open",
+			method: "HelloService.Hello",
+		},
+		{
+			name:         "policy_requires_principal",
+			method:       "NoteService.List",
+			expectedCode: int(jsonrpc.ErrorCodeUnauthorized),
+			expectedData: "authentication required",
+		},
+		{
+			name:      "service_policy_applies_to_methods",
+			principal: reader,
+			method:    "NoteService.List",
+		},
+		{
+			name:         "method_policy_overrides_service_policy",
+			principal:    reader,
+			method:       "NoteService.Delete",
+			expectedCode: int(jsonrpc.ErrorCodeForbidden),
+			expectedData: "missing scopes: notes:write",
+		},
+		{
+			name:         "roles_are_required",
+			principal:    writer,
+			method:       "NoteService.Delete",
+			expectedCode: int(jsonrpc.ErrorCodeForbidden),
+			expectedData: "requires one of roles: admin, owner",
+		},
+		{
+			name:      "any_role_is_enough",
+			principal: owner,
+			method:    "NoteService.Delete",
+		},
+		{
+			name:      "empty_policy_admits_any_principal",
+			principal: reader,
+			method:    "AdminService.Reset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceName, methodName, _ := strings.Cut(tt.method, ".")
+
+			err := ts.Authorize(tt.principal, serviceName, methodName)
+			if tt.expectedCode == 0 {
+				if err != nil {
+					t.Errorf("expected call to be allowed, got %v", err)
+				}
+				return
+			}
+
+			rpcErr, ok := jsonrpc.AsError(err)
+			if !ok {
+				t.Fatalf("expected JSON-RPC error, got %v", err)
+			}
+			if rpcErr.Code != tt.expectedCode || rpcErr.Data != tt.expectedData {
+				t.Errorf("expected code %d with data %q, got %+v", tt.expectedCode, tt.expectedData, rpcErr)
+			}
+		})
+	}
+}
+
+func TestToolService_SetPolicyInvalidName(t *testing.T) {
+	ts := NewToolService()
+	for _, name := range []string{"", ".Method", "Service.", "Service.Method.Extra"} {
+		if err := ts.SetPolicy(name, Policy{}); err == nil {
+			t.Errorf("expected error for policy name %q", name)
+		}
+	}
+}
+
+func TestToolService_ToolDiscoveryHandlerPolicies(t *testing.T) {
+	ts := newPolicyToolService(t)
+
+	tests := []struct {
+		name          string
+		principal     *server.Principal
+		expectedTools []string
+	}{
+		{
+			name:          "anonymous_sees_open_tools",
+			expectedTools: []string{"HelloService.Hello"},
+		},
+		{
+			name:          "reader_sees_readable_tools",
+			principal:     &server.Principal{Subject: "ada", Scopes: []string{"notes:read"}},
+			expectedTools: []string{"AdminService.Reset", "HelloService.Hello", "NoteService.List"},
+		},
+		{
+			name:          "admin_sees_everything",
+			principal:     &server.Principal{Subject: "root", Scopes: []string{"notes:read", "notes:write"}, Roles: []string{"admin"}},
+			expectedTools: []string{"AdminService.Reset", "HelloService.Hello", "NoteService.Delete", "NoteService.List"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+			if tt.principal != nil {
+				req = req.WithContext(server.ContextWithPrincipal(req.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			ts.ToolDiscoveryHandler().ServeHTTP(w, req)
+
+			var response struct {
+				Tools map[string]ToolInfo `json:"tools"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			names := make([]string, 0, len(response.Tools))
+			for name := range response.Tools {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.expectedTools) {
+				t.Errorf("expected tools %v, got %v", tt.expectedTools, names)
+			}
+		})
+	}
+}
+
+func TestToolService_AuthorizationInterceptor(t *testing.T) {
+	ts := newPolicyToolService(t)
+	interceptors := server.NewInterceptors()
+	interceptors.Use(ts.AuthorizationInterceptor())
+
+	tests := []struct {
+		name        string
+		caller      server.Caller
+		expectedErr bool
+	}{
+		{
+			name:        "anonymous_http_call_is_rejected",
+			caller:      server.Caller{Transport: server.TransportHTTP},
+			expectedErr: true,
+		},
+		{
+			name:   "authorized_http_call_is_allowed",
+			caller: server.Caller{Transport: server.TransportHTTP, Principal: &server.Principal{Scopes: []string{"notes:read"}}},
+		},
+		{
+			name:   "direct_call_without_principal_is_trusted",
+			caller: server.Caller{Transport: server.TransportDirect},
+		},
+		{
+			name:        "direct_call_with_principal_is_checked",
+			caller:      server.Caller{Transport: server.TransportDirect, Principal: &server.Principal{Subject: "ada"}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewMockMethodExecutor()
+			call := &server.ToolCall{ServiceName: "NoteService", MethodName: "List", Caller: tt.caller}
+
+			_, err := interceptors.Execute(context.Background(), executor, call)
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if executor.executeCalled == tt.expectedErr {
+				t.Errorf("expected executeCalled %v, got %v", !tt.expectedErr, executor.executeCalled)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema dialect of generated schemas
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// GenerateSchema builds a JSON Schema (draft 2020-12) describing how values of type t
+// are encoded as JSON. Struct fields follow encoding/json naming; fields without
+// omitempty are required, a `desc:"..."` tag becomes the description and an
+// `enum:"a,b,c"` tag restricts the allowed values. Recursive types are emitted
+// once under "$defs" and referenced with "$ref".
+func GenerateSchema(t reflect.Type) map[string]interface{} {
+	g := &schemaGenerator{
+		defs:       make(map[string]interface{}),
+		inProgress: make(map[reflect.Type]bool),
+		recursive:  make(map[reflect.Type]bool),
+	}
+
+	schema := g.schemaFor(t)
+	schema["$schema"] = SchemaDialect
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema
+}
+
+// schemaGenerator tracks state while walking a type graph
+type schemaGenerator struct {
+	defs       map[string]interface{}
+	inProgress map[reflect.Type]bool
+	recursive  map[reflect.Type]bool
+}
+
+// schemaFor returns the schema for a single type
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	case reflect.PointerTo(t).Implements(jsonUnmarshalerType) || t.Implements(jsonMarshalerType):
+		// Custom JSON encodings can take any shape
+		return map[string]interface{}{}
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		schema := map[string]interface{}{
+			"type":  "array",
+			"items": g.schemaFor(t.Elem()),
+		}
+		if t.Kind() == reflect.Array {
+			schema["minItems"] = t.Len()
+			schema["maxItems"] = t.Len()
+		}
+		return schema
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schemaFor(t.Elem()),
+		}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		// Interfaces and anything else accept any value
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the object schema for a struct, switching to a $ref for recursive types
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	if g.inProgress[t] {
+		g.recursive[t] = true
+		return map[string]interface{}{"$ref": "#/$defs/" + defName(t)}
+	}
+	g.inProgress[t] = true
+	defer delete(g.inProgress, t)
+
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for _, field := range structFields(t) {
+		fieldSchema := g.schemaFor(field.Type)
+
+		if desc := field.Tag.Get("desc"); desc != "" {
+			fieldSchema["description"] = desc
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			target := fieldSchema
+			if items, ok := fieldSchema["items"].(map[string]interface{}); ok {
+				target = items
+			}
+			target["enum"] = enumValues(enum, target["type"])
+		}
+
+		properties[field.Name] = fieldSchema
+		if !field.OmitEmpty {
+			required = append(required, field.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	if g.recursive[t] {
+		g.defs[defName(t)] = schema
+		return map[string]interface{}{"$ref": "#/$defs/" + defName(t)}
+	}
+	return schema
+}
+
+// defName returns the $defs key for a named or anonymous type
+func defName(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return strings.NewReplacer(" ", "", "{", "_", "}", "_", ";", "_").Replace(t.String())
+}
+
+// enumValues converts a comma separated enum tag into values of the schema's type
+func enumValues(tag string, schemaType interface{}) []interface{} {
+	var values []interface{}
+	for _, raw := range strings.Split(tag, ",") {
+		raw = strings.TrimSpace(raw)
+		switch schemaType {
+		case "integer":
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				values = append(values, n)
+				continue
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(raw, 64); err == nil {
+				values = append(values, f)
+				continue
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(raw); err == nil {
+				values = append(values, b)
+				continue
+			}
+		}
+		values = append(values, raw)
+	}
+	return values
+}
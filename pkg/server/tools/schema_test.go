@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaAddress struct {
+	Street string `json:"street" desc:"Street and number"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+type schemaRequest struct {
+	schemaBase
+	Name     string            `json:"name" desc:"Display name"`
+	Status   string            `json:"status" enum:"active,inactive"`
+	Priority int               `json:"priority,omitempty" enum:"1,2,3"`
+	Count    uint              `json:"count,omitempty"`
+	Ratio    float64           `json:"ratio,omitempty"`
+	Tags     []string          `json:"tags,omitempty" enum:"a,b"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Address  *schemaAddress    `json:"address,omitempty"`
+	Due      time.Time         `json:"due,omitempty"`
+	Data     []byte            `json:"data,omitempty"`
+	Any      interface{}       `json:"any,omitempty"`
+	Secret   string            `json:"-"`
+	internal string
+}
+
+type schemaNode struct {
+	Value    string        `json:"value"`
+	Children []*schemaNode `json:"children,omitempty"`
+}
+
+// schemaJSON round-trips a schema through JSON so it can be compared with literal values
+func schemaJSON(t *testing.T, schema map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal schema: %v", err)
+	}
+	return decoded
+}
+
+func TestGenerateSchema(t *testing.T) {
+	schema := schemaJSON(t, GenerateSchema(reflect.TypeOf(schemaRequest{})))
+
+	if schema["$schema"] != SchemaDialect {
+		t.Errorf("expected $schema %s, got %v", SchemaDialect, schema["$schema"])
+	}
+	if schema["type"] != "object" {
+		t.Errorf("expected object schema, got %v", schema["type"])
+	}
+
+	expectedRequired := []interface{}{"id", "name", "status"}
+	if !reflect.DeepEqual(schema["required"], expectedRequired) {
+		t.Errorf("expected required %v, got %v", expectedRequired, schema["required"])
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"Secret", "internal", "schemaBase"} {
+		if _, exists := properties[name]; exists {
+			t.Errorf("property %s should not be in the schema", name)
+		}
+	}
+
+	tests := []struct {
+		property string
+		expected map[string]interface{}
+	}{
+		{"id", map[string]interface{}{"type": "string"}},
+		{"name", map[string]interface{}{"type": "string", "description": "Display name"}},
+		{"status", map[string]interface{}{"type": "string", "enum": []interface{}{"active", "inactive"}}},
+		{"priority", map[string]interface{}{"type": "integer", "enum": []interface{}{float64(1), float64(2), float64(3)}}},
+		{"count", map[string]interface{}{"type": "integer", "minimum": float64(0)}},
+		{"ratio", map[string]interface{}{"type": "number"}},
+		{"tags", map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b"}}}},
+		{"labels", map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}}},
+		{"address", map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"street": map[string]interface{}{"type": "string", "description": "Street and number"},
+				"zip":    map[string]interface{}{"type": "string"},
+			},
+			"required": []interface{}{"street"},
+		}},
+		{"due", map[string]interface{}{"type": "string", "format": "date-time"}},
+		{"data", map[string]interface{}{"type": "string", "contentEncoding": "base64"}},
+		{"any", map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			if !reflect.DeepEqual(properties[tt.property], tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, properties[tt.property])
+			}
+		})
+	}
+}
+
+func TestGenerateSchema_RecursiveType(t *testing.T) {
+	schema := schemaJSON(t, GenerateSchema(reflect.TypeOf(schemaNode{})))
+
+	if schema["$ref"] != "#/$defs/schemaNode" {
+		t.Errorf("expected root $ref to schemaNode, got %v", schema["$ref"])
+	}
+
+	defs, ok := schema["$defs"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected $defs, got %v", schema["$defs"])
+	}
+	node := defs["schemaNode"].(map[string]interface{})
+	children := node["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	items := children["items"].(map[string]interface{})
+	if items["$ref"] != "#/$defs/schemaNode" {
+		t.Errorf("expected children items to reference schemaNode, got %v", items)
+	}
+}
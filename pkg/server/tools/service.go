@@ -2,11 +2,16 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"reflect"
 	"strings"
 	"sync"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
 )
 
 // ToolServiceOpts defines options for configuring the tool service
@@ -17,6 +22,8 @@ type ToolService struct {
 	// Separate internal storage for each registration mode
 	structMethods map[string]structMethodInfo // Key: "ServiceName.MethodName"
 	llmMethods    map[string]llmMethodInfo    // Key: "ServiceName.MethodName"
+	policies      map[string]Policy           // Key: "ServiceName.MethodName" or "ServiceName"
+	logger        *server.Logger
 	mutex         sync.RWMutex
 }
 
@@ -24,6 +31,7 @@ type ToolService struct {
 type structMethodInfo struct {
 	ServiceName, MethodName, Description string
 	Parameters                           map[string]interface{} `json:"omitempty"`
+	ReturnsSchema                        map[string]interface{} // Set for methods described from Go types
 }
 
 // llmMethodInfo contains data for LLM-friendly method registration
@@ -45,6 +53,8 @@ type ToolInfo struct {
 	Description string                 `json:"description"`
 	Parameters  map[string]interface{} `json:"parameters,omitempty"`
 	Returns     string                 `json:"returns"`
+	// ReturnsSchema is a JSON Schema of the method's response, when known
+	ReturnsSchema map[string]interface{} `json:"returnsSchema,omitempty"`
 }
 
 // NewToolService creates a new tool service
@@ -52,6 +62,7 @@ func NewToolService(opts ...ToolServiceOpts) *ToolService {
 	t := &ToolService{
 		structMethods: make(map[string]structMethodInfo),
 		llmMethods:    make(map[string]llmMethodInfo),
+		policies:      make(map[string]Policy),
 	}
 
 	for _, opt := range opts {
@@ -61,18 +72,84 @@ func NewToolService(opts ...ToolServiceOpts) *ToolService {
 	return t
 }
 
-// RegisterMethod registers a method as a tool using struct-based parameters
+// RegisterMethod registers a method as a tool using struct-based parameters.
+// Parameter types may be JSON Schema types or Go type names such as "int", "[]string" or
+// "*Config"; a type that cannot be either, such as "unsigned int", is an error.
 func (t *ToolService) RegisterMethod(serviceName, methodName, description string, parameters map[string]interface{}) error {
+	methodKey := serviceName + "." + methodName
+	if err := CheckParameters(parameters); err != nil {
+		return fmt.Errorf("invalid parameters for %s: %w", methodKey, err)
+	}
+
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
-	methodKey := serviceName + "." + methodName
 	t.structMethods[methodKey] = structMethodInfo{
 		ServiceName: serviceName,
 		MethodName:  methodName,
 		Description: description,
 		Parameters:  parameters,
 	}
+	t.logger.Event(context.Background(), "tool registered", slog.String("tool", methodKey), slog.String("registration", "struct"))
+
+	return nil
+}
+
+// CheckParameters returns the error RegisterMethod would report for parameters, so that
+// several methods can be checked before any of them is registered
+func CheckParameters(parameters map[string]interface{}) error {
+	if parameters == nil {
+		return nil
+	}
+	return checkSchemaTypes(ParametersSchema(parameters), "")
+}
+
+// DescribeService registers every method of service that has a valid service signature
+// (see JSONRPCMethodExecutor.RegisterService), deriving its parameters and return value
+// from the Go request and response types with GenerateSchema. Descriptions are taken
+// from WithMethodDescription options; other options are ignored.
+func (t *ToolService) DescribeService(service any, opts ...ServiceOpts) error {
+	if service == nil {
+		return fmt.Errorf("cannot describe nil service")
+	}
+
+	config := newServiceConfig(opts...)
+
+	serviceValue := reflect.ValueOf(service)
+	serviceType := serviceValue.Type()
+	serviceName := reflect.Indirect(serviceValue).Type().Name()
+	if serviceName == "" {
+		return fmt.Errorf("service must be a named type")
+	}
+
+	methods := make(map[string]structMethodInfo)
+	for i := 0; i < serviceType.NumMethod(); i++ {
+		methodName := serviceType.Method(i).Name
+		requestType, responseType, ok := serviceMethodTypes(serviceValue.Method(i).Type())
+		if !ok {
+			continue
+		}
+
+		methods[serviceName+"."+methodName] = structMethodInfo{
+			ServiceName:   serviceName,
+			MethodName:    methodName,
+			Description:   config.descriptions[methodName],
+			Parameters:    GenerateSchema(requestType),
+			ReturnsSchema: GenerateSchema(responseType),
+		}
+	}
+
+	if len(methods) == 0 {
+		return fmt.Errorf("service '%s' has no methods of suitable type", serviceName)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for key, method := range methods {
+		t.structMethods[key] = method
+		t.logger.Event(context.Background(), "tool registered", slog.String("tool", key), slog.String("registration", "described"))
+	}
 
 	return nil
 }
@@ -109,6 +186,7 @@ func (t *ToolService) RegisterMethodLLM(methodName, description string, returns
 			Returns:     returnValue,
 		},
 	}
+	t.logger.Event(context.Background(), "tool registered", slog.String("tool", methodName), slog.String("registration", "llm"))
 
 	return nil
 }
@@ -123,10 +201,11 @@ func (t *ToolService) GetMethodRegistry() map[string]ToolInfo {
 	// Add struct methods
 	for key, method := range t.structMethods {
 		tools[key] = ToolInfo{
-			Name:        key,
-			Description: method.Description,
-			Parameters:  method.Parameters,
-			Returns:     "",
+			Name:          key,
+			Description:   method.Description,
+			Parameters:    method.Parameters,
+			Returns:       "",
+			ReturnsSchema: method.ReturnsSchema,
 		}
 	}
 
@@ -143,7 +222,21 @@ func (t *ToolService) GetMethodRegistry() map[string]ToolInfo {
 	return tools
 }
 
-// ToolDiscoveryHandler returns an HTTP handler for tool discovery
+// ParameterSchema returns the parameters registered for a method with RegisterMethod
+// or DescribeService. Methods registered with RegisterMethodLLM have no schema.
+func (t *ToolService) ParameterSchema(serviceName, methodName string) (map[string]interface{}, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	method, ok := t.structMethods[serviceName+"."+methodName]
+	if !ok || method.Parameters == nil {
+		return nil, false
+	}
+	return method.Parameters, true
+}
+
+// ToolDiscoveryHandler returns an HTTP handler for tool discovery.
+// Only the tools that the request's principal may call are listed.
 func (t *ToolService) ToolDiscoveryHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -154,8 +247,10 @@ func (t *ToolService) ToolDiscoveryHandler() http.Handler {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 
-		// Get unified view of all methods
-		tools := t.GetMethodRegistry()
+		// Get unified view of the methods the caller may use
+		principal, _ := server.PrincipalFromContext(r.Context())
+		tools := t.AuthorizedTools(principal)
+		t.logger.Event(r.Context(), "tool discovery", slog.Int("tools", len(tools)))
 
 		response := map[string]interface{}{
 			"tools":       tools,
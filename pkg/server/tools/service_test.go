@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -215,6 +216,55 @@ func TestToolService_RegisterMethod(t *testing.T) {
 			expectedKey:   ".",
 			expectedError: false,
 		},
+		{
+			name:          "register_method_with_go_type_names",
+			serviceName:   "CounterService",
+			methodName:    "Add",
+			description:   "Adds to a counter",
+			parameters:    map[string]interface{}{"count": map[string]interface{}{"type": "int"}, "tags": "[]string"},
+			expectedKey:   "CounterService.Add",
+			expectedError: false,
+		},
+		{
+			name:          "reject_unknown_type",
+			serviceName:   "CounterService",
+			methodName:    "Add",
+			description:   "Adds to a counter",
+			parameters:    map[string]interface{}{"count": map[string]interface{}{"type": "unsigned int"}},
+			expectedKey:   "CounterService.Add",
+			expectedError: true,
+		},
+		{
+			name:        "reject_unknown_nested_type",
+			serviceName: "UserService",
+			methodName:  "Save",
+			description: "Saves users",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"users": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "User Data"}},
+				},
+			},
+			expectedKey:   "UserService.Save",
+			expectedError: true,
+		},
+		{
+			name:        "register_method_with_named_types",
+			serviceName: "UserService",
+			methodName:  "Save",
+			description: "Saves users",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"users":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "UserData"}},
+					"config":  map[string]interface{}{"type": "*Config"},
+					"note":    map[string]interface{}{"type": "models.Note"},
+					"profile": map[string]interface{}{"type": "map[string]Profile"},
+				},
+			},
+			expectedKey:   "UserService.Save",
+			expectedError: false,
+		},
 		{
 			name:          "register_method_with_nil_parameters",
 			serviceName:   "TestService",
@@ -239,9 +289,15 @@ func TestToolService_RegisterMethod(t *testing.T) {
 				t.Errorf("unexpected error: %v", err)
 			}
 
-			// Verify the method was registered
+			// Verify the method was registered, unless it was rejected
 			registry := ts.GetMethodRegistry()
 			methodInfo, exists := registry[tt.expectedKey]
+			if tt.expectedError {
+				if exists {
+					t.Errorf("method %s was registered despite the error", tt.expectedKey)
+				}
+				return
+			}
 
 			if !exists {
 				t.Errorf("method %s was not registered", tt.expectedKey)
@@ -695,3 +751,116 @@ func TestToolService_ToolDiscoveryHandler(t *testing.T) {
 		})
 	}
 }
+
+// DescribedService exercises schema generation from service methods
+type DescribedService struct{}
+
+type GreetRequest struct {
+	Name     string `json:"name" desc:"The name to greet"`
+	Language string `json:"language,omitempty" enum:"en,de"`
+}
+
+type GreetResponse struct {
+	Message string `json:"message"`
+}
+
+func (s *DescribedService) Greet(req GreetRequest, resp *GreetResponse) error {
+	return nil
+}
+
+func (s *DescribedService) GreetContext(ctx context.Context, req GreetRequest, resp *GreetResponse) error {
+	return nil
+}
+
+// Helper does not have a service signature and must be skipped
+func (s *DescribedService) Helper() string {
+	return ""
+}
+
+type NoMethodsService struct{}
+
+func TestToolService_DescribeService(t *testing.T) {
+	tests := []struct {
+		name          string
+		service       any
+		opts          []ServiceOpts
+		expectedTools []string
+		expectedError bool
+	}{
+		{
+			name:          "describe_service_methods",
+			service:       &DescribedService{},
+			opts:          []ServiceOpts{WithMethodDescription("Greet", "Greets someone")},
+			expectedTools: []string{"DescribedService.Greet", "DescribedService.GreetContext"},
+		},
+		{
+			name:          "service_without_suitable_methods",
+			service:       &NoMethodsService{},
+			expectedError: true,
+		},
+		{
+			name:          "nil_service",
+			service:       nil,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := NewToolService()
+			err := ts.DescribeService(tt.service, tt.opts...)
+
+			if tt.expectedError && err == nil {
+				t.Error("expected error but got none")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectedError {
+				return
+			}
+
+			tools := ts.GetMethodRegistry()
+			if len(tools) != len(tt.expectedTools) {
+				t.Errorf("expected %d tools, got %d", len(tt.expectedTools), len(tools))
+			}
+			for _, name := range tt.expectedTools {
+				if _, exists := tools[name]; !exists {
+					t.Errorf("tool %s was not registered", name)
+				}
+			}
+
+			greet := tools["DescribedService.Greet"]
+			if greet.Description != "Greets someone" {
+				t.Errorf("expected description 'Greets someone', got %q", greet.Description)
+			}
+			if greet.Parameters["$schema"] != SchemaDialect {
+				t.Errorf("expected parameters to be a JSON Schema, got %v", greet.Parameters)
+			}
+			if !reflect.DeepEqual(greet.Parameters["required"], []string{"name"}) {
+				t.Errorf("expected required [name], got %v", greet.Parameters["required"])
+			}
+			responseProps, ok := greet.ReturnsSchema["properties"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected returns schema properties, got %v", greet.ReturnsSchema)
+			}
+			if _, exists := responseProps["message"]; !exists {
+				t.Error("returns schema missing message property")
+			}
+
+			// The description is published through the discovery endpoint
+			req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+			w := httptest.NewRecorder()
+			ts.ToolDiscoveryHandler().ServeHTTP(w, req)
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			tool := response["tools"].(map[string]interface{})["DescribedService.Greet"].(map[string]interface{})
+			if _, exists := tool["returnsSchema"]; !exists {
+				t.Error("discovery response missing returnsSchema")
+			}
+		})
+	}
+}
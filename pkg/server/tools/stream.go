@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+	"github.com/pangobit/agent-sdk/pkg/server/tracing"
+)
+
+// streamKeepAlive is how often a comment is sent on an idle stream so that
+// proxies do not close it
+const streamKeepAlive = 15 * time.Second
+
+// StreamHandler returns a handler that executes a single JSON-RPC request and answers with
+// Server-Sent Events instead of a JSON body. Methods that take a server.Stream push
+// "partial" and "progress" events while they run; the JSON-RPC response follows as a
+// "result" event, after which the stream ends:
+//
+//	event: progress
+//	data: {"progress":1,"total":3,"message":"compiling"}
+//
+//	event: result
+//	data: {"jsonrpc":"2.0","result":{...},"id":1}
+//
+// The write deadline of the server does not apply to streams.
+func (h *MethodExecutionHandler) StreamHandler() http.Handler {
+	return http.HandlerFunc(h.serveStream)
+}
+
+// wantsStream reports whether the client negotiated an event stream with its Accept header
+func wantsStream(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		if strings.TrimSpace(mediaType) == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveStream handles streaming execution requests
+func (h *MethodExecutionHandler) serveStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.inFlight.add()
+	defer h.inFlight.done()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	stream := newSSEStream(w)
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		stream.finish(h.errorResponse(nil, -32600, "Invalid Request", "batch requests cannot be streamed"))
+		return
+	}
+
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	applyIdempotencyHeader(r, request)
+
+	// Streams may outlive the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(server.ContextWithStream(tracing.Extract(r.Context(), r.Header), stream))
+	defer cancel()
+	go stream.keepAlive(ctx)
+
+	caller := server.Caller{Transport: server.TransportHTTP, RemoteAddr: r.RemoteAddr}
+	caller.Principal, _ = server.PrincipalFromContext(r.Context())
+
+	stream.finish(h.handleRequest(ctx, caller, request))
+}
+
+// sseStream writes a call's events to an HTTP response
+type sseStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+
+	mutex   sync.Mutex
+	started bool
+	closed  bool
+	nextID  int
+}
+
+// newSSEStream creates a stream writing to w
+func newSSEStream(w http.ResponseWriter) *sseStream {
+	return &sseStream{w: w, controller: http.NewResponseController(w)}
+}
+
+// Send implements server.Stream
+func (s *sseStream) Send(partial any) error {
+	return s.event("partial", partial)
+}
+
+// Progress implements server.Stream
+func (s *sseStream) Progress(progress, total float64, message string) error {
+	event := map[string]interface{}{"progress": progress}
+	if total > 0 {
+		event["total"] = total
+	}
+	if message != "" {
+		event["message"] = message
+	}
+	return s.event("progress", event)
+}
+
+// finish writes the final response and closes the stream. A rate limited call that has not
+// streamed anything yet also gets a Retry-After header.
+func (s *sseStream) finish(response map[string]interface{}) {
+	encoded, err := json.Marshal(response)
+
+	// The result is written and the stream closed at once, so that no event can follow it
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if err != nil {
+		return
+	}
+	if wait := retryAfter(response); wait > 0 && !s.started {
+		s.w.Header().Set("Retry-After", strconv.Itoa(wait))
+	}
+	s.write("result", encoded)
+}
+
+// keepAlive writes a comment every streamKeepAlive until ctx is done
+func (s *sseStream) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mutex.Lock()
+			if !s.closed {
+				s.start()
+				io.WriteString(s.w, ": keep-alive\n\n")
+				s.controller.Flush()
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// event writes one event and flushes it to the client
+func (s *sseStream) event(name string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", name, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		// Events sent after the final response, e.g. by goroutines of the method, are dropped
+		return nil
+	}
+	return s.write(name, encoded)
+}
+
+// write writes an encoded event and flushes it; the caller must hold the mutex
+func (s *sseStream) write(name string, encoded []byte) error {
+	s.start()
+	s.nextID++
+	if _, err := fmt.Fprintf(s.w, "id: %d\nevent: %s\ndata: %s\n\n", s.nextID, name, encoded); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
+
+// start writes the response headers before the first event; the caller must hold the mutex
+func (s *sseStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	header := s.w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
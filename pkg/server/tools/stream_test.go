@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// newStreamHandler returns a handler executing StreamService
+func newStreamHandler(t *testing.T) *MethodExecutionHandler {
+	t.Helper()
+	executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+	if err := executor.RegisterService(&StreamService{}); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	return NewMethodExecutionHandler(executor)
+}
+
+func TestMethodExecutionHandler_StreamHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "partials_and_progress_precede_result",
+			body:           `{"jsonrpc":"2.0","method":"StreamService.Count","params":{"to":2},"id":1}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: "id: 1\nevent: progress\ndata: {\"progress\":1,\"total\":2}\n\n" +
+				"id: 2\nevent: partial\ndata: {\"n\":1}\n\n" +
+				"id: 3\nevent: progress\ndata: {\"progress\":2,\"total\":2}\n\n" +
+				"id: 4\nevent: partial\ndata: {\"n\":2}\n\n" +
+				"id: 5\nevent: result\ndata: {\"id\":1,\"jsonrpc\":\"2.0\",\"result\":{\"total\":3}}\n\n",
+		},
+		{
+			name:           "errors_are_sent_as_result",
+			body:           `{"jsonrpc":"2.0","method":"StreamService.Missing","params":{},"id":1}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "id: 1\nevent: result\ndata: {\"error\":{\"code\":-32601,\"data\":\"method 'Missing' not found in service 'StreamService'\",\"message\":\"Method not found\"},\"id\":1,\"jsonrpc\":\"2.0\"}\n\n",
+		},
+		{
+			name:           "batches_are_rejected",
+			body:           `[{"jsonrpc":"2.0","method":"StreamService.Count","params":{"to":2},"id":1}]`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "id: 1\nevent: result\ndata: {\"error\":{\"code\":-32600,\"data\":\"batch requests cannot be streamed\",\"message\":\"Invalid Request\"},\"id\":null,\"jsonrpc\":\"2.0\"}\n\n",
+		},
+		{
+			name:           "invalid_json",
+			body:           `{"jsonrpc":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid JSON\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newStreamHandler(t)
+			req := httptest.NewRequest(http.MethodPost, "/execute/stream", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.StreamHandler().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && w.Header().Get("Content-Type") != "text/event-stream" {
+				t.Errorf("expected event stream, got content type %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestMethodExecutionHandler_ServeHTTPAcceptEventStream(t *testing.T) {
+	tests := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+	}{
+		{
+			name:                "event_stream_is_negotiated",
+			accept:              "application/json;q=0.5, text/event-stream",
+			expectedContentType: "text/event-stream",
+		},
+		{
+			name:                "json_by_default",
+			expectedContentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newStreamHandler(t)
+			body := []byte(`{"jsonrpc":"2.0","method":"StreamService.Count","params":{"to":1},"id":1}`)
+			req := httptest.NewRequest(http.MethodPost, "/execute", bytes.NewReader(body))
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Type"); got != tt.expectedContentType {
+				t.Errorf("expected content type %q, got %q", tt.expectedContentType, got)
+			}
+		})
+	}
+}
+
+// slowStreamService streams for longer than the server's write timeout
+type slowStreamService struct{}
+
+func (s *slowStreamService) Wait(req CountRequest, stream server.Stream, resp *CountResponse) error {
+	for i := 0; i < req.To; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if err := stream.Send(i); err != nil {
+			return err
+		}
+	}
+	resp.Total = req.To
+	return nil
+}
+
+func TestMethodExecutionHandler_StreamHandlerWriteTimeout(t *testing.T) {
+	executor := NewJSONRPCMethodExecutor(NewMockServiceRegistry())
+	if err := executor.RegisterService(&slowStreamService{}); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	handler := NewMethodExecutionHandler(executor)
+
+	srv := httptest.NewUnstartedServer(handler.StreamHandler())
+	srv.Config.WriteTimeout = 30 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	body := `{"jsonrpc":"2.0","method":"slowStreamService.Wait","params":{"to":5},"id":1}`
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	events, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("stream was cut off: %v", err)
+	}
+	if !strings.Contains(string(events), "event: result\ndata: {\"id\":1,\"jsonrpc\":\"2.0\",\"result\":{\"total\":5}}") {
+		t.Errorf("expected final result, got %q", events)
+	}
+}
+
+func TestSSEStream_EventsAfterFinishAreDropped(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := newSSEStream(w)
+
+	stream.finish(map[string]interface{}{"jsonrpc": "2.0", "result": "done", "id": 1})
+	if err := stream.Send("late"); err != nil {
+		t.Errorf("expected late partial to be dropped, got %v", err)
+	}
+	if err := stream.Progress(1, 1, "late"); err != nil {
+		t.Errorf("expected late progress to be dropped, got %v", err)
+	}
+	stream.finish(map[string]interface{}{"jsonrpc": "2.0", "result": "again", "id": 1})
+
+	expected := "id: 1\nevent: result\ndata: {\"id\":1,\"jsonrpc\":\"2.0\",\"result\":\"done\"}\n\n"
+	if w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+}
@@ -0,0 +1,580 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SchemaProvider looks up the parameter schema registered for a method
+type SchemaProvider interface {
+	ParameterSchema(serviceName, methodName string) (map[string]interface{}, bool)
+}
+
+// Violation describes one way in which params fail to satisfy a schema.
+// Path uses JSON names with indexes for array elements, e.g. "items[2].sku";
+// an empty path refers to the params object itself.
+type Violation struct {
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when params do not satisfy a method's schema
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		if v.Path == "" {
+			messages[i] = v.Message
+		} else {
+			messages[i] = v.Path + ": " + v.Message
+		}
+	}
+	return "invalid params: " + strings.Join(messages, "; ")
+}
+
+// ValidateParams checks params against a JSON Schema, or against a property map of the
+// form accepted by ToolService.RegisterMethod. A nil error means the params are valid.
+// Supported keywords are type, enum, const, required, properties, additionalProperties,
+// items, minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength,
+// pattern, format (date-time), minItems, maxItems, uniqueItems, allOf, anyOf, oneOf
+// and $ref into "$defs". Unknown keywords are ignored.
+func ValidateParams(schema map[string]interface{}, params map[string]interface{}) error {
+	root := ParametersSchema(schema)
+	v := &validator{root: root}
+	v.validate(root, params, "")
+
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+// ParametersSchema converts a RegisterMethod property map into an object schema.
+// Maps that already are object schemas are returned unchanged.
+func ParametersSchema(params map[string]interface{}) map[string]interface{} {
+	if isObjectSchema(params) {
+		return params
+	}
+
+	properties := make(map[string]interface{}, len(params))
+	required := []string{}
+	for name, param := range params {
+		switch p := param.(type) {
+		case map[string]interface{}:
+			property := make(map[string]interface{}, len(p))
+			for key, value := range p {
+				// A boolean "required" marks the parameter itself as required
+				if isRequired, ok := value.(bool); ok && key == "required" {
+					if isRequired {
+						required = append(required, name)
+					}
+					continue
+				}
+				property[key] = value
+			}
+			properties[name] = property
+		case string:
+			// Shorthand: {"name": "string"}
+			properties[name] = map[string]interface{}{"type": p}
+		default:
+			properties[name] = map[string]interface{}{}
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// isObjectSchema reports whether params is a JSON Schema rather than a property map.
+// Property map entries are maps themselves, so a string "type" of "object" marks a schema.
+func isObjectSchema(params map[string]interface{}) bool {
+	if _, ok := params["$schema"]; ok {
+		return true
+	}
+	return params["type"] == "object"
+}
+
+// validator collects violations while walking a schema
+type validator struct {
+	root       map[string]interface{}
+	violations []Violation
+}
+
+func (v *validator) fail(path, rule, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{
+		Path:    path,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks value against schema, recording violations under path
+func (v *validator) validate(schema map[string]interface{}, value interface{}, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, found := v.resolve(ref)
+		if !found {
+			v.fail(path, "$ref", "unresolvable schema reference %q", ref)
+			return
+		}
+		v.validate(resolved, value, path)
+	}
+
+	if types, ok := schemaTypes(schema["type"]); ok && !matchesAnyType(value, types, hasFields(schema)) {
+		v.fail(path, "type", "expected %s, got %s", strings.Join(jsonTypeNames(types, hasFields(schema)), " or "), jsonTypeOf(value))
+		// Further keywords assume the right type
+		return
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if !containsValue(toSlice(enum), value) {
+			v.fail(path, "enum", "must be one of %v", enum)
+		}
+	}
+	if constValue, ok := schema["const"]; ok && !jsonEqual(constValue, value) {
+		v.fail(path, "const", "must be %v", constValue)
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, typed, path)
+	case []interface{}:
+		v.validateArray(schema, typed, path)
+	case string:
+		v.validateString(schema, typed, path)
+	default:
+		if n, ok := toNumber(value); ok {
+			v.validateNumber(schema, n, path)
+		}
+	}
+
+	v.validateCombinators(schema, value, path)
+}
+
+func (v *validator) validateObject(schema map[string]interface{}, object map[string]interface{}, path string) {
+	for _, name := range toStrings(schema["required"]) {
+		if _, ok := object[name]; !ok {
+			v.fail(joinPath(path, name), "required", "is required")
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			v.validate(property, object[name], joinPath(path, name))
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(joinPath(path, name), "additionalProperties", "is not allowed")
+			}
+		case map[string]interface{}:
+			v.validate(additional, object[name], joinPath(path, name))
+		}
+	}
+}
+
+func (v *validator) validateArray(schema map[string]interface{}, array []interface{}, path string) {
+	if min, ok := toNumber(schema["minItems"]); ok && float64(len(array)) < min {
+		v.fail(path, "minItems", "must have at least %v items", min)
+	}
+	if max, ok := toNumber(schema["maxItems"]); ok && float64(len(array)) > max {
+		v.fail(path, "maxItems", "must have at most %v items", max)
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if jsonEqual(array[i], array[j]) {
+					v.fail(indexPath(path, j), "uniqueItems", "duplicates item %d", i)
+				}
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range array {
+			v.validate(items, item, indexPath(path, i))
+		}
+	}
+}
+
+func (v *validator) validateString(schema map[string]interface{}, s string, path string) {
+	length := float64(len([]rune(s)))
+	if min, ok := toNumber(schema["minLength"]); ok && length < min {
+		v.fail(path, "minLength", "must be at least %v characters", min)
+	}
+	if max, ok := toNumber(schema["maxLength"]); ok && length > max {
+		v.fail(path, "maxLength", "must be at most %v characters", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil && !re.MatchString(s) {
+			v.fail(path, "pattern", "must match pattern %q", pattern)
+		}
+	}
+	if schema["format"] == "date-time" {
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.fail(path, "format", "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func (v *validator) validateNumber(schema map[string]interface{}, n float64, path string) {
+	if min, ok := toNumber(schema["minimum"]); ok && n < min {
+		v.fail(path, "minimum", "must be >= %v", min)
+	}
+	if max, ok := toNumber(schema["maximum"]); ok && n > max {
+		v.fail(path, "maximum", "must be <= %v", max)
+	}
+	if min, ok := toNumber(schema["exclusiveMinimum"]); ok && n <= min {
+		v.fail(path, "exclusiveMinimum", "must be > %v", min)
+	}
+	if max, ok := toNumber(schema["exclusiveMaximum"]); ok && n >= max {
+		v.fail(path, "exclusiveMaximum", "must be < %v", max)
+	}
+}
+
+func (v *validator) validateCombinators(schema map[string]interface{}, value interface{}, path string) {
+	for _, sub := range toSchemas(schema["allOf"]) {
+		v.validate(sub, value, path)
+	}
+
+	if anyOf := toSchemas(schema["anyOf"]); len(anyOf) > 0 && v.countMatches(anyOf, value) == 0 {
+		v.fail(path, "anyOf", "must match at least one of the allowed schemas")
+	}
+	if oneOf := toSchemas(schema["oneOf"]); len(oneOf) > 0 && v.countMatches(oneOf, value) != 1 {
+		v.fail(path, "oneOf", "must match exactly one of the allowed schemas")
+	}
+}
+
+// countMatches returns how many of schemas value satisfies
+func (v *validator) countMatches(schemas []map[string]interface{}, value interface{}) int {
+	matches := 0
+	for _, sub := range schemas {
+		probe := &validator{root: v.root}
+		probe.validate(sub, value, "")
+		if len(probe.violations) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// resolve looks up a local reference of the form "#/$defs/Name"
+func (v *validator) resolve(ref string) (map[string]interface{}, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, false
+	}
+	defs, _ := v.root["$defs"].(map[string]interface{})
+	schema, ok := defs[name].(map[string]interface{})
+	return schema, ok
+}
+
+// schemaTypes normalizes the "type" keyword, which may be a string or a list
+func schemaTypes(t interface{}) ([]string, bool) {
+	switch typed := t.(type) {
+	case string:
+		return []string{typed}, true
+	default:
+		types := toStrings(t)
+		return types, len(types) > 0
+	}
+}
+
+// matchesAnyType reports whether value has one of the JSON types.
+// Go type names, as written by apigen, are matched by their JSON type; fields reports
+// whether the schema lists the fields of the type.
+func matchesAnyType(value interface{}, types []string, fields bool) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range types {
+		name, known := jsonTypeName(t, fields)
+		if known && name == "" {
+			// any, interface{} and named types without fields accept every value
+			return true
+		}
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFields reports whether schema describes the fields of an object, either as JSON Schema
+// properties or as the fields apigen writes for structs
+func hasFields(schema map[string]interface{}) bool {
+	if _, ok := schema["properties"].(map[string]interface{}); ok {
+		return true
+	}
+	_, ok := schema["fields"].(map[string]interface{})
+	return ok
+}
+
+// jsonSchemaTypes are the type names defined by JSON Schema
+var jsonSchemaTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true,
+	"object": true, "array": true, "null": true,
+}
+
+// goTypeNames maps Go type names to JSON Schema types; "" means any value
+var goTypeNames = map[string]string{
+	"bool":   "boolean",
+	"string": "string",
+	"int":    "integer", "int8": "integer", "int16": "integer", "int32": "integer", "int64": "integer",
+	"uint": "integer", "uint8": "integer", "uint16": "integer", "uint32": "integer", "uint64": "integer",
+	"byte": "integer", "rune": "integer",
+	"float32": "number", "float64": "number",
+	"[]byte":          "string", // base64, as with encoding/json
+	"time.Time":       "string",
+	"time.Duration":   "integer",
+	"json.Number":     "number",
+	"json.RawMessage": "",
+	"any":             "",
+	"interface{}":     "",
+}
+
+// jsonTypeName returns the JSON Schema type for a type name, which may be a JSON Schema
+// type or a Go type name such as "int", "*string", "[]string", "map[string]int" or "User".
+// Named types such as "User" or "models.Note" are objects when fields is true; otherwise
+// they may stand for any kind of type and accept any value.
+// The name is "" for types that accept any value. known is false for names that are
+// neither JSON Schema types nor Go types.
+func jsonTypeName(t string, fields bool) (name string, known bool) {
+	if jsonSchemaTypes[t] {
+		return t, true
+	}
+	if name, ok := goTypeNames[t]; ok {
+		return name, true
+	}
+	switch {
+	case strings.HasPrefix(t, "*"):
+		return jsonTypeName(t[1:], fields)
+	case strings.HasPrefix(t, "["):
+		return "array", true
+	case strings.HasPrefix(t, "map["):
+		return "object", true
+	case strings.HasPrefix(t, "struct{"):
+		return "object", true
+	case strings.HasPrefix(t, "interface{"):
+		return "", true
+	case isNamedType(t):
+		if fields {
+			return "object", true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// isNamedType reports whether t is a Go identifier or a qualified identifier such as
+// "models.Note", optionally instantiated with type arguments as in "Page[User]"
+func isNamedType(t string) bool {
+	if open := strings.IndexByte(t, '['); open > 0 && strings.HasSuffix(t, "]") {
+		t = t[:open]
+	}
+	pkg, name, qualified := strings.Cut(t, ".")
+	if !qualified {
+		return isIdentifier(t)
+	}
+	return isIdentifier(pkg) && isIdentifier(name)
+}
+
+// isIdentifier reports whether s is a Go identifier
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonTypeNames translates type names for messages, keeping unknown names as they are
+func jsonTypeNames(types []string, fields bool) []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t
+		if name, known := jsonTypeName(t, fields); known && name != "" {
+			names[i] = name
+		}
+	}
+	return names
+}
+
+// checkSchemaTypes returns an error for the first "type" in schema or its subschemas that
+// cannot be a JSON Schema type or a Go type name, which usually is a typo
+func checkSchemaTypes(schema map[string]interface{}, path string) error {
+	if t, ok := schema["type"]; ok {
+		types, ok := schemaTypes(t)
+		if !ok {
+			return fmt.Errorf("%s: type must be a string or a list of strings", schemaPath(path))
+		}
+		for _, name := range types {
+			if _, known := jsonTypeName(name, false); !known {
+				return fmt.Errorf("%s: unknown type %q", schemaPath(path), name)
+			}
+		}
+	}
+
+	for _, keyword := range []string{"properties", "$defs"} {
+		subschemas, _ := schema[keyword].(map[string]interface{})
+		names := make([]string, 0, len(subschemas))
+		for name := range subschemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := subschemas[name].(map[string]interface{}); ok {
+				if err := checkSchemaTypes(sub, joinPath(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, keyword := range []string{"items", "additionalProperties"} {
+		if sub, ok := schema[keyword].(map[string]interface{}); ok {
+			if err := checkSchemaTypes(sub, path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		for _, sub := range toSchemas(schema[keyword]) {
+			if err := checkSchemaTypes(sub, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// schemaPath names a schema location in errors
+func schemaPath(path string) string {
+	if path == "" {
+		return "params"
+	}
+	return path
+}
+
+// jsonTypeOf returns the JSON Schema type name of a decoded JSON value
+func jsonTypeOf(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	if n, ok := toNumber(value); ok {
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "unknown"
+	}
+}
+
+// toNumber converts any Go numeric value to float64
+func toNumber(value interface{}) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		f, err := toFloat(reflect.ValueOf(value))
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toSlice converts any Go slice to []interface{}
+func toSlice(value interface{}) []interface{} {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+// toStrings converts a []string or []interface{} of strings to []string
+func toStrings(value interface{}) []string {
+	var out []string
+	for _, item := range toSlice(value) {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// toSchemas converts a list of schema objects to typed maps
+func toSchemas(value interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, item := range toSlice(value) {
+		if schema, ok := item.(map[string]interface{}); ok {
+			out = append(out, schema)
+		}
+	}
+	return out
+}
+
+// containsValue reports whether values contains value, comparing numbers by value
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if jsonEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares two values the way they would compare once encoded as JSON
+func jsonEqual(a, b interface{}) bool {
+	if na, ok := toNumber(a); ok {
+		nb, ok := toNumber(b)
+		return ok && na == nb
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type validateAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type validateOrder struct {
+	Customer string            `json:"customer"`
+	Quantity uint              `json:"quantity"`
+	Priority string            `json:"priority,omitempty" enum:"low,high"`
+	Tags     []string          `json:"tags,omitempty"`
+	Address  *validateAddress  `json:"address,omitempty"`
+	Notes    map[string]string `json:"notes,omitempty"`
+}
+
+func TestValidateParams(t *testing.T) {
+	orderSchema := GenerateSchema(reflect.TypeOf(validateOrder{}))
+
+	tests := []struct {
+		name       string
+		schema     map[string]interface{}
+		params     string
+		violations []Violation
+	}{
+		{
+			name:   "valid_generated_schema",
+			schema: orderSchema,
+			params: `{"customer":"acme","quantity":2,"priority":"high","tags":["a"],"address":{"street":"Main"}}`,
+		},
+		{
+			name:   "missing_required_fields",
+			schema: orderSchema,
+			params: `{}`,
+			violations: []Violation{
+				{Path: "customer", Rule: "required", Message: "is required"},
+				{Path: "quantity", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name:   "nested_violations",
+			schema: orderSchema,
+			params: `{"customer":"acme","quantity":-1,"priority":"urgent","tags":["a",3],"address":{"zip":"1"}}`,
+			violations: []Violation{
+				{Path: "address.street", Rule: "required", Message: "is required"},
+				{Path: "priority", Rule: "enum", Message: "must be one of [low high]"},
+				{Path: "quantity", Rule: "minimum", Message: "must be >= 0"},
+				{Path: "tags[1]", Rule: "type", Message: "expected string, got integer"},
+			},
+		},
+		{
+			name:   "integer_rejects_fraction",
+			schema: orderSchema,
+			params: `{"customer":"acme","quantity":1.5}`,
+			violations: []Violation{
+				{Path: "quantity", Rule: "type", Message: "expected integer, got number"},
+			},
+		},
+		{
+			name: "property_map_required",
+			schema: map[string]interface{}{
+				"name": map[string]interface{}{"type": "string", "required": true},
+				"age":  map[string]interface{}{"type": "number"},
+			},
+			params: `{"age":"old"}`,
+			violations: []Violation{
+				{Path: "name", Rule: "required", Message: "is required"},
+				{Path: "age", Rule: "type", Message: "expected number, got string"},
+			},
+		},
+		{
+			name: "property_map_shorthand",
+			schema: map[string]interface{}{
+				"name": "string",
+			},
+			params: `{"name":true}`,
+			violations: []Violation{
+				{Path: "name", Rule: "type", Message: "expected string, got boolean"},
+			},
+		},
+		{
+			name: "go_type_names",
+			schema: map[string]interface{}{
+				"count": map[string]interface{}{"type": "int", "required": true},
+				"tags":  map[string]interface{}{"type": "[]string"},
+				"ratio": "float64",
+				"owner": "*string",
+				"extra": "map[string]interface{}",
+				"data":  "any",
+			},
+			params: `{"count":3,"tags":["a"],"ratio":0.5,"owner":"me","extra":{"k":1},"data":[1]}`,
+		},
+		{
+			name: "go_type_names_mismatch",
+			schema: map[string]interface{}{
+				"count": map[string]interface{}{"type": "int"},
+				"tags":  map[string]interface{}{"type": "[]string"},
+			},
+			params: `{"count":"3","tags":"a"}`,
+			violations: []Violation{
+				{Path: "count", Rule: "type", Message: "expected integer, got string"},
+				{Path: "tags", Rule: "type", Message: "expected array, got string"},
+			},
+		},
+		{
+			name: "named_types",
+			schema: map[string]interface{}{
+				"user": map[string]interface{}{
+					"type":   "User",
+					"fields": map[string]interface{}{"Name": map[string]interface{}{"type": "string"}},
+				},
+				"config": "*Config",
+				"status": "models.Status",
+			},
+			params: `{"user":{"name":"ada"},"config":{"debug":true},"status":"active"}`,
+		},
+		{
+			name: "named_types_mismatch",
+			schema: map[string]interface{}{
+				"user": map[string]interface{}{
+					"type":   "User",
+					"fields": map[string]interface{}{"Name": map[string]interface{}{"type": "string"}},
+				},
+				"users": map[string]interface{}{"type": "[]User"},
+			},
+			params: `{"user":"ada","users":{"name":"ada"}}`,
+			violations: []Violation{
+				{Path: "user", Rule: "type", Message: "expected object, got string"},
+				{Path: "users", Rule: "type", Message: "expected array, got object"},
+			},
+		},
+		{
+			name: "string_and_array_rules",
+			schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"code":  map[string]interface{}{"type": "string", "pattern": "^[A-Z]+$", "maxLength": 3},
+					"ids":   map[string]interface{}{"type": "array", "minItems": 1, "uniqueItems": true},
+					"since": map[string]interface{}{"type": "string", "format": "date-time"},
+				},
+				"additionalProperties": false,
+			},
+			params: `{"code":"abcd","ids":[1,1],"since":"yesterday","extra":1}`,
+			violations: []Violation{
+				{Path: "code", Rule: "maxLength", Message: "must be at most 3 characters"},
+				{Path: "code", Rule: "pattern", Message: `must match pattern "^[A-Z]+$"`},
+				{Path: "extra", Rule: "additionalProperties", Message: "is not allowed"},
+				{Path: "ids[1]", Rule: "uniqueItems", Message: "duplicates item 0"},
+				{Path: "since", Rule: "format", Message: "must be an RFC 3339 date-time"},
+			},
+		},
+		{
+			name: "one_of",
+			schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"oneOf": []interface{}{
+							map[string]interface{}{"type": "string"},
+							map[string]interface{}{"type": "integer"},
+						},
+					},
+				},
+			},
+			params: `{"id":true}`,
+			violations: []Violation{
+				{Path: "id", Rule: "oneOf", Message: "must match exactly one of the allowed schemas"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.params), &params); err != nil {
+				t.Fatalf("invalid test params: %v", err)
+			}
+
+			err := ValidateParams(tt.schema, params)
+			if tt.violations == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			if !reflect.DeepEqual(validationErr.Violations, tt.violations) {
+				t.Errorf("expected violations %+v, got %+v", tt.violations, validationErr.Violations)
+			}
+		})
+	}
+}
+
+func TestValidateParams_RecursiveType(t *testing.T) {
+	schema := GenerateSchema(reflect.TypeOf(schemaNode{}))
+
+	var params map[string]interface{}
+	json.Unmarshal([]byte(`{"value":"a","children":[{"value":"b"},{"value":3}]}`), &params)
+
+	err := ValidateParams(schema, params)
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	want := []Violation{{Path: "children[1].value", Rule: "type", Message: "expected string, got integer"}}
+	if !reflect.DeepEqual(validationErr.Violations, want) {
+		t.Errorf("expected violations %+v, got %+v", want, validationErr.Violations)
+	}
+}
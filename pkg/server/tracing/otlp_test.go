@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOTLPExporter_ExportSpans(t *testing.T) {
+	var (
+		body   []byte
+		header http.Header
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer collector.Close()
+
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	exporter := NewOTLPExporter(collector.URL+"/v1/traces",
+		WithServiceName("notes"),
+		WithHeaders(map[string]string{"Authorization": "Bearer collector-token"}),
+	)
+	tracer := NewTracer(exporter)
+	tracer.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	_, span := tracer.Start(ContextWithSpanContext(context.Background(), parent), "NoteService.Add", SpanKindServer)
+	span.SetAttribute("rpc.method", "Add")
+	span.SetAttribute("rpc.jsonrpc.error_code", -32003)
+	span.SetAttribute("retry", false)
+	span.SetStatus(StatusError, "Forbidden")
+	span.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON content type, got %q", got)
+	}
+	if got := header.Get("Authorization"); got != "Bearer collector-token" {
+		t.Errorf("expected the configured headers, got %q", got)
+	}
+
+	var request struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpAttribute `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				Spans []map[string]any `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("collector received invalid JSON: %v\n%s", err, body)
+	}
+	if len(request.ResourceSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("expected one resource and scope, got %s", body)
+	}
+	resource := request.ResourceSpans[0]
+	if attrs := resource.Resource.Attributes; len(attrs) != 1 || attrs[0].Key != "service.name" || attrs[0].Value["stringValue"] != "notes" {
+		t.Errorf("unexpected resource attributes %v", attrs)
+	}
+	if resource.ScopeSpans[0].Scope.Name != instrumentationScope {
+		t.Errorf("unexpected scope %q", resource.ScopeSpans[0].Scope.Name)
+	}
+
+	spans := resource.ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	got := spans[0]
+	expected := map[string]any{
+		"traceId":           "4bf92f3577b34da6a3ce929d0e0e4736",
+		"spanId":            span.SpanContext.SpanID.String(),
+		"parentSpanId":      "00f067aa0ba902b7",
+		"name":              "NoteService.Add",
+		"kind":              float64(SpanKindServer),
+		"startTimeUnixNano": "1700000000000000000",
+		"endTimeUnixNano":   "1700000000000000000",
+		"status":            map[string]any{"code": float64(StatusError), "message": "Forbidden"},
+	}
+	for key, value := range expected {
+		if encoded, _ := json.Marshal(got[key]); string(encoded) != mustMarshal(t, value) {
+			t.Errorf("expected %s %s, got %s", key, mustMarshal(t, value), encoded)
+		}
+	}
+
+	expectedAttributes := `[{"key":"retry","value":{"boolValue":false}},` +
+		`{"key":"rpc.jsonrpc.error_code","value":{"intValue":"-32003"}},` +
+		`{"key":"rpc.method","value":{"stringValue":"Add"}}]`
+	if attrs := mustMarshal(t, got["attributes"]); attrs != expectedAttributes {
+		t.Errorf("expected attributes %s, got %s", expectedAttributes, attrs)
+	}
+}
+
+func TestOTLPExporter_ExportSpansError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "unavailable", status: http.StatusServiceUnavailable},
+		{name: "bad_request", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer collector.Close()
+
+			exporter := NewOTLPExporter(collector.URL)
+			if err := exporter.ExportSpans(context.Background(), []*Span{{Name: "work"}}); err == nil {
+				t.Errorf("expected error for status %d", tt.status)
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to encode %v: %v", v, err)
+	}
+	return string(encoded)
+}
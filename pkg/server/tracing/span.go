@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// SpanKind describes the role of a span in a trace, with the values used by OTLP
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode is the outcome of a span, with the values used by OTLP
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Span is a timed operation within a trace. Exporters receive spans once they have ended,
+// after which their fields no longer change.
+type Span struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	ParentSpanID  SpanID // Zero for the root span of a trace
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]any
+	StatusCode    StatusCode
+	StatusMessage string
+
+	tracer *Tracer
+	mutex  sync.Mutex
+	ended  bool
+}
+
+// SetAttribute records a key/value pair on the span. Values should be strings, bools,
+// integers or floats; other values are exported in their fmt representation.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.ended {
+		s.Attributes[key] = value
+	}
+}
+
+// SetStatus sets the outcome of the span; message is only kept for StatusError
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ended {
+		return
+	}
+	s.StatusCode = code
+	s.StatusMessage = ""
+	if code == StatusError {
+		s.StatusMessage = message
+	}
+}
+
+// End records the end time of the span and queues it for export if it is sampled.
+// Calls after the first are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = s.tracer.now()
+	s.mutex.Unlock()
+
+	if s.SpanContext.Sampled {
+		s.tracer.enqueue(s)
+	}
+}
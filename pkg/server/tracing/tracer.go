@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// maxQueuedSpans bounds the spans waiting for export; further spans are dropped
+const maxQueuedSpans = 2048
+
+// exportTimeout bounds a single export
+const exportTimeout = 10 * time.Second
+
+// errorMessage is the message of logged export failures
+const errorMessage = "spans not exported"
+
+// Exporter sends ended spans to a tracing backend
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*Span) error
+}
+
+// TracerOpts defines options for configuring a tracer
+type TracerOpts func(*Tracer)
+
+// Tracer starts spans and exports them in batches from a background goroutine.
+// Call Shutdown to export the remaining spans before the process exits.
+type Tracer struct {
+	exporter      Exporter
+	batchSize     int
+	flushInterval time.Duration
+	onError       func(error)
+	now           func() time.Time
+
+	mutex    sync.Mutex
+	queue    []*Span
+	flush    chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTracer creates a tracer exporting to exporter. By default spans are exported in batches
+// of up to 512, at least every 5 seconds, and export errors are logged.
+func NewTracer(exporter Exporter, opts ...TracerOpts) *Tracer {
+	t := &Tracer{
+		exporter:      exporter,
+		batchSize:     512,
+		flushInterval: 5 * time.Second,
+		onError:       server.NewLogger(slog.Default()).ErrorHandler(errorMessage),
+		now:           time.Now,
+		flush:         make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	go t.exportLoop()
+	return t
+}
+
+// WithBatchSize sets how many spans are exported at once
+func WithBatchSize(n int) TracerOpts {
+	return func(t *Tracer) {
+		if n < 1 {
+			n = 1
+		}
+		t.batchSize = n
+	}
+}
+
+// WithFlushInterval sets how long ended spans wait for a batch to fill up before export
+func WithFlushInterval(d time.Duration) TracerOpts {
+	return func(t *Tracer) {
+		if d > 0 {
+			t.flushInterval = d
+		}
+	}
+}
+
+// WithErrorHandler sets the function called when spans cannot be exported.
+// Calls are never failed because of tracing; by default the error is logged to the
+// default slog logger.
+func WithErrorHandler(fn func(error)) TracerOpts {
+	return func(t *Tracer) {
+		t.onError = fn
+	}
+}
+
+// WithLogger logs spans that cannot be exported to logger, at warn level
+func WithLogger(logger *slog.Logger) TracerOpts {
+	return func(t *Tracer) {
+		t.onError = server.NewLogger(logger).ErrorHandler(errorMessage)
+	}
+}
+
+// Start starts a span as a child of the span context of ctx, or as the root of a new trace,
+// and returns a copy of ctx carrying the span. The span inherits the sampling decision and
+// tracestate of its parent; new traces are sampled. End the span when the operation is done.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		StartTime:  t.now(),
+		Attributes: make(map[string]any),
+		tracer:     t,
+	}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		span.SpanContext = parent
+		span.ParentSpanID = parent.SpanID
+	} else {
+		span.SpanContext = SpanContext{TraceID: newTraceID(), Sampled: true}
+	}
+	span.SpanContext.SpanID = newSpanID()
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Interceptor returns an interceptor that records a server span around every call passing
+// through it. Spans are named "Service.Method" and carry the OpenTelemetry RPC attributes;
+// failed calls get an error status and their JSON-RPC error code. Install it with UseFirst
+// so that the span covers the other interceptors.
+func (t *Tracer) Interceptor() server.Interceptor {
+	return func(ctx context.Context, call *server.ToolCall, next server.Invoker) (any, error) {
+		ctx, span := t.Start(ctx, call.ServiceName+"."+call.MethodName, SpanKindServer)
+		defer span.End()
+
+		span.SetAttribute("rpc.system", "jsonrpc")
+		span.SetAttribute("rpc.service", call.ServiceName)
+		span.SetAttribute("rpc.method", call.MethodName)
+		span.SetAttribute("agentsdk.transport", call.Caller.Transport)
+		if call.Caller.Principal != nil {
+			span.SetAttribute("enduser.id", call.Caller.Principal.Subject)
+		}
+
+		result, err := next(ctx, call)
+		if err != nil {
+			span.SetAttribute("rpc.jsonrpc.error_code", int(server.ErrorCode(err)))
+			span.SetStatus(StatusError, err.Error())
+		}
+		return result, err
+	}
+}
+
+// Shutdown stops the background export after exporting the spans that have ended.
+// Spans ending later are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.stopOnce.Do(func() { close(t.done) })
+	select {
+	case <-t.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue queues an ended span for export, waking the export loop when a batch is full
+func (t *Tracer) enqueue(span *Span) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	select {
+	case <-t.done:
+		return
+	default:
+	}
+	if len(t.queue) >= maxQueuedSpans {
+		return
+	}
+	t.queue = append(t.queue, span)
+	if len(t.queue) >= t.batchSize {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// exportLoop exports queued spans whenever a batch is full or the flush interval passes
+func (t *Tracer) exportLoop() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.flush:
+		case <-t.done:
+			t.export()
+			return
+		}
+		t.export()
+	}
+}
+
+// export sends the queued spans in batches
+func (t *Tracer) export() {
+	t.mutex.Lock()
+	queue := t.queue
+	t.queue = nil
+	t.mutex.Unlock()
+
+	for len(queue) > 0 {
+		batch := queue[:min(len(queue), t.batchSize)]
+		queue = queue[len(batch):]
+
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := t.exporter.ExportSpans(ctx, batch); err != nil {
+			t.onError(err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pangobit/agent-sdk/pkg/jsonrpc"
+	"github.com/pangobit/agent-sdk/pkg/server"
+)
+
+// recordingExporter keeps the spans it is given
+type recordingExporter struct {
+	mutex   sync.Mutex
+	batches [][]*Span
+	err     error
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.batches = append(e.batches, spans)
+	return e.err
+}
+
+func (e *recordingExporter) spans() []*Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	var spans []*Span
+	for _, batch := range e.batches {
+		spans = append(spans, batch...)
+	}
+	return spans
+}
+
+func TestTracer_Interceptor(t *testing.T) {
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	parent.TraceState = "rojo=00f067aa0ba902b7"
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		err        error
+		attributes map[string]any
+		status     StatusCode
+	}{
+		{
+			name: "child_of_remote_parent",
+			ctx:  ContextWithSpanContext(context.Background(), parent),
+			attributes: map[string]any{
+				"rpc.system":         "jsonrpc",
+				"rpc.service":        "NoteService",
+				"rpc.method":         "Add",
+				"agentsdk.transport": "http",
+				"enduser.id":         "alice",
+				"note.length":        5,
+			},
+		},
+		{
+			name: "failed_call",
+			ctx:  ContextWithSpanContext(context.Background(), parent),
+			err:  jsonrpc.NewError(jsonrpc.ErrorCodeForbidden, "Forbidden", nil),
+			attributes: map[string]any{
+				"rpc.system":             "jsonrpc",
+				"rpc.service":            "NoteService",
+				"rpc.method":             "Add",
+				"agentsdk.transport":     "http",
+				"enduser.id":             "alice",
+				"note.length":            5,
+				"rpc.jsonrpc.error_code": -32003,
+			},
+			status: StatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := &recordingExporter{}
+			tracer := NewTracer(exporter)
+
+			call := &server.ToolCall{
+				ServiceName: "NoteService",
+				MethodName:  "Add",
+				Caller:      server.Caller{Transport: "http", Principal: &server.Principal{Subject: "alice"}},
+			}
+			var inner SpanContext
+			tracer.Interceptor()(tt.ctx, call, func(ctx context.Context, call *server.ToolCall) (any, error) {
+				SpanFromContext(ctx).SetAttribute("note.length", 5)
+				inner, _ = SpanContextFromContext(ctx)
+				return nil, tt.err
+			})
+			if err := tracer.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown failed: %v", err)
+			}
+
+			spans := exporter.spans()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name != "NoteService.Add" || span.Kind != SpanKindServer {
+				t.Errorf("unexpected name %q or kind %d", span.Name, span.Kind)
+			}
+			if span.SpanContext.TraceID != parent.TraceID || span.ParentSpanID != parent.SpanID {
+				t.Errorf("expected child of %s, got trace %s parent %s", parent.Traceparent(), span.SpanContext.TraceID, span.ParentSpanID)
+			}
+			if span.SpanContext.TraceState != parent.TraceState {
+				t.Errorf("expected tracestate %q, got %q", parent.TraceState, span.SpanContext.TraceState)
+			}
+			if inner != span.SpanContext {
+				t.Errorf("expected the call's context to carry the span, got %+v", inner)
+			}
+			if !reflect.DeepEqual(span.Attributes, tt.attributes) {
+				t.Errorf("expected attributes %v, got %v", tt.attributes, span.Attributes)
+			}
+			if span.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, span.StatusCode)
+			}
+			if span.EndTime.Before(span.StartTime) {
+				t.Errorf("span ends before it starts")
+			}
+		})
+	}
+}
+
+func TestTracer_Start(t *testing.T) {
+	unsampled, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		exported int
+	}{
+		{name: "new_trace_is_sampled", ctx: context.Background(), exported: 1},
+		{name: "unsampled_parent", ctx: ContextWithSpanContext(context.Background(), unsampled), exported: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := &recordingExporter{}
+			tracer := NewTracer(exporter)
+
+			_, span := tracer.Start(tt.ctx, "work", SpanKindInternal)
+			if !span.SpanContext.IsValid() {
+				t.Errorf("expected a valid span context, got %+v", span.SpanContext)
+			}
+			span.End()
+			span.End()
+			tracer.Shutdown(context.Background())
+
+			if got := len(exporter.spans()); got != tt.exported {
+				t.Errorf("expected %d exported spans, got %d", tt.exported, got)
+			}
+		})
+	}
+}
+
+func TestTracer_Batching(t *testing.T) {
+	exporter := &recordingExporter{err: errors.New("collector down")}
+	var exportErrs []error
+	var mutex sync.Mutex
+	tracer := NewTracer(exporter,
+		WithBatchSize(2),
+		WithFlushInterval(time.Hour),
+		WithErrorHandler(func(err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			exportErrs = append(exportErrs, err)
+		}),
+	)
+
+	for i := 0; i < 5; i++ {
+		_, span := tracer.Start(context.Background(), "work", SpanKindInternal)
+		span.End()
+	}
+	tracer.Shutdown(context.Background())
+
+	if got := len(exporter.spans()); got != 5 {
+		t.Errorf("expected 5 exported spans, got %d", got)
+	}
+	for _, batch := range exporter.batches {
+		if len(batch) > 2 {
+			t.Errorf("expected batches of at most 2 spans, got %d", len(batch))
+		}
+	}
+	if len(exportErrs) != len(exporter.batches) {
+		t.Errorf("expected %d export errors, got %d", len(exporter.batches), len(exportErrs))
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := &recordingExporter{err: errors.New("collector down")}
+	tracer := NewTracer(exporter, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	_, span := tracer.Start(context.Background(), "work", SpanKindInternal)
+	span.End()
+	tracer.Shutdown(context.Background())
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("failed to decode logged event %q: %v", buf.String(), err)
+	}
+	if event["level"] != "WARN" || event["msg"] != errorMessage || event["error"] != "collector down" {
+		t.Errorf("expected the export error to be logged, got %v", event)
+	}
+}
+
+func TestSpan_NilSafe(t *testing.T) {
+	span := SpanFromContext(context.Background())
+	span.SetAttribute("key", "value")
+	span.SetStatus(StatusError, "failed")
+	span.End()
+}
@@ -0,0 +1,230 @@
+// Package tracing records spans around tool calls and propagates their trace context in the
+// W3C Trace Context format (traceparent and tracestate headers). Spans are handed to a
+// pluggable Exporter, such as the OTLP/JSON exporter of this package.
+//
+// A Tracer's interceptor starts a span for every call. Transports put the caller's trace
+// context into the call's context, so the span joins the caller's trace, and service
+// methods reach the span through SpanFromContext:
+//
+//	func (s *NoteService) Add(ctx context.Context, req AddRequest, reply *AddResponse) error {
+//	    tracing.SpanFromContext(ctx).SetAttribute("note.length", len(req.Text))
+//	    ...
+//	}
+//
+// The TCP JSON-RPC transport does not propagate trace context: its messages have no headers
+// and jsonrpc.Client calls take no context. Calls over TCP are still traced, but each starts
+// a new trace.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Header names of the W3C Trace Context format
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// maxTracestateLength is the longest tracestate that is propagated; longer values are dropped
+const maxTracestateLength = 512
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+// String returns the trace ID as lowercase hex
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether the trace ID is not all zeros
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// String returns the span ID as lowercase hex
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether the span ID is not all zeros
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext is the part of a span that is propagated to other processes
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string // Vendor-specific trace data, passed on unchanged
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as a version 00 traceparent value
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent value. Values of future versions are accepted
+// as long as they start with the fields of version 00.
+func ParseTraceparent(value string) (SpanContext, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, fmt.Errorf("traceparent must have the form 00-<trace-id>-<parent-id>-<flags>")
+	}
+
+	version, err := decodeHex(value[0:2], 1)
+	if err != nil || version[0] == 0xff {
+		return SpanContext{}, fmt.Errorf("invalid traceparent version %q", value[0:2])
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if len(value) > 55 && (version[0] == 0 || value[55] != '-') {
+		return SpanContext{}, fmt.Errorf("traceparent must have the form 00-<trace-id>-<parent-id>-<flags>")
+	}
+
+	var sc SpanContext
+	traceID, err := decodeHex(value[3:35], 16)
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("invalid trace ID: %w", err)
+	}
+	copy(sc.TraceID[:], traceID)
+	spanID, err := decodeHex(value[36:52], 8)
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("invalid parent ID: %w", err)
+	}
+	copy(sc.SpanID[:], spanID)
+	flags, err := decodeHex(value[53:55], 1)
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("invalid trace flags: %w", err)
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("trace ID and parent ID must not be all zeros")
+	}
+	return sc, nil
+}
+
+// decodeHex decodes n bytes of lowercase hex
+func decodeHex(s string, n int) ([]byte, error) {
+	if strings.ToLower(s) != s {
+		return nil, fmt.Errorf("%q is not lowercase hex", s)
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil || len(decoded) != n {
+		return nil, fmt.Errorf("%q is not %d bytes of hex", s, n)
+	}
+	return decoded, nil
+}
+
+// spanContextKey is the context key for the remote span context of the current call
+type spanContextKey struct{}
+
+// spanKey is the context key for the current span
+type spanKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx carrying a span context received from the
+// caller, which spans started from ctx use as their parent
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context to propagate from ctx: that of the current
+// span, or else the one received from the caller
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext, true
+	}
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// ContextWithSpan returns a copy of ctx carrying span as the current span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span, or nil if there is none.
+// The methods of a nil span do nothing, so the result can be used without a check.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Extract returns a copy of ctx carrying the span context of the traceparent and tracestate
+// headers. ctx is returned unchanged if there is no valid traceparent.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = joinTracestate(header.Values(TracestateHeader))
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// Inject sets the traceparent and tracestate headers of an outbound request from the span
+// context of ctx. header is left unchanged if ctx carries none.
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return
+	}
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// joinTracestate combines tracestate headers, which may be split across several lines
+func joinTracestate(values []string) string {
+	var members []string
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				members = append(members, member)
+			}
+		}
+	}
+	tracestate := strings.Join(members, ",")
+	if len(tracestate) > maxTracestateLength {
+		return ""
+	}
+	return tracestate
+}
+
+// newTraceID returns a random trace ID
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// newSpanID returns a random span ID
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
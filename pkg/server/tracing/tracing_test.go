@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    string
+		sampled     bool
+		expectError bool
+	}{
+		{
+			name:     "sampled",
+			value:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expected: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			sampled:  true,
+		},
+		{
+			name:     "not_sampled",
+			value:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			expected: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+		},
+		{
+			name:     "future_version_with_extra_fields",
+			value:    "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-what-the-future-holds",
+			expected: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			sampled:  true,
+		},
+		{name: "empty", value: "", expectError: true},
+		{name: "version_00_with_extra_fields", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", expectError: true},
+		{name: "invalid_version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectError: true},
+		{name: "uppercase_hex", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", expectError: true},
+		{name: "zero_trace_id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", expectError: true},
+		{name: "zero_parent_id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", expectError: true},
+		{name: "short_trace_id", value: "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %+v", sc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := sc.Traceparent(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if sc.Sampled != tt.sampled {
+				t.Errorf("expected sampled %v, got %v", tt.sampled, sc.Sampled)
+			}
+		})
+	}
+}
+
+func TestExtractInject(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     http.Header
+		expected   string
+		tracestate string
+	}{
+		{
+			name: "traceparent_and_tracestate",
+			header: http.Header{
+				"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+				"Tracestate":  {"rojo=00f067aa0ba902b7", "congo=t61rcWkgMzE"},
+			},
+			expected:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			tracestate: "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE",
+		},
+		{
+			name:     "invalid_traceparent",
+			header:   http.Header{"Traceparent": {"00-xyz"}, "Tracestate": {"rojo=00f067aa0ba902b7"}},
+			expected: "",
+		},
+		{
+			name:     "no_headers",
+			header:   http.Header{},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := Extract(context.Background(), tt.header)
+
+			outbound := http.Header{}
+			Inject(ctx, outbound)
+			if got := outbound.Get(TraceparentHeader); got != tt.expected {
+				t.Errorf("expected traceparent %q, got %q", tt.expected, got)
+			}
+			if got := outbound.Get(TracestateHeader); got != tt.tracestate {
+				t.Errorf("expected tracestate %q, got %q", tt.tracestate, got)
+			}
+		})
+	}
+}